@@ -62,13 +62,13 @@ func main() {
 		switch m := msg.(type) {
 		case *types.StreamEvent:
 			// Handle partial updates - text arrives incrementally
-			if m.EventType == "content_block_delta" {
-				if delta := m.Delta; delta != nil {
-					if text, ok := delta["text"].(string); ok {
-						fmt.Print(text)
-						charCount += len(text)
-					}
-				}
+			data, err := m.Data()
+			if err != nil {
+				continue
+			}
+			if delta, ok := data.(*types.ContentBlockDeltaEvent); ok && delta.Delta.Type == types.DeltaText {
+				fmt.Print(delta.Delta.Text)
+				charCount += len(delta.Delta.Text)
 			}
 
 		case *types.AssistantMessage:
@@ -69,32 +69,24 @@ func main() {
 	// Look for plugin information in messages
 	for _, msg := range messages {
 		switch m := msg.(type) {
-		case *types.SystemMessage:
-			if m.Subtype == "init" {
-				fmt.Println("System initialized!")
-
-				// Check for plugins in the system message data
-				if pluginsData, ok := m.Data["plugins"]; ok {
-					if plugins, ok := pluginsData.([]any); ok && len(plugins) > 0 {
-						fmt.Println("\nPlugins loaded:")
-						for _, p := range plugins {
-							if plugin, ok := p.(map[string]any); ok {
-								name := plugin["name"]
-								path := plugin["path"]
-								fmt.Printf("  - %v (path: %v)\n", name, path)
-							}
-						}
-						foundPluginInfo = true
-					}
+		case *types.SystemInitMessage:
+			fmt.Println("System initialized!")
+
+			// Check for plugins in the init message
+			if len(m.Plugins) > 0 {
+				fmt.Println("\nPlugins loaded:")
+				for _, plugin := range m.Plugins {
+					fmt.Printf("  - %s (path: %s)\n", plugin.Name, plugin.Path)
 				}
+				foundPluginInfo = true
+			}
 
-				if !foundPluginInfo {
-					// Plugin might be loaded but not reported in system message
-					fmt.Println("\nNote: Plugin was configured via options.")
-					fmt.Printf("Plugin path: %s\n", pluginPath)
-					fmt.Println("The plugin may be loaded but not visible in system messages.")
-					fmt.Println("Check Claude Code documentation for plugin visibility details.")
-				}
+			if !foundPluginInfo {
+				// Plugin might be loaded but not reported in system message
+				fmt.Println("\nNote: Plugin was configured via options.")
+				fmt.Printf("Plugin path: %s\n", pluginPath)
+				fmt.Println("The plugin may be loaded but not visible in system messages.")
+				fmt.Println("Check Claude Code documentation for plugin visibility details.")
 			}
 
 		case *types.AssistantMessage:
@@ -6,6 +6,8 @@ package parser
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
 )
 
 func FuzzParseMessage_NoPanic(f *testing.F) {
@@ -51,6 +53,9 @@ func FuzzParseContentBlock_NoPanic(f *testing.F) {
 		[]byte(`{"type":"tool_use","id":"tool_1","name":"Bash","input":{"command":"ls"}}`),
 		[]byte(`{"type":"tool_result","tool_use_id":"tool_1","content":"ok"}`),
 		[]byte(`{"type":"tool_result","tool_use_id":"tool_1","content":[{"type":"text","text":"ok"}]}`),
+		[]byte(`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"aGk="}}`),
+		[]byte(`{"type":"document","source":{"type":"content","content":[{"type":"text","text":"a"}]}}`),
+		[]byte(`{"type":"web_search_tool_result","tool_use_id":"s1","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}}`),
 		[]byte(`{"type":"unknown"}`),
 	}
 	for _, seed := range seeds {
@@ -69,13 +74,13 @@ func FuzzParseContentBlock_NoPanic(f *testing.F) {
 
 		defer func() {
 			if r := recover(); r != nil {
-				t.Fatalf("parseContentBlock panicked: %v; input=%q", r, data)
+				t.Fatalf("ParseContentBlock panicked: %v; input=%q", r, data)
 			}
 		}()
 
-		block, err := parseContentBlock(raw)
+		block, err := types.ParseContentBlock(raw)
 		if err == nil && block == nil {
-			t.Fatalf("parseContentBlock returned nil block without error for input=%q", data)
+			t.Fatalf("ParseContentBlock returned nil block without error for input=%q", data)
 		}
 	})
 }
@@ -4,6 +4,7 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/victorarias/claude-agent-sdk-go/types"
@@ -24,9 +25,9 @@ func TestParseMessage_System(t *testing.T) {
 		t.Fatalf("ParseMessage failed: %v", err)
 	}
 
-	sys, ok := msg.(*types.SystemMessage)
+	sys, ok := msg.(*types.SystemInitMessage)
 	if !ok {
-		t.Fatalf("expected *SystemMessage, got %T", msg)
+		t.Fatalf("expected *SystemInitMessage, got %T", msg)
 	}
 
 	if sys.Subtype != "init" {
@@ -533,6 +534,66 @@ func TestParseMessage_SystemTaskNotification(t *testing.T) {
 	}
 }
 
+func TestParseMessage_SystemInit(t *testing.T) {
+	raw := map[string]any{
+		"type":                "system",
+		"subtype":             "init",
+		"uuid":                "sys_init",
+		"session_id":          "sess_init",
+		"claude_code_version": "2.0.30",
+		"cwd":                 "/work",
+		"model":               "claude-sonnet-4-5",
+		"permissionMode":      "acceptEdits",
+		"apiKeySource":        "ANTHROPIC_API_KEY",
+		"output_style":        "default",
+		"tools":               []any{"Bash", "Read", "mcp__calc__add"},
+		"mcp_servers": []any{
+			map[string]any{"name": "calc", "status": "connected"},
+			map[string]any{"name": "github", "status": "failed"},
+		},
+		"slash_commands": []any{"compact", "review"},
+		"agents":         []any{"reviewer"},
+		"skills":         []any{"pdf"},
+		"betas":          []any{"context-1m-2025-08-07"},
+		"plugins":        []any{map[string]any{"name": "lint", "path": "/plugins/lint"}},
+	}
+
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	initMsg, ok := msg.(*types.SystemInitMessage)
+	if !ok {
+		t.Fatalf("expected *SystemInitMessage, got %T", msg)
+	}
+
+	if initMsg.MessageType() != "system" || initMsg.Subtype != "init" || initMsg.UUID != "sys_init" || initMsg.SessionID != "sess_init" {
+		t.Errorf("unexpected metadata: %+v", initMsg.SystemMessage)
+	}
+	if initMsg.ClaudeCodeVersion != "2.0.30" || initMsg.Cwd != "/work" || initMsg.Model != "claude-sonnet-4-5" ||
+		initMsg.PermissionMode != types.PermissionAccept || initMsg.APIKeySource != "ANTHROPIC_API_KEY" || initMsg.OutputStyle != "default" {
+		t.Errorf("unexpected scalar fields: %+v", initMsg)
+	}
+	if len(initMsg.Tools) != 3 || len(initMsg.SlashCommands) != 2 || initMsg.Agents[0] != "reviewer" || initMsg.Skills[0] != "pdf" || initMsg.Betas[0] != "context-1m-2025-08-07" {
+		t.Errorf("unexpected list fields: %+v", initMsg)
+	}
+	if len(initMsg.Plugins) != 1 || initMsg.Plugins[0] != (types.SystemInitPlugin{Name: "lint", Path: "/plugins/lint"}) {
+		t.Errorf("unexpected plugins: %+v", initMsg.Plugins)
+	}
+	if !initMsg.HasTool("mcp__calc__add") || initMsg.HasTool("Write") {
+		t.Error("HasTool does not match the tools list")
+	}
+	if server, ok := initMsg.MCPServer("calc"); !ok || server.Status != "connected" {
+		t.Errorf("unexpected calc server: %+v", server)
+	}
+	if unavailable := initMsg.UnavailableMCPServers(); len(unavailable) != 1 || unavailable[0].Name != "github" {
+		t.Errorf("unexpected unavailable servers: %+v", unavailable)
+	}
+	if initMsg.Data["cwd"] != "/work" {
+		t.Error("expected the raw payload to be kept in Data")
+	}
+}
+
 func TestParseMessage_System_MetadataFields(t *testing.T) {
 	raw := map[string]any{
 		"type":       "system",
@@ -547,9 +608,9 @@ func TestParseMessage_System_MetadataFields(t *testing.T) {
 		t.Fatalf("ParseMessage failed: %v", err)
 	}
 
-	sys, ok := msg.(*types.SystemMessage)
+	sys, ok := msg.(*types.SystemInitMessage)
 	if !ok {
-		t.Fatalf("expected *SystemMessage, got %T", msg)
+		t.Fatalf("expected *SystemInitMessage, got %T", msg)
 	}
 	if sys.UUID != "sys_1" {
 		t.Fatalf("expected uuid=sys_1, got %s", sys.UUID)
@@ -764,7 +825,7 @@ func TestParseContentBlock(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			block, err := parseContentBlock(tt.raw)
+			block, err := types.ParseContentBlock(tt.raw)
 			if err != nil {
 				t.Fatalf("parseContentBlock failed: %v", err)
 			}
@@ -785,7 +846,7 @@ func TestParseContentBlock_ToolResultStructuredContent(t *testing.T) {
 		},
 	}
 
-	block, err := parseContentBlock(raw)
+	block, err := types.ParseContentBlock(raw)
 	if err != nil {
 		t.Fatalf("parseContentBlock failed: %v", err)
 	}
@@ -800,13 +861,106 @@ func TestParseContentBlock_ToolResultStructuredContent(t *testing.T) {
 	}
 }
 
+func TestParseContentBlock_ToolResultSubBlocks(t *testing.T) {
+	raw := map[string]any{
+		"type":        "tool_result",
+		"tool_use_id": "tool_1",
+		"content": []any{
+			map[string]any{"type": "text", "text": "screenshot taken"},
+			map[string]any{"type": "image", "source": map[string]any{
+				"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo=",
+			}},
+		},
+	}
+
+	block, err := types.ParseContentBlock(raw)
+	if err != nil {
+		t.Fatalf("parseContentBlock failed: %v", err)
+	}
+	toolResult := block.(*types.ToolResultBlock)
+	if len(toolResult.ContentBlocks) != 2 {
+		t.Fatalf("expected 2 sub-blocks, got %d", len(toolResult.ContentBlocks))
+	}
+	if toolResult.Text() != "screenshot taken" {
+		t.Errorf("unexpected text %q", toolResult.Text())
+	}
+	image, ok := toolResult.ContentBlocks[1].(*types.ImageBlock)
+	if !ok {
+		t.Fatalf("expected *ImageBlock, got %T", toolResult.ContentBlocks[1])
+	}
+	if image.Source.Type != "base64" || image.Source.MediaType != "image/png" || image.Source.Data != "iVBORw0KGgo=" {
+		t.Errorf("unexpected image source: %+v", image.Source)
+	}
+
+	// A string result is a single text block
+	block, _ = types.ParseContentBlock(map[string]any{"type": "tool_result", "tool_use_id": "tool_2", "content": "ok"})
+	if blocks := block.(*types.ToolResultBlock).ContentBlocks; len(blocks) != 1 || blocks[0].(*types.TextBlock).TextContent != "ok" {
+		t.Errorf("unexpected blocks for string content: %v", blocks)
+	}
+}
+
+func TestParseMessage_AssistantExtendedBlocks(t *testing.T) {
+	raw := map[string]any{
+		"type": "assistant",
+		"message": map[string]any{
+			"model": "claude-sonnet-4-5",
+			"content": []any{
+				map[string]any{"type": "redacted_thinking", "data": "EmwKAhgBEgy"},
+				map[string]any{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": map[string]any{"query": "go generics"}},
+				map[string]any{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": []any{
+					map[string]any{"type": "web_search_result", "url": "https://go.dev/doc", "title": "Go docs", "encrypted_content": "abc", "page_age": "1 day"},
+				}},
+				map[string]any{"type": "code_execution_tool_result", "tool_use_id": "srvtoolu_2", "content": map[string]any{
+					"type": "code_execution_result", "stdout": "4\n", "return_code": float64(0),
+				}},
+				map[string]any{"type": "future_block", "payload": "x"},
+				map[string]any{"type": "text", "text": "done"},
+			},
+		},
+	}
+
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	content := msg.(*types.AssistantMessage).Content
+	if len(content) != 6 {
+		t.Fatalf("expected 6 blocks, got %d", len(content))
+	}
+
+	if redacted, ok := content[0].(*types.RedactedThinkingBlock); !ok || redacted.Data != "EmwKAhgBEgy" {
+		t.Errorf("unexpected redacted_thinking block: %#v", content[0])
+	}
+	if toolUse, ok := content[1].(*types.ServerToolUseBlock); !ok || toolUse.Name != "web_search" || toolUse.Input()["query"] != "go generics" {
+		t.Errorf("unexpected server_tool_use block: %#v", content[1])
+	}
+	search, ok := content[2].(*types.WebSearchToolResultBlock)
+	if !ok || search.ToolUseID != "srvtoolu_1" || len(search.Results) != 1 {
+		t.Fatalf("unexpected web_search_tool_result block: %#v", content[2])
+	}
+	if want := (types.WebSearchResult{URL: "https://go.dev/doc", Title: "Go docs", EncryptedContent: "abc", PageAge: "1 day"}); search.Results[0] != want {
+		t.Errorf("unexpected search result: %+v", search.Results[0])
+	}
+	execution, ok := content[3].(*types.ServerToolResultBlock)
+	if !ok || execution.Type() != "code_execution_tool_result" || execution.Content.(map[string]any)["stdout"] != "4\n" {
+		t.Errorf("unexpected code_execution_tool_result block: %#v", content[3])
+	}
+	unknown, ok := content[4].(*types.UnknownBlock)
+	if !ok || unknown.Type() != "future_block" || string(unknown.Raw) != `{"payload":"x","type":"future_block"}` {
+		t.Errorf("unexpected unknown block: %#v", content[4])
+	}
+	if msg.(*types.AssistantMessage).Text() != "done" {
+		t.Errorf("unexpected text %q", msg.(*types.AssistantMessage).Text())
+	}
+}
+
 func TestParseMessage_AssistantInvalidOnlyContentFails(t *testing.T) {
 	raw := map[string]any{
 		"type": "assistant",
 		"message": map[string]any{
 			"model": "claude-sonnet-4-5",
 			"content": []any{
-				map[string]any{"type": "unknown_block_type"},
+				map[string]any{"text": "block without a type"},
 			},
 		},
 	}
@@ -822,7 +976,7 @@ func TestParseMessage_UserInvalidOnlyContentFails(t *testing.T) {
 		"message": map[string]any{
 			"role": "user",
 			"content": []any{
-				map[string]any{"type": "unknown_block_type"},
+				map[string]any{"text": "block without a type"},
 			},
 		},
 	}
@@ -839,7 +993,7 @@ func TestParseContentBlock_ThinkingWithSignature(t *testing.T) {
 		"signature": "abc123",
 	}
 
-	block, err := parseContentBlock(raw)
+	block, err := types.ParseContentBlock(raw)
 	if err != nil {
 		t.Fatalf("parseContentBlock failed: %v", err)
 	}
@@ -860,11 +1014,28 @@ func TestParseContentBlock_ThinkingWithSignature(t *testing.T) {
 
 func TestParseMessage_UnknownType(t *testing.T) {
 	raw := map[string]any{
-		"type": "unknown_type",
+		"type":       "unknown_type",
+		"uuid":       "u_1",
+		"session_id": "sess_1",
+		"payload":    "x",
 	}
 
-	_, err := ParseMessage(raw)
-	if err == nil {
-		t.Error("expected error for unknown message type")
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	unknown, ok := msg.(*types.UnknownMessage)
+	if !ok {
+		t.Fatalf("expected *UnknownMessage, got %T", msg)
+	}
+	if unknown.MessageType() != "unknown_type" || unknown.UUID != "u_1" || unknown.SessionID != "sess_1" || unknown.Raw["payload"] != "x" {
+		t.Errorf("unexpected unknown message: %+v", unknown)
+	}
+}
+
+func TestParseMessage_MissingType(t *testing.T) {
+	_, err := ParseMessage(map[string]any{"payload": "x"})
+	if !errors.Is(err, types.ErrParse) {
+		t.Errorf("expected a parse error for a message without a type, got %v", err)
 	}
 }
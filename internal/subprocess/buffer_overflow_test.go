@@ -13,6 +13,11 @@ import (
 func TestJSONAccumulatorBufferOverflowReturnsError(t *testing.T) {
 	acc := newJSONAccumulator()
 
+	// Open an object whose string value is continued by the following lines
+	if _, err := acc.addLine(`{"data":"`); err != nil {
+		t.Fatalf("Unexpected error on opening line: %v", err)
+	}
+
 	// Add lines until we exceed the buffer limit
 	// Each line is 1KB, and the limit is 1MB, so we need ~1025 lines to exceed
 	longLine := strings.Repeat("x", 1024)
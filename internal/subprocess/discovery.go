@@ -0,0 +1,210 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// fileStamp identifies one version of a file. Replacing or upgrading the CLI
+// changes its size or modification time, which invalidates cached results.
+type fileStamp struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func stampFile(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{path: path, size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// discoveryKey holds the inputs of a CLI search.
+type discoveryKey struct {
+	explicit string
+	bundled  string
+	pathEnv  string
+}
+
+// versionKey identifies a version check: the CLI file and the command that ran it.
+type versionKey struct {
+	cli     fileStamp
+	command string
+}
+
+// cliCache remembers CLI discovery and version checks for the life of the
+// process, so Connect does not search the filesystem and spawn the CLI for
+// its version every time.
+var cliCache = struct {
+	sync.Mutex
+	found    map[discoveryKey]fileStamp
+	versions map[versionKey]string
+}{
+	found:    make(map[discoveryKey]fileStamp),
+	versions: make(map[versionKey]string),
+}
+
+// findCLICached is findCLI with its result cached until the found file changes.
+func findCLICached(explicitPath, bundledPath string) (string, error) {
+	key := discoveryKey{explicit: explicitPath, bundled: bundledPath, pathEnv: os.Getenv("PATH")}
+
+	cliCache.Lock()
+	cached, ok := cliCache.found[key]
+	cliCache.Unlock()
+	if ok {
+		if current, exists := stampFile(cached.path); exists && current == cached {
+			return cached.path, nil
+		}
+	}
+
+	path, err := findCLI(explicitPath, bundledPath)
+	cliCache.Lock()
+	defer cliCache.Unlock()
+	if err != nil {
+		delete(cliCache.found, key)
+		return "", err
+	}
+	if stamp, exists := stampFile(path); exists {
+		cliCache.found[key] = stamp
+	} else {
+		delete(cliCache.found, key)
+	}
+	return path, nil
+}
+
+// cliVersion runs the version check for the CLI at path through command,
+// reusing an earlier result while the file is unchanged. Failures, such as a
+// timeout, are not cached.
+func cliVersion(path string, command []string) (string, error) {
+	stamp, stamped := stampFile(path)
+	key := versionKey{cli: stamp, command: strings.Join(command, "\x00")}
+	if stamped {
+		cliCache.Lock()
+		version, ok := cliCache.versions[key]
+		cliCache.Unlock()
+		if ok {
+			return version, nil
+		}
+	}
+
+	version, err := checkCLIVersion(command[0], command[1:])
+	if err != nil {
+		return "", err
+	}
+	if stamped {
+		cliCache.Lock()
+		cliCache.versions[key] = version
+		cliCache.Unlock()
+	}
+	return version, nil
+}
+
+// ResolveCLI locates the CLI the way Connect would and reports its version
+// and runtime. Results are cached for the life of the process, so calling it
+// ahead of time makes later connections skip discovery and the version check.
+//
+// A version outside opts.CLIVersionPolicy returns the CLIInfo along with a
+// *types.CLIVersionError. A version that could not be determined is left
+// empty without an error, since Connect proceeds in that case too.
+func ResolveCLI(opts *types.Options) (*types.CLIInfo, error) {
+	if opts == nil {
+		opts = types.DefaultOptions()
+	}
+	path, err := findCLICached(explicitCLIPath(opts), opts.BundledCLIPath)
+	if err != nil {
+		return nil, err
+	}
+	return describeCLI(path, opts)
+}
+
+// describeCLI checks the version of the CLI at path against the options' policy.
+func describeCLI(path string, opts *types.Options) (*types.CLIInfo, error) {
+	info := &types.CLIInfo{Path: path}
+	if isScriptEntryPoint(path) {
+		info.RuntimeExecutable = resolveRuntimeExecutable(opts)
+	}
+
+	version, err := cliVersion(path, buildProcessCommand(path, nil, opts))
+	if err != nil {
+		// Matching the Python SDK, an unknown version does not block the CLI
+		return info, nil
+	}
+	info.Version = version
+	info.Warning, err = checkVersionPolicy(version, opts.CLIVersionPolicy)
+	return info, err
+}
+
+// checkVersionPolicy rejects a version below the policy's minimum, and warns
+// about or rejects one above its maximum tested version.
+func checkVersionPolicy(version string, policy *types.CLIVersionPolicy) (*types.CLIVersionWarning, error) {
+	minimum, maximum, rejectUntested := types.MinimumCLIVersion, types.MaximumTestedCLIVersion, false
+	if policy != nil {
+		if policy.Minimum != "" {
+			minimum = policy.Minimum
+		}
+		if policy.MaximumTested != "" {
+			maximum = policy.MaximumTested
+		}
+		rejectUntested = policy.RejectUntested
+	}
+
+	if !isVersionAtLeast(version, minimum) {
+		return nil, &types.CLIVersionError{InstalledVersion: version, MinimumVersion: minimum}
+	}
+	if !versionExceeds(version, maximum) {
+		return nil, nil
+	}
+	if rejectUntested {
+		return nil, &types.CLIVersionError{InstalledVersion: version, MinimumVersion: minimum, MaximumVersion: maximum}
+	}
+	return &types.CLIVersionWarning{InstalledVersion: version, MaximumVersion: maximum}, nil
+}
+
+// versionExceeds reports whether version is newer than bound, comparing only
+// the components bound has: "2.1.40" does not exceed "2.1". Pre-release
+// suffixes such as "-beta" are ignored.
+func versionExceeds(version, bound string) bool {
+	versionParts := strings.Split(version, ".")
+	for i, part := range strings.Split(bound, ".") {
+		limit, err := strconv.Atoi(part)
+		if err != nil {
+			return false
+		}
+		n := 0
+		if i < len(versionParts) {
+			n = leadingInt(versionParts[i])
+		}
+		if n != limit {
+			return n > limit
+		}
+	}
+	return false
+}
+
+// leadingInt parses the digits at the start of s, or returns 0 if there are none.
+func leadingInt(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// explicitCLIPath returns the CLI path the options name, if any.
+func explicitCLIPath(opts *types.Options) string {
+	if opts.PathToClaudeCodeExecutable != "" {
+		return opts.PathToClaudeCodeExecutable
+	}
+	return opts.CLIPath
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// writeVersionCLI writes a mock CLI reporting version that logs each version
+// check to a file, and returns its path and a function counting the checks.
+func writeVersionCLI(t *testing.T, version string) (string, func() int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script mock CLI is not supported on Windows")
+	}
+	dir := t.TempDir()
+	mockCLI := filepath.Join(dir, "claude")
+	checks := filepath.Join(dir, "checks.log")
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then echo check >> "` + checks + `"; echo "` + version + ` (Claude Code)"; exit 0; fi
+cat >/dev/null
+`
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return mockCLI, func() int {
+		data, _ := os.ReadFile(checks)
+		return strings.Count(string(data), "check")
+	}
+}
+
+func connectCLI(t *testing.T, opts *types.Options) (*SubprocessTransport, error) {
+	t.Helper()
+	transport := NewStreamingTransport(opts)
+	t.Cleanup(func() { transport.Close() })
+	return transport, transport.Connect(context.Background())
+}
+
+func TestResolveCLI_CachesVersionCheck(t *testing.T) {
+	mockCLI, checks := writeVersionCLI(t, "2.0.5")
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+
+	info, err := ResolveCLI(opts)
+	if err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	want := types.CLIInfo{Path: mockCLI, Version: "2.0.5"}
+	if *info != want {
+		t.Errorf("unexpected CLI info: got %+v, want %+v", *info, want)
+	}
+
+	for i := 0; i < 3; i++ {
+		transport, err := connectCLI(t, opts)
+		if err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		transport.Close()
+	}
+	if n := checks(); n != 1 {
+		t.Errorf("expected the version to be checked once, got %d checks", n)
+	}
+}
+
+func TestResolveCLI_ChangedFileIsRechecked(t *testing.T) {
+	mockCLI, checks := writeVersionCLI(t, "2.0.5")
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+
+	if _, err := ResolveCLI(opts); err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	// An upgrade replaces the file
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(mockCLI, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveCLI(opts); err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	if n := checks(); n != 2 {
+		t.Errorf("expected a changed CLI to be checked again, got %d checks", n)
+	}
+
+	os.Remove(mockCLI)
+	if _, err := ResolveCLI(opts); !errors.Is(err, types.ErrCLINotFound) {
+		t.Errorf("expected ErrCLINotFound for a removed CLI, got %v", err)
+	}
+}
+
+func TestResolveCLI_CachesPathSearch(t *testing.T) {
+	mockCLI, checks := writeVersionCLI(t, "2.0.5")
+	t.Setenv("PATH", filepath.Dir(mockCLI))
+
+	info, err := ResolveCLI(types.DefaultOptions())
+	if err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	if info.Path != mockCLI {
+		t.Errorf("expected %s from PATH, got %s", mockCLI, info.Path)
+	}
+	if _, err := ResolveCLI(types.DefaultOptions()); err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	if n := checks(); n != 1 {
+		t.Errorf("expected one version check, got %d", n)
+	}
+}
+
+func TestResolveCLI_RuntimeExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script mock runtime is not supported on Windows")
+	}
+	dir := t.TempDir()
+	entry := filepath.Join(dir, "cli.js")
+	if err := os.WriteFile(entry, []byte("// cli\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runtimePath := filepath.Join(dir, "fake-node")
+	if err := os.WriteFile(runtimePath, []byte("#!/bin/sh\necho \"2.0.9 (Claude Code)\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = entry
+	opts.Executable = runtimePath
+	info, err := ResolveCLI(opts)
+	if err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	want := types.CLIInfo{Path: entry, Version: "2.0.9", RuntimeExecutable: runtimePath}
+	if *info != want {
+		t.Errorf("unexpected CLI info: got %+v, want %+v", *info, want)
+	}
+}
+
+func TestConnect_UntestedVersionWarns(t *testing.T) {
+	mockCLI, _ := writeVersionCLI(t, "9.0.0")
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+
+	transport, err := connectCLI(t, opts)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	select {
+	case err := <-transport.Errors():
+		var warning *types.CLIVersionWarning
+		if !errors.As(err, &warning) || warning.InstalledVersion != "9.0.0" || warning.MaximumVersion != types.MaximumTestedCLIVersion {
+			t.Fatalf("expected *types.CLIVersionWarning, got %v", err)
+		}
+	default:
+		t.Fatal("expected a version warning on the errors channel")
+	}
+}
+
+func TestConnect_VersionPolicy(t *testing.T) {
+	mockCLI, _ := writeVersionCLI(t, "2.3.1")
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	types.ApplyOptions(opts, types.WithCLIVersionPolicy(types.CLIVersionPolicy{MaximumTested: "2.2", RejectUntested: true}))
+
+	transport, err := connectCLI(t, opts)
+	var versionErr *types.CLIVersionError
+	if !errors.As(err, &versionErr) || versionErr.MaximumVersion != "2.2" {
+		t.Fatalf("expected *types.CLIVersionError for an untested version, got %v", err)
+	}
+	if transport.IsReady() {
+		t.Error("transport should not be ready")
+	}
+
+	// ResolveCLI reports what it found along with the error
+	info, err := ResolveCLI(opts)
+	if !errors.Is(err, types.ErrCLIVersion) || info == nil || info.Version != "2.3.1" {
+		t.Fatalf("expected CLI info with ErrCLIVersion, got %+v, %v", info, err)
+	}
+}
+
+func TestCheckVersionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		policy  *types.CLIVersionPolicy
+		warn    bool
+		reject  bool
+	}{
+		{"default in range", "2.0.14", nil, false, false},
+		{"default tested line", "2.1.40", nil, false, false},
+		{"default newer minor", "2.2.0", nil, false, false},
+		{"default untested major", "3.0.0", nil, true, false},
+		{"default too old", "1.9.9", nil, false, true},
+		{"pre-release above maximum", "3.0.0-beta", nil, true, false},
+		{"custom minimum", "2.0.5", &types.CLIVersionPolicy{Minimum: "2.0.10"}, false, true},
+		{"custom maximum", "2.0.5", &types.CLIVersionPolicy{MaximumTested: "2.0.4"}, true, false},
+		{"reject untested", "2.0.5", &types.CLIVersionPolicy{MaximumTested: "2.0.4", RejectUntested: true}, false, true},
+		{"reject untested in range", "2.0.4", &types.CLIVersionPolicy{MaximumTested: "2.0.4", RejectUntested: true}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := checkVersionPolicy(tt.version, tt.policy)
+			if (warning != nil) != tt.warn {
+				t.Errorf("warning = %v, want warning %v", warning, tt.warn)
+			}
+			if (err != nil) != tt.reject {
+				t.Errorf("err = %v, want rejection %v", err, tt.reject)
+			}
+			if err != nil && !errors.Is(err, types.ErrCLIVersion) {
+				t.Errorf("expected ErrCLIVersion, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// stderrTailSize bounds how much stderr output is kept for ProcessError.
+const stderrTailSize = 16 * 1024
+
+// tailBuffer keeps the last max bytes of line-oriented output.
+type tailBuffer struct {
+	mu   sync.Mutex
+	max  int
+	data []byte
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+// writeLine appends a line, discarding the oldest whole lines beyond the limit.
+func (b *tailBuffer) writeLine(line []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(line) >= b.max {
+		b.data = append(b.data[:0], line[len(line)-b.max+1:]...)
+		b.data = append(b.data, '\n')
+		return
+	}
+	b.data = append(b.data, line...)
+	b.data = append(b.data, '\n')
+	if excess := len(b.data) - b.max; excess > 0 {
+		cut := excess
+		if i := bytes.IndexByte(b.data[excess:], '\n'); i >= 0 {
+			cut = excess + i + 1
+		}
+		b.data = append(b.data[:0], b.data[cut:]...)
+	}
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(bytes.TrimRight(b.data, "\n"))
+}
+
+// waitForExit reaps the process and, once its output has been drained, records
+// an unexpected exit as a *types.ProcessError, or as a *types.ResourceLimitError
+// wrapping one if a resource limit explains it. It closes the wire channel,
+// and through it the messages channel, last, so consumers that see either
+// closed can rely on ExitError.
+func (t *SubprocessTransport) waitForExit() {
+	defer t.wg.Done()
+	defer close(t.wire)
+	defer close(t.exited)
+
+	waitErr := t.cmd.Wait()
+	close(t.reaped)
+	// Processes the CLI started may still hold its output open
+	t.readers.Wait()
+
+	t.closeMu.Lock()
+	closing := t.closed
+	t.closeMu.Unlock()
+	if closing || t.ctx.Err() != nil {
+		// Terminated by Close or context cancellation, not a failure
+		return
+	}
+
+	procErr := newProcessError(t.cmd.ProcessState, waitErr, t.stderrTail.String())
+	if procErr == nil {
+		return
+	}
+	exitErr := t.limitViolation(procErr)
+	t.exitMu.Lock()
+	t.exitError = exitErr
+	t.exitMu.Unlock()
+	t.sendError(exitErr)
+}
+
+// newProcessError describes a failed process, or returns nil if it exited cleanly.
+func newProcessError(state *os.ProcessState, waitErr error, stderr string) *types.ProcessError {
+	if state == nil {
+		if waitErr == nil {
+			return nil
+		}
+		return &types.ProcessError{ExitCode: -1, Stderr: joinNonEmpty(waitErr.Error(), stderr)}
+	}
+	if state.Success() {
+		return nil
+	}
+
+	procErr := &types.ProcessError{ExitCode: state.ExitCode(), Stderr: stderr}
+	if status, ok := state.Sys().(interface {
+		Signaled() bool
+		Signal() syscall.Signal
+	}); ok && status.Signaled() {
+		procErr.Signal = status.Signal()
+	}
+	return procErr
+}
+
+func joinNonEmpty(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n" + b
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func TestTailBuffer(t *testing.T) {
+	buf := newTailBuffer(16)
+	buf.writeLine([]byte("first line"))
+	buf.writeLine([]byte("second"))
+	buf.writeLine([]byte("third"))
+	if got := buf.String(); got != "second\nthird" {
+		t.Fatalf("String() = %q", got)
+	}
+
+	buf.writeLine([]byte(strings.Repeat("x", 40)))
+	if got := buf.String(); got != strings.Repeat("x", 15) {
+		t.Fatalf("String() after long line = %q", got)
+	}
+}
+
+// startExitingCLI connects a transport to a mock CLI running body.
+func startExitingCLI(t *testing.T, body string) *SubprocessTransport {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script mock CLI")
+	}
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-v\" ]; then echo \"2.0.0 (Claude Code)\"; exit 0; fi\n" + body + "\n"
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	transport := NewStreamingTransport(opts)
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+// waitForMessagesClosed drains messages until the channel closes.
+func waitForMessagesClosed(t *testing.T, transport *SubprocessTransport) []map[string]any {
+	t.Helper()
+	var messages []map[string]any
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-transport.Messages():
+			if !ok {
+				return messages
+			}
+			messages = append(messages, msg)
+		case <-timeout:
+			t.Fatal("messages channel was not closed")
+		}
+	}
+}
+
+func TestProcessExit_NonZeroExitCode(t *testing.T) {
+	transport := startExitingCLI(t, `echo '{"type":"system","subtype":"init"}'
+echo "loading config" >&2
+echo "Error: invalid API key" >&2
+exit 3`)
+
+	messages := waitForMessagesClosed(t, transport)
+	if len(messages) != 1 {
+		t.Fatalf("expected the message printed before exit, got %v", messages)
+	}
+
+	var procErr *types.ProcessError
+	if !errors.As(transport.ExitError(), &procErr) {
+		t.Fatalf("ExitError() = %v, want ProcessError", transport.ExitError())
+	}
+	if procErr.ExitCode != 3 || procErr.Signal != nil {
+		t.Fatalf("unexpected exit: %+v", procErr)
+	}
+	if procErr.Stderr != "loading config\nError: invalid API key" {
+		t.Fatalf("Stderr = %q", procErr.Stderr)
+	}
+
+	select {
+	case err := <-transport.Errors():
+		if err != transport.ExitError() {
+			t.Fatalf("Errors() delivered %v, want the ExitError", err)
+		}
+	default:
+		t.Fatal("expected ProcessError on Errors()")
+	}
+}
+
+func TestProcessExit_Signal(t *testing.T) {
+	transport := startExitingCLI(t, `echo "about to crash" >&2
+kill -KILL $$`)
+
+	waitForMessagesClosed(t, transport)
+
+	var procErr *types.ProcessError
+	if !errors.As(transport.ExitError(), &procErr) {
+		t.Fatalf("ExitError() = %v, want ProcessError", transport.ExitError())
+	}
+	if procErr.Signal != syscall.SIGKILL || procErr.ExitCode != -1 {
+		t.Fatalf("unexpected exit: %+v", procErr)
+	}
+	if !strings.Contains(procErr.Error(), "terminated by signal killed: about to crash") {
+		t.Fatalf("Error() = %q", procErr.Error())
+	}
+}
+
+func TestProcessExit_CleanExit(t *testing.T) {
+	transport := startExitingCLI(t, `echo '{"type":"result","subtype":"success"}'`)
+
+	waitForMessagesClosed(t, transport)
+	if err := transport.ExitError(); err != nil {
+		t.Fatalf("ExitError() = %v, want nil", err)
+	}
+}
+
+func TestProcessExit_CloseIsNotAnError(t *testing.T) {
+	transport := startExitingCLI(t, `cat > /dev/null`)
+
+	if err := transport.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.ExitError(); err != nil {
+		t.Fatalf("ExitError() after Close = %v, want nil", err)
+	}
+	select {
+	case err := <-transport.Errors():
+		t.Fatalf("unexpected error after Close: %v", err)
+	default:
+	}
+}
+
+func TestProcessExit_StderrCallbackStillInvoked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script mock CLI")
+	}
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-v\" ]; then echo \"2.0.0 (Claude Code)\"; exit 0; fi\necho warn >&2\nexit 1\n"
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	transport := NewStreamingTransport(opts)
+
+	lines := make(chan string, 10)
+	transport.SetStderrCallback(func(line string) { lines <- line })
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+
+	waitForMessagesClosed(t, transport)
+	select {
+	case line := <-lines:
+		if line != "warn" {
+			t.Fatalf("callback got %q", line)
+		}
+	default:
+		t.Fatal("stderr callback was not invoked")
+	}
+}
@@ -110,8 +110,8 @@ func TestJSONAccumulatorWrapsJSONErrors(t *testing.T) {
 func TestBufferOverflowIsNotWrappedInJSONDecodeError(t *testing.T) {
 	acc := newJSONAccumulatorWithLimit(100)
 
-	// Add data that exceeds buffer limit
-	_, err := acc.addLine("x" + "y" + "z" + "a" + "b" + "c" + "d" + "e" + "f" + "g" + "h" + "i" + "j" + "k" + "l" + "m" + "n" + "o" + "p" + "q" + "r" + "s" + "t" + "u" + "v" + "w" + "x" + "y" + "z")
+	// Add data that exceeds buffer limit, starting inside an unterminated object
+	_, err := acc.addLine(`{"data":"` + "x" + "y" + "z" + "a" + "b" + "c" + "d" + "e" + "f" + "g" + "h" + "i" + "j" + "k" + "l" + "m" + "n" + "o" + "p" + "q" + "r" + "s" + "t" + "u" + "v" + "w" + "x" + "y" + "z")
 
 	if err == nil {
 		// Try with a definitely oversized line
@@ -0,0 +1,285 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+// scanResult is what a jsonScanner has seen so far.
+type scanResult int
+
+const (
+	// scanIncomplete means the input is a valid but unfinished JSON value.
+	scanIncomplete scanResult = iota
+	// scanComplete means the input is one whole value, possibly followed by whitespace.
+	scanComplete
+	// scanInvalid means no further input can make the value valid.
+	scanInvalid
+)
+
+type scanState int
+
+const (
+	scanBeginValue scanState = iota
+	scanBeginValueOrClose
+	scanBeginKeyOrClose
+	scanBeginKey
+	scanColon
+	scanAfterValue
+	scanEnd
+	scanString
+	scanEscape
+	scanUnicode
+	scanLiteral
+	scanNeg
+	scanZero
+	scanInt
+	scanDot
+	scanFrac
+	scanExp
+	scanExpSign
+	scanExpDigits
+	scanError
+)
+
+// jsonScanner checks JSON syntax incrementally, so a message arriving over
+// many lines is scanned once in total instead of once per line. Its verdicts
+// match encoding/json for values that begin with an object or array.
+type jsonScanner struct {
+	state   scanState
+	stack   []byte // open containers, '{' or '['
+	key     bool   // the current string is an object key
+	literal string // the rest of true, false or null
+	hex     int    // hex digits left in a \u escape
+}
+
+// feed scans data as the continuation of everything fed before.
+func (s *jsonScanner) feed(data string) scanResult {
+	for i := 0; i < len(data) && s.state != scanError; i++ {
+		s.step(data[i])
+	}
+	switch s.state {
+	case scanError:
+		return scanInvalid
+	case scanEnd:
+		return scanComplete
+	}
+	return scanIncomplete
+}
+
+func (s *jsonScanner) reset() {
+	*s = jsonScanner{stack: s.stack[:0]}
+}
+
+func (s *jsonScanner) step(c byte) {
+	switch s.state {
+	case scanBeginValue:
+		s.beginValue(c)
+	case scanBeginValueOrClose:
+		if c == ']' {
+			s.close()
+			return
+		}
+		s.beginValue(c)
+	case scanBeginKeyOrClose:
+		if c == '}' {
+			s.close()
+			return
+		}
+		s.beginKey(c)
+	case scanBeginKey:
+		s.beginKey(c)
+	case scanColon:
+		switch {
+		case c == ':':
+			s.state = scanBeginValue
+		case !isJSONSpace(c):
+			s.state = scanError
+		}
+	case scanAfterValue:
+		s.afterValue(c)
+	case scanEnd:
+		if !isJSONSpace(c) {
+			s.state = scanError
+		}
+	case scanString:
+		switch {
+		case c == '"':
+			if s.key {
+				s.state = scanColon
+			} else {
+				s.endValue()
+			}
+		case c == '\\':
+			s.state = scanEscape
+		case c < 0x20:
+			s.state = scanError
+		}
+	case scanEscape:
+		switch c {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			s.state = scanString
+		case 'u':
+			s.hex = 4
+			s.state = scanUnicode
+		default:
+			s.state = scanError
+		}
+	case scanUnicode:
+		if !isHexDigit(c) {
+			s.state = scanError
+			return
+		}
+		if s.hex--; s.hex == 0 {
+			s.state = scanString
+		}
+	case scanLiteral:
+		if c != s.literal[0] {
+			s.state = scanError
+			return
+		}
+		if s.literal = s.literal[1:]; s.literal == "" {
+			s.endValue()
+		}
+	case scanNeg:
+		switch {
+		case c == '0':
+			s.state = scanZero
+		case '1' <= c && c <= '9':
+			s.state = scanInt
+		default:
+			s.state = scanError
+		}
+	case scanZero, scanInt:
+		switch {
+		case s.state == scanInt && isDigit(c):
+		case c == '.':
+			s.state = scanDot
+		case c == 'e' || c == 'E':
+			s.state = scanExp
+		default:
+			s.endNumber(c)
+		}
+	case scanDot:
+		s.digit(c, scanFrac)
+	case scanFrac:
+		switch {
+		case isDigit(c):
+		case c == 'e' || c == 'E':
+			s.state = scanExp
+		default:
+			s.endNumber(c)
+		}
+	case scanExp:
+		if c == '+' || c == '-' {
+			s.state = scanExpSign
+			return
+		}
+		s.digit(c, scanExpDigits)
+	case scanExpSign:
+		s.digit(c, scanExpDigits)
+	case scanExpDigits:
+		if !isDigit(c) {
+			s.endNumber(c)
+		}
+	}
+}
+
+func (s *jsonScanner) beginValue(c byte) {
+	switch {
+	case isJSONSpace(c):
+	case c == '{':
+		s.stack = append(s.stack, c)
+		s.state = scanBeginKeyOrClose
+	case c == '[':
+		s.stack = append(s.stack, c)
+		s.state = scanBeginValueOrClose
+	case c == '"':
+		s.key = false
+		s.state = scanString
+	case c == '-':
+		s.state = scanNeg
+	case c == '0':
+		s.state = scanZero
+	case '1' <= c && c <= '9':
+		s.state = scanInt
+	case c == 't':
+		s.beginLiteral("rue")
+	case c == 'f':
+		s.beginLiteral("alse")
+	case c == 'n':
+		s.beginLiteral("ull")
+	default:
+		s.state = scanError
+	}
+}
+
+func (s *jsonScanner) beginLiteral(rest string) {
+	s.literal = rest
+	s.state = scanLiteral
+}
+
+func (s *jsonScanner) beginKey(c byte) {
+	switch {
+	case c == '"':
+		s.key = true
+		s.state = scanString
+	case !isJSONSpace(c):
+		s.state = scanError
+	}
+}
+
+func (s *jsonScanner) afterValue(c byte) {
+	if isJSONSpace(c) {
+		return
+	}
+	open := s.stack[len(s.stack)-1]
+	switch {
+	case c == ',' && open == '{':
+		s.state = scanBeginKey
+	case c == ',':
+		s.state = scanBeginValue
+	case c == '}' && open == '{', c == ']' && open == '[':
+		s.close()
+	default:
+		s.state = scanError
+	}
+}
+
+// digit requires a digit and moves to next.
+func (s *jsonScanner) digit(c byte, next scanState) {
+	if isDigit(c) {
+		s.state = next
+	} else {
+		s.state = scanError
+	}
+}
+
+// endNumber finishes a number at c, which belongs to what follows it.
+func (s *jsonScanner) endNumber(c byte) {
+	s.endValue()
+	s.step(c)
+}
+
+func (s *jsonScanner) close() {
+	s.stack = s.stack[:len(s.stack)-1]
+	s.endValue()
+}
+
+func (s *jsonScanner) endValue() {
+	if len(s.stack) == 0 {
+		s.state = scanEnd
+	} else {
+		s.state = scanAfterValue
+	}
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
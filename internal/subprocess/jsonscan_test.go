@@ -0,0 +1,87 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// referenceScan classifies data with encoding/json.
+func referenceScan(data string) scanResult {
+	if json.Valid([]byte(data)) {
+		return scanComplete
+	}
+	var v any
+	if err := json.NewDecoder(strings.NewReader(data)).Decode(&v); errors.Is(err, io.ErrUnexpectedEOF) {
+		return scanIncomplete
+	}
+	return scanInvalid
+}
+
+func TestJSONScanner_MatchesEncodingJSON(t *testing.T) {
+	inputs := []string{
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"hi \"there\"\né"}]}}`,
+		`{"n":[0,-1,2.5,-0.25e+10,3E-2,10e5],"b":[true,false,null],"e":{},"a":[]}  `,
+		` { "k" : [ { } , [ ] , "" ] } `,
+		`[1,{"a":[2,[3]]}]`,
+		`{"a":1,}`,
+		`{"a" 1}`,
+		`{"a":01}`,
+		`{"a":1.}`,
+		`{"a":-}`,
+		`{"a":1e}`,
+		`{"a":tru e}`,
+		`{"a":nul}`,
+		`{"a":"\x"}`,
+		`{"a":"\u12g4"}`,
+		"{\"a\":\"tab\there\"}",
+		`{"a":[1,2}`,
+		`{"a":1}]`,
+		`{"a":1} {"b":2}`,
+		`{"a":1} x`,
+		`{1:2}`,
+		`{"a":1,,"b":2}`,
+		`[,1]`,
+		`{"a":[1 2]}`,
+	}
+	for _, input := range inputs {
+		for end := 1; end <= len(input); end++ {
+			prefix := input[:end]
+			if strings.TrimSpace(prefix) == "" {
+				continue
+			}
+			want := referenceScan(prefix)
+
+			var whole jsonScanner
+			if got := whole.feed(prefix); got != want {
+				t.Fatalf("feed(%q) = %v, want %v", prefix, got, want)
+			}
+
+			// Fed in pieces, the verdict is the same
+			var pieces jsonScanner
+			var got scanResult
+			for i := 0; i < len(prefix); i += 3 {
+				got = pieces.feed(prefix[i:min(i+3, len(prefix))])
+			}
+			if got != want {
+				t.Fatalf("feed(%q) in pieces = %v, want %v", prefix, got, want)
+			}
+		}
+	}
+}
+
+func TestJSONScanner_Reset(t *testing.T) {
+	var scan jsonScanner
+	if got := scan.feed(`{"a":[1,`); got != scanIncomplete {
+		t.Fatalf("expected incomplete, got %v", got)
+	}
+	scan.reset()
+	if got := scan.feed(`{"b":2}`); got != scanComplete {
+		t.Fatalf("expected complete after reset, got %v", got)
+	}
+}
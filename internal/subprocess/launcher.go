@@ -0,0 +1,44 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+// Launcher runs the CLI somewhere other than directly on the host, such as
+// inside a container or on a remote machine, by wrapping its command line.
+//
+// SubprocessTransport still starts, reads and reaps the wrapping process
+// exactly as it would the CLI itself, so message parsing, ProcessError
+// reporting and Close behave the same.
+type Launcher interface {
+	// CLIPath returns the CLI entry point in the launched environment. It
+	// replaces CLI discovery and the version check on the host.
+	CLIPath() string
+
+	// Command wraps the CLI command line. env holds the variables the SDK sets
+	// for a local CLI (PWD, Options.Env and SDK-required variables), which the
+	// launcher must forward. It returns the host command to start and the
+	// host environment to start it with; a nil environment inherits the
+	// current process's.
+	//
+	// Options.Cwd is not applied to the host process; the launcher is
+	// responsible for running the CLI there.
+	Command(cli []string, env []string) (args []string, hostEnv []string, err error)
+
+	// Cleanup runs after the wrapping process has been reaped, to release
+	// anything that can outlive it, such as a container.
+	Cleanup() error
+}
+
+// SetLauncher makes Connect start the CLI through l. It must be called before Connect.
+func (t *SubprocessTransport) SetLauncher(l Launcher) {
+	t.launcher = l
+}
+
+// resolveCLIPath returns the launcher's CLI path, or discovers the CLI on the host.
+func (t *SubprocessTransport) resolveCLIPath() (string, error) {
+	if t.launcher != nil {
+		return t.launcher.CLIPath(), nil
+	}
+
+	return findCLICached(explicitCLIPath(t.options), t.options.BundledCLIPath)
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// validateResourceLimits checks Options.ResourceLimits before the CLI starts.
+func (t *SubprocessTransport) validateResourceLimits() error {
+	limits := t.options.ResourceLimits
+	if limits == nil {
+		return nil
+	}
+	if t.launcher != nil {
+		return &types.ResourceLimitError{Cause: errors.New("not supported with a launcher; limit the launched environment instead")}
+	}
+	if limits.Nice < -20 || limits.Nice > 19 {
+		return &types.ResourceLimitError{Resource: "nice", Limit: strconv.Itoa(limits.Nice), Cause: errors.New("must be between -20 and 19")}
+	}
+	if limits.CPUTime < 0 {
+		return &types.ResourceLimitError{Resource: "cpu_time", Limit: limits.CPUTime.String(), Cause: errors.New("must not be negative")}
+	}
+	if limits.Cgroup != "" && !filepath.IsAbs(limits.Cgroup) {
+		return &types.ResourceLimitError{Resource: "cgroup", Limit: limits.Cgroup, Cause: errors.New("must be an absolute path")}
+	}
+	return nil
+}
+
+// gateOpen is written to the gate pipe once the limits are in place.
+const gateOpen = "go"
+
+// limitGate holds a started CLI back until its resource limits are applied,
+// so no process it starts can run outside them.
+type limitGate struct {
+	release []func() // Close the parent's copies of what the child inherited
+	ready   *os.File // Write end of the pipe the child waits on; nil if it does not wait
+}
+
+// open lets the CLI run.
+func (g *limitGate) open() error {
+	if g.ready == nil {
+		return nil
+	}
+	_, err := g.ready.WriteString(gateOpen + "\n")
+	if closeErr := g.ready.Close(); err == nil {
+		err = closeErr
+	}
+	g.ready = nil
+	return err
+}
+
+// close releases the gate. A CLI still waiting on it exits without running.
+func (g *limitGate) close() {
+	for _, release := range g.release {
+		release()
+	}
+	if g.ready != nil {
+		g.ready.Close()
+	}
+}
+
+// ResourceLimits returns the resource limits in effect for the CLI process,
+// read back after they were applied, with zero meaning unlimited. It returns
+// nil if Options.ResourceLimits is unset or the CLI has not been started.
+func (t *SubprocessTransport) ResourceLimits() *types.ResourceLimits {
+	t.closeMu.Lock()
+	defer t.closeMu.Unlock()
+
+	if t.limits == nil {
+		return nil
+	}
+	limits := *t.limits
+	return &limits
+}
+
+// cpuSeconds rounds a CPU time limit up to whole seconds.
+func cpuSeconds(d time.Duration) uint64 {
+	return uint64((d + time.Second - 1) / time.Second)
+}
+
+// cgroupOOMKills returns the cgroup's "oom_kill" count from memory.events,
+// or 0 if it cannot be read.
+func cgroupOOMKills(cgroup string) int {
+	data, err := os.ReadFile(filepath.Join(cgroup, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if count, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			n, _ := strconv.Atoi(count)
+			return n
+		}
+	}
+	return 0
+}
+
+// stderrMentions reports whether stderr contains any of the lower-case phrases.
+func stderrMentions(stderr string, phrases ...string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, phrase := range phrases {
+		if strings.Contains(stderr, phrase) {
+			return true
+		}
+	}
+	return false
+}
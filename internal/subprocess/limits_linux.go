@@ -0,0 +1,152 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+const rlimInfinity = ^uint64(0)
+
+// gateScript runs the CLI once the gate opens. The shell keeps its PID when it
+// execs the CLI, so limits set on the waiting shell apply to the CLI. If the
+// pipe closes without the go-ahead the CLI never runs.
+const gateScript = `IFS= read -r ok <&%[1]d; exec %[1]d<&-; [ "$ok" = ` + gateOpen + ` ] || exit 125; exec "$@"`
+
+// prepareResourceLimits arranges for cmd to start inside the configured
+// cgroup and, when rlimits or a nice level are set, to wait until
+// applyResourceLimits has set them before running the CLI, so nothing the CLI
+// runs escapes them. The returned gate must be opened once the limits are
+// applied and closed when Connect returns.
+func prepareResourceLimits(cmd *exec.Cmd, limits *types.ResourceLimits) (*limitGate, error) {
+	gate := &limitGate{}
+	if limits.Cgroup != "" {
+		fd, err := syscall.Open(limits.Cgroup, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return nil, &types.ResourceLimitError{Resource: "cgroup", Limit: limits.Cgroup, Cause: err}
+		}
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = fd
+		gate.release = append(gate.release, func() { syscall.Close(fd) })
+	}
+
+	if limits.AddressSpace == 0 && limits.CPUTime == 0 && limits.OpenFiles == 0 && limits.Nice == 0 {
+		return gate, nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		gate.close()
+		return nil, &types.ResourceLimitError{Cause: err}
+	}
+	fd := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+	gate.release = append(gate.release, func() { r.Close() })
+	gate.ready = w
+	// exec.Cmd keeps a failed lookup in cmd.Err, so Start still reports it
+	cmd.Args = append([]string{"sh", "-c", fmt.Sprintf(gateScript, fd), "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+	return gate, nil
+}
+
+// applyResourceLimits sets the rlimits and nice level of the started CLI
+// while it waits on the gate, and reads back the limits in effect.
+func applyResourceLimits(process *os.Process, limits *types.ResourceLimits) (*types.ResourceLimits, error) {
+	pid := process.Pid
+
+	setrlimit := func(resource, limit string, which int, soft, hard uint64) error {
+		err := prlimit(pid, which, &syscall.Rlimit{Cur: soft, Max: hard}, nil)
+		if err != nil && err != syscall.ESRCH {
+			return &types.ResourceLimitError{Resource: resource, Limit: limit, Cause: err}
+		}
+		return nil
+	}
+	if n := limits.AddressSpace; n > 0 {
+		if err := setrlimit("address_space", strconv.FormatUint(n, 10), syscall.RLIMIT_AS, n, n); err != nil {
+			return nil, err
+		}
+	}
+	if limits.CPUTime > 0 {
+		// A hard limit one second above the soft one delivers SIGXCPU first
+		n := cpuSeconds(limits.CPUTime)
+		if err := setrlimit("cpu_time", limits.CPUTime.String(), syscall.RLIMIT_CPU, n, n+1); err != nil {
+			return nil, err
+		}
+	}
+	if n := limits.OpenFiles; n > 0 {
+		if err := setrlimit("open_files", strconv.FormatUint(n, 10), syscall.RLIMIT_NOFILE, n, n); err != nil {
+			return nil, err
+		}
+	}
+	if limits.Nice != 0 {
+		// PRIO_PGRP covers every thread of every process in the CLI's group
+		err := syscall.Setpriority(syscall.PRIO_PGRP, pid, limits.Nice)
+		if err != nil && err != syscall.ESRCH {
+			return nil, &types.ResourceLimitError{Resource: "nice", Limit: strconv.Itoa(limits.Nice), Cause: err}
+		}
+	}
+
+	applied := &types.ResourceLimits{Cgroup: limits.Cgroup}
+	getrlimit := func(which int) uint64 {
+		var rl syscall.Rlimit
+		if prlimit(pid, which, nil, &rl) != nil || rl.Cur == rlimInfinity {
+			return 0
+		}
+		return rl.Cur
+	}
+	applied.AddressSpace = getrlimit(syscall.RLIMIT_AS)
+	applied.CPUTime = time.Duration(getrlimit(syscall.RLIMIT_CPU)) * time.Second
+	applied.OpenFiles = getrlimit(syscall.RLIMIT_NOFILE)
+	// The raw getpriority system call returns 20 - nice
+	if prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid); err == nil {
+		applied.Nice = 20 - prio
+	}
+	return applied, nil
+}
+
+func prlimit(pid, resource int, newLimit, oldLimit *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(newLimit)), uintptr(unsafe.Pointer(oldLimit)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// limitViolation returns a *types.ResourceLimitError if the CLI's failed exit
+// is explained by a configured limit, or procErr otherwise.
+func (t *SubprocessTransport) limitViolation(procErr *types.ProcessError) error {
+	limits := t.options.ResourceLimits
+	if limits == nil {
+		return procErr
+	}
+	exceeded := func(resource, limit string) error {
+		return &types.ResourceLimitError{Resource: resource, Limit: limit, Process: procErr}
+	}
+
+	state := t.cmd.ProcessState
+	switch {
+	case limits.CPUTime > 0 && (procErr.Signal == syscall.SIGXCPU ||
+		procErr.Signal == syscall.SIGKILL && state != nil &&
+			state.UserTime()+state.SystemTime() >= time.Duration(cpuSeconds(limits.CPUTime))*time.Second):
+		return exceeded("cpu_time", limits.CPUTime.String())
+	case limits.Cgroup != "" && cgroupOOMKills(limits.Cgroup) > t.oomKills:
+		return exceeded("memory", limits.Cgroup)
+	case limits.AddressSpace > 0 && stderrMentions(procErr.Stderr, "out of memory", "cannot allocate memory"):
+		return exceeded("address_space", strconv.FormatUint(limits.AddressSpace, 10))
+	case limits.OpenFiles > 0 && stderrMentions(procErr.Stderr, "too many open files", "emfile"):
+		return exceeded("open_files", strconv.FormatUint(limits.OpenFiles, 10))
+	}
+	return procErr
+}
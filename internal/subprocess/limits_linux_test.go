@@ -0,0 +1,269 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// startLimitedCLI starts a mock CLI running body with the given limits.
+func startLimitedCLI(t *testing.T, body string, limits types.ResourceLimits) (*SubprocessTransport, error) {
+	t.Helper()
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+` + body
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	opts.ShutdownTimeout = time.Second
+	types.ApplyOptions(opts, types.WithResourceLimits(limits))
+	transport := NewStreamingTransport(opts)
+	t.Cleanup(func() { transport.Close() })
+	return transport, transport.Connect(context.Background())
+}
+
+func TestResourceLimits_AppliedAndReported(t *testing.T) {
+	// The CLI reports its limits once it reads input, as the real CLI would only act then
+	transport, err := startLimitedCLI(t, `read -r line
+printf '{"type":"limits","open_files":"%s","address_space":"%s","cpu":"%s"}\n' "$(ulimit -n)" "$(ulimit -v)" "$(ulimit -t)"
+cat >/dev/null
+`, types.ResourceLimits{
+		OpenFiles:    256,
+		AddressSpace: 4 << 30,
+		CPUTime:      1500 * time.Millisecond,
+		Nice:         5,
+	})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	applied := transport.ResourceLimits()
+	if applied == nil {
+		t.Fatal("expected applied limits to be reported")
+	}
+	want := types.ResourceLimits{OpenFiles: 256, AddressSpace: 4 << 30, CPUTime: 2 * time.Second, Nice: 5}
+	if *applied != want {
+		t.Errorf("unexpected applied limits: got %+v, want %+v", *applied, want)
+	}
+
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	select {
+	case msg := <-transport.Messages():
+		// ulimit -v reports kilobytes
+		if msg["open_files"] != "256" || msg["address_space"] != "4194304" || msg["cpu"] != "2" {
+			t.Errorf("limits not in effect in the CLI: %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestResourceLimits_InEffectBeforeCLIRuns(t *testing.T) {
+	// A child started before the CLI reads anything must inherit the limits
+	transport, err := startLimitedCLI(t, `(printf '{"type":"limits","open_files":"%s","nice":"%s"}\n' "$(ulimit -n)" "$(cut -d' ' -f19 /proc/self/stat)") &
+cat >/dev/null
+`, types.ResourceLimits{OpenFiles: 128, Nice: 7})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	select {
+	case msg := <-transport.Messages():
+		if msg["open_files"] != "128" || msg["nice"] != "7" {
+			t.Errorf("limits not in effect at startup: %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestResourceLimits_NotReportedWhenUnset(t *testing.T) {
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(mockCLI, []byte("#!/bin/sh\ncat >/dev/null\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAUDE_AGENT_SDK_SKIP_VERSION_CHECK", "1")
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	transport := NewStreamingTransport(opts)
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	if limits := transport.ResourceLimits(); limits != nil {
+		t.Errorf("expected no limits, got %+v", limits)
+	}
+}
+
+func TestResourceLimits_CPUTimeExceeded(t *testing.T) {
+	transport, err := startLimitedCLI(t, `read -r line
+while :; do :; done
+`, types.ResourceLimits{CPUTime: time.Second})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for range transport.Messages() {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("CLI was not stopped by its CPU time limit")
+	}
+
+	err = transport.ExitError()
+	var limitErr *types.ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Resource != "cpu_time" {
+		t.Fatalf("expected cpu_time *types.ResourceLimitError, got %v", err)
+	}
+	if !errors.Is(err, types.ErrResourceLimit) || !errors.Is(err, types.ErrProcess) {
+		t.Errorf("expected error to match ErrResourceLimit and ErrProcess: %v", err)
+	}
+	var procErr *types.ProcessError
+	if !errors.As(err, &procErr) || procErr.Signal == nil {
+		t.Errorf("expected the wrapped process error to carry the signal: %v", err)
+	}
+}
+
+func TestResourceLimits_AddressSpaceExceeded(t *testing.T) {
+	// The CLI fails an allocation and reports it the way node does
+	transport, err := startLimitedCLI(t, `read -r line
+echo "FATAL ERROR: Reached heap limit Allocation failed - JavaScript heap out of memory" >&2
+exit 134
+`, types.ResourceLimits{AddressSpace: 8 << 30})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for range transport.Messages() {
+	}
+
+	var limitErr *types.ResourceLimitError
+	if !errors.As(transport.ExitError(), &limitErr) || limitErr.Resource != "address_space" {
+		t.Fatalf("expected address_space *types.ResourceLimitError, got %v", transport.ExitError())
+	}
+	if limitErr.Process.ExitCode != 134 {
+		t.Errorf("expected exit code 134, got %d", limitErr.Process.ExitCode)
+	}
+}
+
+func TestResourceLimits_UnrelatedFailureStaysProcessError(t *testing.T) {
+	transport, err := startLimitedCLI(t, `read -r line
+echo "fatal: bad config" >&2
+exit 1
+`, types.ResourceLimits{OpenFiles: 512})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for range transport.Messages() {
+	}
+
+	err = transport.ExitError()
+	if errors.Is(err, types.ErrResourceLimit) {
+		t.Fatalf("unrelated failure attributed to a limit: %v", err)
+	}
+	var procErr *types.ProcessError
+	if !errors.As(err, &procErr) || procErr.ExitCode != 1 {
+		t.Fatalf("expected *types.ProcessError, got %v", err)
+	}
+}
+
+func TestResourceLimits_ConnectErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   types.ResourceLimits
+		resource string
+	}{
+		{"nice out of range", types.ResourceLimits{Nice: 20}, "nice"},
+		{"negative cpu time", types.ResourceLimits{CPUTime: -time.Second}, "cpu_time"},
+		{"relative cgroup", types.ResourceLimits{Cgroup: "agents/a"}, "cgroup"},
+		{"missing cgroup", types.ResourceLimits{Cgroup: "/sys/fs/cgroup/does-not-exist/agent"}, "cgroup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := startLimitedCLI(t, "cat >/dev/null\n", tt.limits)
+			var limitErr *types.ResourceLimitError
+			if !errors.As(err, &limitErr) || limitErr.Resource != tt.resource {
+				t.Fatalf("expected %s *types.ResourceLimitError, got %v", tt.resource, err)
+			}
+			if !errors.Is(err, types.ErrResourceLimit) {
+				t.Errorf("expected errors.Is(err, ErrResourceLimit)")
+			}
+			if transport.IsReady() {
+				t.Error("transport should not be ready")
+			}
+		})
+	}
+}
+
+func TestResourceLimits_PrivilegedNiceRejected(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may raise its priority")
+	}
+	_, err := startLimitedCLI(t, "cat >/dev/null\n", types.ResourceLimits{Nice: -10})
+	var limitErr *types.ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Resource != "nice" || !errors.Is(err, syscall.EACCES) {
+		t.Fatalf("expected nice *types.ResourceLimitError with EACCES, got %v", err)
+	}
+}
+
+func TestResourceLimits_CgroupPlacement(t *testing.T) {
+	root := "/sys/fs/cgroup"
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		t.Skip("cgroup v2 is not mounted at /sys/fs/cgroup")
+	}
+	cgroup := filepath.Join(root, "claude-agent-sdk-test-"+strings.ReplaceAll(t.Name(), "/", "-"))
+	if err := os.Mkdir(cgroup, 0755); err != nil {
+		t.Skipf("cannot create a cgroup: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(cgroup) })
+
+	transport, err := startLimitedCLI(t, `read -r line
+printf '{"type":"cgroup","path":"%s"}\n' "$(grep '^0::' /proc/self/cgroup | cut -d: -f3)"
+cat >/dev/null
+`, types.ResourceLimits{Cgroup: cgroup})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	select {
+	case msg := <-transport.Messages():
+		if want := strings.TrimPrefix(cgroup, root); msg["path"] != want {
+			t.Errorf("expected CLI in cgroup %q, got %v", want, msg["path"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	transport.Close()
+}
@@ -0,0 +1,28 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+//go:build !linux
+
+package subprocess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func prepareResourceLimits(cmd *exec.Cmd, limits *types.ResourceLimits) (*limitGate, error) {
+	return nil, &types.ResourceLimitError{Cause: fmt.Errorf("%w on %s", errors.ErrUnsupported, runtime.GOOS)}
+}
+
+func applyResourceLimits(process *os.Process, limits *types.ResourceLimits) (*types.ResourceLimits, error) {
+	return nil, &types.ResourceLimitError{Cause: fmt.Errorf("%w on %s", errors.ErrUnsupported, runtime.GOOS)}
+}
+
+func (t *SubprocessTransport) limitViolation(procErr *types.ProcessError) error {
+	return procErr
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+const (
+	// readChunkSize is the size of the bufio.Reader window. Lines longer than this
+	// are assembled from several chunks, so it does not bound message size.
+	readChunkSize = 64 * 1024
+
+	// overflowPrefixSize is how much of an oversized line is kept to identify it.
+	overflowPrefixSize = 64 * 1024
+)
+
+// lineReader reads newline-delimited messages of up to limit bytes without
+// holding more than limit bytes in memory. Oversized lines are skipped and
+// reported as *types.BufferOverflowError.
+type lineReader struct {
+	r     *bufio.Reader
+	limit int
+	buf   []byte
+}
+
+func newLineReader(r io.Reader, limit int) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, readChunkSize), limit: limit}
+}
+
+// next returns the next line without its line terminator. The returned slice is
+// only valid until the following call. It returns io.EOF after the last line.
+func (lr *lineReader) next() ([]byte, error) {
+	lr.buf = lr.buf[:0]
+	size := 0
+	overflow := false
+
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		size += len(chunk)
+		switch {
+		case !overflow && len(lr.buf)+len(chunk) <= lr.limit+1: // +1 for the newline
+			lr.buf = append(lr.buf, chunk...)
+		case !overflow:
+			overflow = true
+			if len(lr.buf) < overflowPrefixSize {
+				lr.buf = append(lr.buf, chunk[:min(len(chunk), overflowPrefixSize-len(lr.buf))]...)
+			}
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if errors.Is(err, io.EOF) && size == 0 {
+			return nil, io.EOF
+		}
+
+		size -= len(chunk) - len(trimEOL(chunk))
+		if overflow {
+			return nil, &types.BufferOverflowError{Limit: lr.limit, Size: size, MessageType: sniffMessageType(lr.buf)}
+		}
+		line := trimEOL(lr.buf)
+		if len(line) > lr.limit {
+			return nil, &types.BufferOverflowError{Limit: lr.limit, Size: size, MessageType: sniffMessageType(line)}
+		}
+		return line, nil
+	}
+}
+
+// trimEOL removes a trailing "\n" or "\r\n".
+func trimEOL(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
+
+// sniffMessageType returns the top-level "type" field of a possibly truncated
+// JSON object, or "" if it does not appear in data.
+func sniffMessageType(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ""
+	}
+	for {
+		key, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if key == "type" {
+			value, err := dec.Token()
+			if err != nil {
+				return ""
+			}
+			s, _ := value.(string)
+			return s
+		}
+		if err := skipJSONValue(dec); err != nil {
+			return ""
+		}
+	}
+}
+
+// skipJSONValue consumes one value, including nested objects and arrays.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
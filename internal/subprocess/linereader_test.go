@@ -0,0 +1,214 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func TestLineReader_Lines(t *testing.T) {
+	input := "{\"a\":1}\r\n\n{\"b\":2}\n" + strings.Repeat("x", readChunkSize*3) + "\nlast"
+	reader := newLineReader(strings.NewReader(input), readChunkSize*4)
+
+	want := []string{`{"a":1}`, "", `{"b":2}`, strings.Repeat("x", readChunkSize*3), "last"}
+	for i, w := range want {
+		line, err := reader.next()
+		if err != nil {
+			t.Fatalf("line %d: unexpected error: %v", i, err)
+		}
+		if string(line) != w {
+			t.Fatalf("line %d = %q (len %d), want len %d", i, line[:min(len(line), 20)], len(line), len(w))
+		}
+	}
+	if _, err := reader.next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineReader_OverflowSkipsLine(t *testing.T) {
+	big := `{"type":"user","message":{"content":"` + strings.Repeat("x", readChunkSize*2) + `"}}`
+	input := `{"type":"system"}` + "\n" + big + "\n" + `{"type":"result"}` + "\n"
+	reader := newLineReader(strings.NewReader(input), 1000)
+
+	line, err := reader.next()
+	if err != nil || string(line) != `{"type":"system"}` {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+
+	_, err = reader.next()
+	var overflow *types.BufferOverflowError
+	if !errors.As(err, &overflow) {
+		t.Fatalf("expected BufferOverflowError, got %v", err)
+	}
+	if !errors.Is(err, types.ErrBufferOverflow) {
+		t.Fatal("expected errors.Is(err, ErrBufferOverflow)")
+	}
+	if overflow.Limit != 1000 || overflow.Size != len(big) || overflow.MessageType != "user" {
+		t.Fatalf("unexpected overflow: %+v", overflow)
+	}
+
+	line, err = reader.next()
+	if err != nil || string(line) != `{"type":"result"}` {
+		t.Fatalf("line after overflow = %q, %v", line, err)
+	}
+}
+
+func TestLineReader_ExactLimit(t *testing.T) {
+	line := strings.Repeat("y", 100)
+	reader := newLineReader(strings.NewReader(line+"\n"+line+"z"), 100)
+
+	got, err := reader.next()
+	if err != nil || string(got) != line {
+		t.Fatalf("line at limit: len %d, err %v", len(got), err)
+	}
+	if _, err := reader.next(); !errors.Is(err, types.ErrBufferOverflow) {
+		t.Fatalf("expected overflow for unterminated line over limit, got %v", err)
+	}
+}
+
+func TestSniffMessageType(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"type":"assistant","message":{}}`, "assistant"},
+		{`{"message":{"type":"nested","content":[{"type":"text"}]},"type":"user"}`, "user"},
+		{`{"uuid":"1","type":"result","result":"tru`, "result"},
+		{`{"message":{"content":"trunc`, ""},
+		{`not json`, ""},
+		{`["type","user"]`, ""},
+	}
+	for _, tt := range tests {
+		if got := sniffMessageType([]byte(tt.data)); got != tt.want {
+			t.Errorf("sniffMessageType(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestJSONAccumulator_OverflowCarriesMessageType(t *testing.T) {
+	acc := newJSONAccumulatorWithLimit(50)
+	if _, err := acc.addLine(`{"type":"assistant","message":`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := acc.addLine(`{"content":"` + strings.Repeat("x", 50) + `"}}`)
+	var overflow *types.BufferOverflowError
+	if !errors.As(err, &overflow) {
+		t.Fatalf("expected BufferOverflowError, got %v", err)
+	}
+	if overflow.MessageType != "assistant" {
+		t.Fatalf("MessageType = %q, want assistant", overflow.MessageType)
+	}
+}
+
+// writeLargeOutputCLI creates a mock CLI that prints a user message with a
+// payload of the given size followed by a result message.
+func writeLargeOutputCLI(t *testing.T, payloadSize int) string {
+	t.Helper()
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "-v" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+printf '{"type":"user","message":{"role":"user","content":"'
+head -c %d /dev/zero | tr '\0' 'x'
+printf '"}}\n{"type":"result","subtype":"success"}\n'
+cat > /dev/null
+`, payloadSize)
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return mockCLI
+}
+
+func collectMessages(t *testing.T, transport *SubprocessTransport) ([]map[string]any, []error) {
+	t.Helper()
+	var messages []map[string]any
+	var errs []error
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg, ok := <-transport.Messages():
+			if !ok {
+				return messages, errs
+			}
+			messages = append(messages, msg)
+			if msg["type"] == "result" {
+				// Drain a pending error, if any, before returning
+				select {
+				case err := <-transport.Errors():
+					errs = append(errs, err)
+				default:
+				}
+				return messages, errs
+			}
+		case err := <-transport.Errors():
+			errs = append(errs, err)
+		case <-timeout:
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+}
+
+func TestReadMessages_HonorsMaxBufferSize(t *testing.T) {
+	opts := types.DefaultOptions()
+	opts.CLIPath = writeLargeOutputCLI(t, 3*1024*1024)
+	opts.MaxBufferSize = 4 * 1024 * 1024
+	transport := NewStreamingTransport(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	messages, errs := collectMessages(t, transport)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(messages) != 2 || messages[0]["type"] != "user" {
+		t.Fatalf("expected user and result messages, got %d", len(messages))
+	}
+	content := messages[0]["message"].(map[string]any)["content"].(string)
+	if len(content) != 3*1024*1024 {
+		t.Fatalf("content length = %d", len(content))
+	}
+}
+
+func TestReadMessages_OverflowReportsTypeAndContinues(t *testing.T) {
+	opts := types.DefaultOptions()
+	opts.CLIPath = writeLargeOutputCLI(t, 200*1024)
+	opts.MaxBufferSize = 64 * 1024
+	transport := NewStreamingTransport(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	messages, errs := collectMessages(t, transport)
+	if len(messages) != 1 || messages[0]["type"] != "result" {
+		t.Fatalf("expected only the result message, got %v", messages)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected one overflow error, got %v", errs)
+	}
+	var overflow *types.BufferOverflowError
+	if !errors.As(errs[0], &overflow) {
+		t.Fatalf("expected BufferOverflowError, got %v", errs[0])
+	}
+	if overflow.MessageType != "user" || overflow.Limit != 64*1024 {
+		t.Fatalf("unexpected overflow: %+v", overflow)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+//go:build !unix
+
+package subprocess
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Without process groups only the CLI itself can be signalled, and there is
+// no graceful termination signal; both steps kill the process.
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func terminateProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+func processGroupAlive(p *os.Process) bool {
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+//go:build unix
+
+package subprocess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// startGroupCLI starts a mock CLI that runs body after spawning a background
+// "sleep" child detached from its pipes, like an MCP server or a Bash tool
+// command would be. It returns the transport and the child's PID.
+func startGroupCLI(t *testing.T, ctx context.Context, body string, timeout time.Duration) (*SubprocessTransport, int) {
+	t.Helper()
+	dir := t.TempDir()
+	mockCLI := filepath.Join(dir, "claude")
+	pidFile := filepath.Join(dir, "child.pid")
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+sleep 60 </dev/null >/dev/null 2>&1 &
+echo $! > "` + pidFile + `.tmp" && mv "` + pidFile + `.tmp" "` + pidFile + `"
+` + body
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	opts.ShutdownTimeout = timeout
+	transport := NewStreamingTransport(opts)
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(pidFile)
+		if err == nil {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("bad child pid %q", data)
+			}
+			t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+			return transport, pid
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("mock CLI did not start its child")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitGone waits for pid to exit and be reaped.
+func waitGone(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d is still running", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func closeWithin(t *testing.T, transport *SubprocessTransport, limit time.Duration) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- transport.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	case <-time.After(limit):
+		t.Fatalf("Close did not return within %v", limit)
+	}
+}
+
+func TestClose_StopsOrphanedChildren(t *testing.T) {
+	// The CLI exits on EOF but leaves its child running
+	transport, child := startGroupCLI(t, context.Background(), `cat >/dev/null`, time.Second)
+
+	closeWithin(t, transport, 5*time.Second)
+	waitGone(t, child)
+}
+
+func TestClose_ReturnsWhenCLIExitsWithOutputHeld(t *testing.T) {
+	// The CLI exits on EOF, but a child it left behind still holds stdout
+	transport, child := startGroupCLI(t, context.Background(), `sleep 60 &
+cat >/dev/null`, 30*time.Second)
+
+	// Well under the shutdown timeout
+	closeWithin(t, transport, 10*time.Second)
+	waitGone(t, child)
+}
+
+func TestClose_EscalatesToSIGKILL(t *testing.T) {
+	// The CLI ignores EOF and SIGTERM, and so does its child
+	transport, child := startGroupCLI(t, context.Background(), `trap '' TERM
+while true; do sleep 0.05; done`, 200*time.Millisecond)
+
+	// Wait for the trap to be installed before the child is checked
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	closeWithin(t, transport, 5*time.Second)
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected Close to wait for both timeouts, took %v", elapsed)
+	}
+	waitGone(t, child)
+}
+
+func TestClose_TerminatesGroupAfterTimeout(t *testing.T) {
+	// The CLI ignores EOF but exits on SIGTERM
+	transport, child := startGroupCLI(t, context.Background(), `trap 'exit 0' TERM
+while true; do sleep 0.05; done`, 200*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	closeWithin(t, transport, 5*time.Second)
+	waitGone(t, child)
+}
+
+func TestKill_KillsProcessGroup(t *testing.T) {
+	transport, child := startGroupCLI(t, context.Background(), `cat >/dev/null`, time.Second)
+
+	if err := transport.Kill(); err != nil {
+		t.Fatalf("Kill failed: %v", err)
+	}
+	waitGone(t, child)
+}
+
+func TestContextCancel_KillsProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	transport, child := startGroupCLI(t, ctx, `cat >/dev/null`, time.Second)
+
+	cancel()
+	waitGone(t, child)
+	for range transport.Messages() {
+	}
+	if err := transport.ExitError(); err != nil {
+		t.Errorf("cancellation should not be reported as a failure, got %v", err)
+	}
+}
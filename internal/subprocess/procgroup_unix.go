@@ -0,0 +1,48 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+//go:build unix
+
+package subprocess
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in a new process group led by the CLI, so that
+// the processes it spawns (MCP servers, Bash tool commands) can be signalled
+// together with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup sends SIGTERM to every process in p's group.
+func terminateProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGTERM)
+}
+
+// killProcessGroup sends SIGKILL to every process in p's group.
+func killProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
+
+// processGroupAlive reports whether any process is left in p's group. The
+// group outlives its leader, so this still works after p has been reaped.
+func processGroupAlive(p *os.Process) bool {
+	return syscall.Kill(-p.Pid, 0) == nil
+}
+
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	if err := syscall.Kill(-p.Pid, sig); err != nil {
+		if err == syscall.ESRCH {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package subprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+type accumulatorStep struct {
+	line    string
+	msgType string // Expected "type" of the returned message, "" for none
+	errLine string // Expected JSONDecodeError.Line, "" for no error
+}
+
+func runAccumulatorSteps(t *testing.T, acc *jsonAccumulator, steps []accumulatorStep) {
+	t.Helper()
+	for i, step := range steps {
+		msg, err := acc.addLine(step.line)
+
+		gotType := ""
+		if msg != nil {
+			gotType, _ = msg["type"].(string)
+		}
+		if gotType != step.msgType || (msg == nil) != (step.msgType == "") {
+			t.Fatalf("step %d (%q): message = %v, want type %q", i, step.line, msg, step.msgType)
+		}
+
+		if step.errLine == "" {
+			if err != nil {
+				t.Fatalf("step %d (%q): unexpected error: %v", i, step.line, err)
+			}
+			continue
+		}
+		var decodeErr *types.JSONDecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("step %d (%q): expected JSONDecodeError, got %v", i, step.line, err)
+		}
+		if decodeErr.Line != step.errLine {
+			t.Fatalf("step %d (%q): JSONDecodeError.Line = %q, want %q", i, step.line, decodeErr.Line, step.errLine)
+		}
+	}
+}
+
+func TestJSONAccumulator_Resync(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []accumulatorStep
+	}{
+		{
+			name: "stray log line between messages",
+			steps: []accumulatorStep{
+				{line: `{"type":"system"}`, msgType: "system"},
+				{line: `[plugin] loaded 3 hooks`, errLine: `[plugin] loaded 3 hooks`},
+				{line: `{"type":"assistant"}`, msgType: "assistant"},
+			},
+		},
+		{
+			name: "consecutive garbage lines are reported separately",
+			steps: []accumulatorStep{
+				{line: `warning: a`, errLine: `warning: a`},
+				{line: `warning: b`, errLine: `warning: b`},
+				{line: `{"type":"result"}`, msgType: "result"},
+			},
+		},
+		{
+			name: "truncated message followed by a complete one",
+			steps: []accumulatorStep{
+				{line: `{"type":"assistant","message":{"content":"hel`},
+				{line: `{"type":"result"}`, msgType: "result", errLine: `{"type":"assistant","message":{"content":"hel`},
+			},
+		},
+		{
+			name: "malformed object",
+			steps: []accumulatorStep{
+				{line: `{not json}`, errLine: `{not json}`},
+				{line: `{"type":"user"}`, msgType: "user"},
+			},
+		},
+		{
+			name: "trailing garbage after an object",
+			steps: []accumulatorStep{
+				{line: `{"type":"user"} extra`, errLine: `{"type":"user"} extra`},
+				{line: `{"type":"user"}`, msgType: "user"},
+			},
+		},
+		{
+			name: "fragment poisoned by a non-JSON continuation",
+			steps: []accumulatorStep{
+				{line: `{"type":"user",`},
+				{line: `oops`, errLine: `{"type":"user",oops`},
+				{line: `{"type":"user"}`, msgType: "user"},
+			},
+		},
+		{
+			name: "fragment abandoned for a new incomplete object",
+			steps: []accumulatorStep{
+				{line: `{"type":"a","x":"1`},
+				{line: `{"type":"b",`, errLine: `{"type":"a","x":"1`},
+				{line: `"k":1}`, msgType: "b"},
+			},
+		},
+		{
+			name: "legitimate split object still accumulates",
+			steps: []accumulatorStep{
+				{line: `{"type":"assistant",`},
+				{line: `"message":{"content":"hi"}}`, msgType: "assistant"},
+			},
+		},
+		{
+			name: "blank lines are ignored",
+			steps: []accumulatorStep{
+				{line: `   `},
+				{line: `{"type":"user"}`, msgType: "user"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runAccumulatorSteps(t, newJSONAccumulatorWithLimit(1000), tt.steps)
+		})
+	}
+}
+
+func TestJSONAccumulator_SkipsRemainderOfOverflowedMessage(t *testing.T) {
+	acc := newJSONAccumulatorWithLimit(40)
+	if _, err := acc.addLine(`{"type":"assistant","text":"`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := acc.addLine(strings.Repeat("x", 30))
+	var overflow *types.BufferOverflowError
+	if !errors.As(err, &overflow) || overflow.MessageType != "assistant" {
+		t.Fatalf("expected overflow for assistant message, got %v", err)
+	}
+
+	// Fragments of the dropped message are skipped silently
+	for _, line := range []string{strings.Repeat("y", 30), `"}`} {
+		if msg, err := acc.addLine(line); msg != nil || err != nil {
+			t.Fatalf("expected %q to be skipped, got %v, %v", line, msg, err)
+		}
+	}
+
+	msg, err := acc.addLine(`{"type":"result"}`)
+	if err != nil || msg["type"] != "result" {
+		t.Fatalf("expected result after resync, got %v, %v", msg, err)
+	}
+
+	// Garbage after resync is reported again
+	if _, err := acc.addLine("log line"); !errors.Is(err, types.ErrParse) {
+		t.Fatalf("expected decode error after resync, got %v", err)
+	}
+}
+
+func TestReadMessages_ResyncsAfterStrayOutput(t *testing.T) {
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	// The sleep keeps the two decode errors from racing for the one-slot errors channel
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"system","subtype":"init"}'
+echo 'Debugger listening on ws://127.0.0.1:9229'
+sleep 0.2
+printf '{"type":"assistant","message":{"content":"trunc\n'
+echo '{"type":"result","subtype":"success"}'
+cat > /dev/null
+`
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	transport := NewStreamingTransport(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	var msgTypes []string
+	var decodeErrors []string
+	timeout := time.After(5 * time.Second)
+	for len(msgTypes) < 2 || len(decodeErrors) < 2 {
+		select {
+		case msg := <-transport.Messages():
+			msgTypes = append(msgTypes, msg["type"].(string))
+		case err := <-transport.Errors():
+			var decodeErr *types.JSONDecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decodeErrors = append(decodeErrors, decodeErr.Line)
+		case <-timeout:
+			t.Fatalf("timed out: messages %v, errors %q", msgTypes, decodeErrors)
+		}
+	}
+
+	if strings.Join(msgTypes, ",") != "system,result" {
+		t.Fatalf("messages = %v", msgTypes)
+	}
+	if decodeErrors[0] != "Debugger listening on ws://127.0.0.1:9229" || decodeErrors[1] != `{"type":"assistant","message":{"content":"trunc` {
+		t.Fatalf("decode errors = %q", decodeErrors)
+	}
+}
+
+// checkAccumulatorLine feeds line and verifies the accumulator's invariants.
+func checkAccumulatorLine(t *testing.T, acc *jsonAccumulator, line string) {
+	msg, err := acc.addLine(line)
+
+	if err != nil {
+		var decodeErr *types.JSONDecodeError
+		var overflow *types.BufferOverflowError
+		if !errors.As(err, &decodeErr) && !errors.As(err, &overflow) {
+			t.Fatalf("addLine(%q) returned unexpected error type %T: %v", line, err, err)
+		}
+	}
+	if acc.buffer.Len() > acc.limit {
+		t.Fatalf("buffer length %d exceeds limit %d", acc.buffer.Len(), acc.limit)
+	}
+
+	// A line that is a complete object on its own is never lost
+	var alone map[string]any
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") && len(line) <= acc.limit && json.Unmarshal([]byte(trimmed), &alone) == nil && msg == nil {
+		t.Fatalf("complete object %q was not returned (err %v)", line, err)
+	}
+}
+
+func FuzzJSONAccumulator(f *testing.F) {
+	f.Add("{\"type\":\"user\"}\nnot json\n{\"type\":\"result\"}")
+	f.Add("{\"a\":\n{\"b\":1}\n{\"c\":2}")
+	f.Add("{\"a\":[\n{\"b\":1}\n]}")
+	f.Add("{\"type\":\"x\",\"s\":\"\n\\\"}\n\"}")
+	f.Add("{{{{\n}}}}\n{\"ok\":true}")
+	f.Add("{\"text\":\"" + strings.Repeat("x", 300) + "\"}\nmore\n{\"type\":\"y\"}")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		acc := newJSONAccumulatorWithLimit(256)
+		for _, line := range strings.Split(input, "\n") {
+			checkAccumulatorLine(t, acc, line)
+		}
+
+		// Whatever came before, the next well-formed message is delivered
+		msg, _ := acc.addLine(`{"type":"sentinel"}`)
+		if msg == nil || msg["type"] != "sentinel" {
+			t.Fatalf("accumulator did not resynchronize after %q", input)
+		}
+	})
+}
+
+func TestJSONAccumulator_Raw(t *testing.T) {
+	acc := newJSONAccumulator()
+	line := `{"type":"result", "total_cost_usd":1.0,"id":12345678901234567890}`
+	if msg, err := acc.addLine(line + "\n"); msg == nil || err != nil {
+		t.Fatalf("addLine = %v, %v", msg, err)
+	}
+	if got := string(acc.raw()); got != line {
+		t.Errorf("raw = %s, want %s", got, line)
+	}
+
+	// Objects split across lines keep every fragment
+	if msg, err := acc.addLine(`{"type":"assistant","text":"a`); msg != nil || err != nil {
+		t.Fatalf("addLine = %v, %v", msg, err)
+	}
+	if msg, err := acc.addLine(`b"}`); msg == nil || err != nil {
+		t.Fatalf("addLine = %v, %v", msg, err)
+	}
+	if got := string(acc.raw()); got != `{"type":"assistant","text":"ab"}` {
+		t.Errorf("raw = %s", got)
+	}
+}
+
+func TestReadMessages_WireMessages(t *testing.T) {
+	mockCLI := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"result","subtype":"success","total_cost_usd":1.0,"z":1,"a":2}'
+cat > /dev/null
+`
+	if err := os.WriteFile(mockCLI, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := types.DefaultOptions()
+	opts.CLIPath = mockCLI
+	transport := NewStreamingTransport(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	select {
+	case msg := <-transport.WireMessages():
+		if msg.Message["type"] != "result" {
+			t.Errorf("unexpected message: %v", msg.Message)
+		}
+		if want := `{"type":"result","subtype":"success","total_cost_usd":1.0,"z":1,"a":2}`; string(msg.JSON) != want {
+			t.Errorf("JSON = %s, want %s", msg.JSON, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+	}
+}
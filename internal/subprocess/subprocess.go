@@ -15,15 +15,17 @@
 package subprocess
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -331,6 +333,28 @@ func buildProcessCommand(entrypoint string, cliArgs []string, opts *types.Option
 	return cmd
 }
 
+// SameProcess reports whether a and b start identical CLI processes, so that a
+// streaming transport connected with a can serve a client configured with b.
+// Options that only affect the SDK side, such as hooks and CanUseTool, are
+// not compared.
+func SameProcess(a, b *types.Options) bool {
+	return slices.Equal(processSignature(a), processSignature(b))
+}
+
+func processSignature(opts *types.Options) []string {
+	cli := buildCommand("claude", "", opts, true)
+	sig := buildProcessCommand(cli[0], cli[1:], opts)
+	sig = append(sig,
+		"\x00",
+		opts.CLIPath, opts.PathToClaudeCodeExecutable, opts.BundledCLIPath, opts.Cwd, opts.Executable,
+		fmt.Sprint(opts.ExecutableArgs, opts.MaxBufferSize, opts.ShutdownTimeout),
+	)
+	if opts.ResourceLimits != nil {
+		sig = append(sig, fmt.Sprintf("%+v", *opts.ResourceLimits))
+	}
+	return append(sig, slices.Sorted(slices.Values(sdkEnvironment(opts)))...)
+}
+
 // WindowsMaxCommandLength is the maximum command line length on Windows.
 const WindowsMaxCommandLength = 8191
 
@@ -642,8 +666,9 @@ func buildCommand(cliPath, prompt string, opts *types.Options, streaming bool) [
 		}
 	}
 
-	// Extra args (escape hatch for future CLI flags)
-	for flag, value := range opts.ExtraArgs {
+	// Extra args (escape hatch for future CLI flags), in a stable order
+	for _, flag := range slices.Sorted(maps.Keys(opts.ExtraArgs)) {
+		value := opts.ExtraArgs[flag]
 		if value == "" {
 			cmd = append(cmd, "--"+flag)
 		} else {
@@ -693,8 +718,12 @@ type SubprocessTransport struct {
 	stdout io.ReadCloser
 	stderr io.ReadCloser
 
-	messages chan map[string]any
-	errors   chan error
+	// wire carries every message; messages is fed from it once Messages is called
+	wire         chan types.WireMessage
+	messages     chan map[string]any
+	messagesOnce sync.Once
+	closing      chan struct{} // Closed by Close
+	errors       chan error
 
 	ready   bool
 	closed  bool
@@ -705,8 +734,11 @@ type SubprocessTransport struct {
 	writeMu sync.Mutex
 
 	// Exit error tracking for proper error reporting
-	exitError error
-	exitMu    sync.Mutex
+	exitError  error
+	exitMu     sync.Mutex
+	reaped     chan struct{} // Closed once the process has been reaped
+	exited     chan struct{} // Closed once the process has been reaped and its output drained
+	stderrTail *tailBuffer
 
 	// Temp files to clean up on close
 	tempFiles []string
@@ -715,9 +747,17 @@ type SubprocessTransport struct {
 	// Stderr callback for debugging
 	stderrCallback func(string)
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	// Launcher wrapping the CLI command line, if any
+	launcher Launcher
+
+	// Resource limits read back after start, and the cgroup's OOM kill count before it
+	limits   *types.ResourceLimits
+	oomKills int
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	readers sync.WaitGroup // stdout and stderr readers, drained before Wait
 }
 
 // NewSubprocessTransport creates a new subprocess transport.
@@ -727,12 +767,15 @@ func NewSubprocessTransport(prompt string, opts *types.Options) *SubprocessTrans
 	}
 
 	return &SubprocessTransport{
-		prompt:    prompt,
-		options:   opts,
-		streaming: prompt == "", // Empty prompt = streaming mode
-		messages:  make(chan map[string]any, 100),
-		errors:    make(chan error, 1),
-		tempFiles: make([]string, 0),
+		prompt:     prompt,
+		options:    opts,
+		streaming:  prompt == "", // Empty prompt = streaming mode
+		wire:       make(chan types.WireMessage, 100),
+		messages:   make(chan map[string]any, 100),
+		closing:    make(chan struct{}),
+		errors:     make(chan error, 1),
+		tempFiles:  make([]string, 0),
+		stderrTail: newTailBuffer(stderrTailSize),
 	}
 }
 
@@ -750,9 +793,29 @@ func (t *SubprocessTransport) IsReady() bool {
 
 // Messages returns the channel of messages from the CLI.
 func (t *SubprocessTransport) Messages() <-chan map[string]any {
+	t.messagesOnce.Do(func() { go t.forwardMessages() })
 	return t.messages
 }
 
+// WireMessages returns the channel of messages from the CLI with the JSON
+// they were decoded from. Use either it or Messages, not both.
+func (t *SubprocessTransport) WireMessages() <-chan types.WireMessage {
+	return t.wire
+}
+
+// forwardMessages feeds Messages from the wire channel, closing it once the
+// wire channel closes.
+func (t *SubprocessTransport) forwardMessages() {
+	defer close(t.messages)
+	for msg := range t.wire {
+		select {
+		case t.messages <- msg.Message:
+		case <-t.closing:
+			// Nobody is reading; drop the rest until the stream ends
+		}
+	}
+}
+
 // Errors returns the channel of errors from the CLI.
 func (t *SubprocessTransport) Errors() <-chan error {
 	return t.errors
@@ -787,31 +850,21 @@ func (t *SubprocessTransport) Connect(ctx context.Context) error {
 		return nil
 	}
 
-	// Find CLI
-	explicitCLIPath := t.options.CLIPath
-	if t.options.PathToClaudeCodeExecutable != "" {
-		explicitCLIPath = t.options.PathToClaudeCodeExecutable
-	}
-	cliPath, err := findCLI(explicitCLIPath, t.options.BundledCLIPath)
+	// Find CLI; a launcher knows where the CLI lives in its environment
+	cliPath, err := t.resolveCLIPath()
 	if err != nil {
 		return err
 	}
 	t.cliPath = cliPath
 
 	// Check CLI version unless skipped via environment variable
-	if os.Getenv("CLAUDE_AGENT_SDK_SKIP_VERSION_CHECK") == "" {
-		versionCmd := buildProcessCommand(cliPath, nil, t.options)
-		if version, err := checkCLIVersion(versionCmd[0], versionCmd[1:]); err == nil {
-			// Check if version meets minimum requirements
-			if !isVersionAtLeast(version, types.MinimumCLIVersion) {
-				return &types.CLIVersionError{
-					InstalledVersion: version,
-					MinimumVersion:   types.MinimumCLIVersion,
-				}
-			}
+	var versionWarning *types.CLIVersionWarning
+	if t.launcher == nil && os.Getenv("CLAUDE_AGENT_SDK_SKIP_VERSION_CHECK") == "" {
+		info, err := describeCLI(cliPath, t.options)
+		if err != nil {
+			return err
 		}
-		// If version check fails (e.g., timeout), log warning but continue
-		// matching Python SDK behavior
+		versionWarning = info.Warning
 	}
 
 	// Validate path options for security
@@ -824,9 +877,20 @@ func (t *SubprocessTransport) Connect(ctx context.Context) error {
 		return err
 	}
 
+	if err := t.validateResourceLimits(); err != nil {
+		return err
+	}
+
 	// Build CLI arguments, then adapt process launch for runtime wrappers.
 	cliCommand := buildCommand(cliPath, t.prompt, t.options, t.streaming)
 	args := buildProcessCommand(cliCommand[0], cliCommand[1:], t.options)
+	env := buildEnvironment(t.options)
+	if t.launcher != nil {
+		args, env, err = t.launcher.Command(args, sdkEnvironment(t.options))
+		if err != nil {
+			return &types.ConnectionError{Message: "failed to build launch command", Cause: err}
+		}
+	}
 
 	// Check command length on Windows
 	if err := checkCommandLength(args); err != nil {
@@ -836,16 +900,22 @@ func (t *SubprocessTransport) Connect(ctx context.Context) error {
 	// Create context for cancellation
 	t.ctx, t.cancel = context.WithCancel(ctx)
 
-	// Create command
-	t.cmd = exec.CommandContext(t.ctx, args[0], args[1:]...)
+	// Create command in its own process group. Cancelling ctx kills the whole
+	// group; Close stops it gracefully instead.
+	t.cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	setProcessGroup(t.cmd)
+	cmd := t.cmd
+	t.cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
 
 	// Set working directory
-	if t.options.Cwd != "" {
+	if t.options.Cwd != "" && t.launcher == nil {
 		t.cmd.Dir = t.options.Cwd
 	}
 
 	// Set environment
-	t.cmd.Env = buildEnvironment(t.options)
+	t.cmd.Env = env
 
 	// Setup pipes
 	t.stdin, err = t.cmd.StdinPipe()
@@ -853,34 +923,83 @@ func (t *SubprocessTransport) Connect(ctx context.Context) error {
 		return &types.ConnectionError{Message: "failed to create stdin pipe", Cause: err}
 	}
 
-	t.stdout, err = t.cmd.StdoutPipe()
+	// Output pipes are made here rather than with StdoutPipe and StderrPipe,
+	// which exec.Cmd.Wait closes, so the process can be reaped before
+	// everything that inherited them has let go.
+	stdout, stdoutW, err := os.Pipe()
 	if err != nil {
 		return &types.ConnectionError{Message: "failed to create stdout pipe", Cause: err}
 	}
-
-	t.stderr, err = t.cmd.StderrPipe()
+	stderr, stderrW, err := os.Pipe()
 	if err != nil {
+		closePipes(stdout, stdoutW)
 		return &types.ConnectionError{Message: "failed to create stderr pipe", Cause: err}
 	}
+	// The process has its own copies of the write ends once started
+	defer closePipes(stdoutW, stderrW)
+	t.cmd.Stdout, t.cmd.Stderr = stdoutW, stderrW
+	t.stdout, t.stderr = stdout, stderr
 
-	// Start process
+	// Start process, within its resource limits if any
+	limits := t.options.ResourceLimits
+	var gate *limitGate
+	if limits != nil {
+		gate, err = prepareResourceLimits(t.cmd, limits)
+		if err != nil {
+			return err
+		}
+		defer gate.close()
+		if limits.Cgroup != "" {
+			t.oomKills = cgroupOOMKills(limits.Cgroup)
+		}
+	}
 	if err := t.cmd.Start(); err != nil {
+		closePipes(stdout, stderr)
+		if limits != nil && limits.Cgroup != "" {
+			return &types.ResourceLimitError{Resource: "cgroup", Limit: limits.Cgroup, Cause: err}
+		}
 		return &types.ConnectionError{Message: "failed to start CLI", Cause: err}
 	}
+	if limits != nil {
+		applied, err := applyResourceLimits(t.cmd.Process, limits)
+		if err == nil {
+			if openErr := gate.open(); openErr != nil {
+				err = &types.ResourceLimitError{Cause: openErr}
+			}
+		}
+		if err != nil {
+			killProcessGroup(t.cmd.Process)
+			t.cmd.Wait()
+			closePipes(stdout, stderr)
+			t.cancel()
+			return err
+		}
+		t.limits = applied
+	}
 
-	// Start reading stdout (to be implemented in Task 5)
-	t.wg.Add(1)
+	// Start reading stdout
+	t.readers.Add(1)
 	go t.readMessages()
 
 	// Start reading stderr
-	t.wg.Add(1)
+	t.readers.Add(1)
 	go t.readStderr()
 
+	// Reap the process, and report its exit once both streams are drained
+	t.reaped = make(chan struct{})
+	t.exited = make(chan struct{})
+	t.wg.Add(1)
+	go t.waitForExit()
+
 	// For non-streaming mode, close stdin immediately after start
 	if !t.streaming {
 		t.stdin.Close()
 	}
 
+	if versionWarning != nil {
+		t.sendError(versionWarning)
+	}
+
 	t.ready = true
 	return nil
 }
@@ -893,7 +1012,13 @@ func (t *SubprocessTransport) Connect(ctx context.Context) error {
 // 4. SDK-required env (TERM, NO_COLOR, SDK internal vars) - CANNOT be overridden
 func buildEnvironment(opts *types.Options) []string {
 	// Start with system environment
-	env := os.Environ()
+	return append(os.Environ(), sdkEnvironment(opts)...)
+}
+
+// sdkEnvironment returns the variables buildEnvironment adds to the system
+// environment, in precedence order.
+func sdkEnvironment(opts *types.Options) []string {
+	var env []string
 
 	// Set PWD when Cwd is provided (BEFORE user env, so it can be overridden)
 	if opts.Cwd != "" {
@@ -939,10 +1064,28 @@ func parseJSONLine(line string) (map[string]any, error) {
 	return result, nil
 }
 
+// Causes for JSONDecodeError on input that was discarded while resynchronizing.
+var (
+	errNotJSONObject    = errors.New("line does not start a JSON object")
+	errUnterminatedJSON = errors.New("unterminated JSON object")
+)
+
 // jsonAccumulator handles speculative JSON parsing for partial lines.
+//
+// A line that begins a JSON object but does not complete it is buffered and
+// later lines are appended until the object parses. Input that can never
+// become valid (a stray log line, a truncated message followed by a new one)
+// is reported as a *types.JSONDecodeError carrying the discarded bytes, and
+// parsing resumes at the next line that begins a JSON object.
 type jsonAccumulator struct {
 	buffer strings.Builder
-	limit  int
+	// scan has scanned the buffer, so each line is scanned only once.
+	scan  jsonScanner
+	limit int
+	// parsed is the text of the object most recently returned.
+	parsed string
+	// skipping drops the remaining fragments of a message that overflowed the limit.
+	skipping bool
 }
 
 func newJSONAccumulator() *jsonAccumulator {
@@ -955,63 +1098,187 @@ func newJSONAccumulatorWithLimit(limit int) *jsonAccumulator {
 
 // addLine adds a line to the accumulator and attempts to parse.
 // Returns (result, nil) if JSON is complete, (nil, nil) if still accumulating,
-// or (nil, error) if buffer limit is exceeded.
+// or (nil, error) if input was discarded: a *types.BufferOverflowError when the
+// buffer limit is exceeded, a *types.JSONDecodeError for malformed input.
+// When discarded input is followed by a complete object on this line, both
+// the result and the error are returned.
 func (a *jsonAccumulator) addLine(line string) (map[string]any, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, nil
+	}
+	startsObject := trimmed[0] == '{'
+
+	if a.buffer.Len() == 0 {
+		if !startsObject {
+			if a.skipping {
+				return nil, nil
+			}
+			if len(line) > a.limit {
+				return nil, &types.BufferOverflowError{Limit: a.limit, Size: len(line)}
+			}
+			return nil, &types.JSONDecodeError{Line: line, OriginalError: errNotJSONObject}
+		}
+		a.skipping = false
+		return a.start(line)
+	}
+
+	// CLI messages never span lines, so a line that is a complete object on its
+	// own means the buffered fragment was garbage.
+	if startsObject && len(line) <= a.limit {
+		if result, err := a.parse(line); err == nil {
+			return result, a.discard()
+		}
+	}
+
 	// Check if adding this line would exceed the buffer limit
-	if a.buffer.Len()+len(line) > a.limit {
-		a.buffer.Reset()
-		return nil, fmt.Errorf("buffer size %d exceeds limit of %d bytes", a.buffer.Len()+len(line), a.limit)
+	if size := a.buffer.Len() + len(line); size > a.limit {
+		messageType := sniffMessageType([]byte(a.buffer.String()))
+		a.reset()
+		overflow := &types.BufferOverflowError{Limit: a.limit, Size: size, MessageType: messageType}
+		if startsObject {
+			// The line may begin the next message
+			result, err := a.start(line)
+			if err != nil {
+				return result, errors.Join(overflow, err)
+			}
+			return result, overflow
+		}
+		a.skipping = true
+		return nil, overflow
 	}
 
+	buffered := a.buffer.String()
 	a.buffer.WriteString(line)
 
-	// Try to parse speculatively
-	result, err := parseJSONLine(a.buffer.String())
-	if err != nil {
+	switch a.scan.feed(line) {
+	case scanIncomplete:
 		// Still accumulating - not an error yet
 		return nil, nil
+	case scanComplete:
+		if result, err := a.parse(a.buffer.String()); err == nil {
+			a.reset()
+			return result, nil
+		}
+	}
+
+	// Unrecoverable. If this line could begin a new object, keep it and
+	// discard only what was buffered before it.
+	if startsObject && isIncompleteJSON(line) {
+		a.reset()
+		a.buffer.WriteString(line)
+		a.scan.feed(line)
+		return nil, &types.JSONDecodeError{Line: buffered, OriginalError: errUnterminatedJSON}
 	}
+	return nil, a.discard()
+}
 
-	// Successfully parsed - reset buffer
+// start begins a new object with line.
+func (a *jsonAccumulator) start(line string) (map[string]any, error) {
+	if len(line) > a.limit {
+		a.skipping = true
+		return nil, &types.BufferOverflowError{Limit: a.limit, Size: len(line), MessageType: sniffMessageType([]byte(line))}
+	}
+	result, err := a.parse(line)
+	if err == nil {
+		return result, nil
+	}
+	if isIncompleteJSON(line) {
+		a.buffer.WriteString(line)
+		a.scan.feed(line)
+		return nil, nil
+	}
+	return nil, err
+}
+
+// reset empties the buffer.
+func (a *jsonAccumulator) reset() {
 	a.buffer.Reset()
-	return result, nil
+	a.scan.reset()
+}
+
+// parse parses data, remembering it for raw if it is a complete object.
+func (a *jsonAccumulator) parse(data string) (map[string]any, error) {
+	result, err := parseJSONLine(data)
+	if err == nil {
+		a.parsed = strings.TrimSpace(data)
+	}
+	return result, err
+}
+
+// raw returns the JSON text of the object most recently returned by addLine.
+func (a *jsonAccumulator) raw() json.RawMessage {
+	return json.RawMessage(a.parsed)
 }
 
+// discard drops the buffered input and returns a JSONDecodeError describing it.
+func (a *jsonAccumulator) discard() error {
+	buffered := a.buffer.String()
+	a.reset()
+	if !isIncompleteJSON(buffered) {
+		if _, err := parseJSONLine(buffered); err != nil {
+			return err
+		}
+	}
+	return &types.JSONDecodeError{Line: buffered, OriginalError: errUnterminatedJSON}
+}
+
+// isIncompleteJSON reports whether data is a valid but truncated JSON object.
+func isIncompleteJSON(data string) bool {
+	var scan jsonScanner
+	return scan.feed(data) == scanIncomplete
+}
+
+// maxBufferSize is the default message size limit when Options.MaxBufferSize is unset.
 const maxBufferSize = 1024 * 1024 // 1MB
 
+// bufferLimit returns the configured per-message size limit.
+func (t *SubprocessTransport) bufferLimit() int {
+	if t.options != nil && t.options.MaxBufferSize > 0 {
+		return t.options.MaxBufferSize
+	}
+	return maxBufferSize
+}
+
 // readMessages reads JSON messages from stdout with speculative parsing.
+// cmd.Wait() is called once, by waitForExit (exit.go), after the readers finish.
 func (t *SubprocessTransport) readMessages() {
-	defer t.wg.Done()
-	defer close(t.messages)
-
-	scanner := bufio.NewScanner(t.stdout)
-	buf := make([]byte, maxBufferSize)
-	scanner.Buffer(buf, maxBufferSize)
+	defer t.readers.Done()
 
-	accumulator := newJSONAccumulator()
+	limit := t.bufferLimit()
+	reader := newLineReader(t.stdout, limit)
+	accumulator := newJSONAccumulatorWithLimit(limit)
 
-	for scanner.Scan() {
+	for {
 		select {
 		case <-t.ctx.Done():
+			t.discardOutput()
 			return
 		default:
 		}
 
-		line := scanner.Text()
-		if line == "" {
+		line, err := reader.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			t.sendError(err)
+			var overflow *types.BufferOverflowError
+			if errors.As(err, &overflow) {
+				// The oversized message was skipped; keep reading
+				continue
+			}
+			return
+		}
+		if len(line) == 0 {
 			continue
 		}
 
 		// Speculative parsing - try to parse immediately
-		msg, err := accumulator.addLine(line)
+		msg, err := accumulator.addLine(string(line))
 		if err != nil {
-			// Buffer overflow - send error to error channel
-			select {
-			case t.errors <- err:
-			default:
-				// Error channel full, continue anyway
-			}
-			continue
+			// Discarded input (overflow or malformed JSON) - report and resynchronize
+			t.sendError(err)
 		}
 		if msg == nil {
 			// Still accumulating, continue
@@ -1020,38 +1287,67 @@ func (t *SubprocessTransport) readMessages() {
 
 		// Successfully parsed
 		select {
-		case t.messages <- msg:
+		case t.wire <- types.WireMessage{Message: msg, JSON: accumulator.raw()}:
 		case <-t.ctx.Done():
+			t.discardOutput()
 			return
 		}
 	}
+}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		select {
-		case t.errors <- err:
-		default:
-		}
-	}
+// discardOutput drains stdout after the transport stopped delivering
+// messages, so a CLI still writing while it shuts down does not block on a
+// full pipe instead of exiting.
+func (t *SubprocessTransport) discardOutput() {
+	io.Copy(io.Discard, t.stdout)
+}
 
-	// Note: cmd.Wait() is called in Close() to avoid duplicate calls
+// sendError delivers err on the errors channel without blocking.
+func (t *SubprocessTransport) sendError(err error) {
+	select {
+	case t.errors <- err:
+	default:
+		// Error channel full, continue anyway
+	}
 }
 
-// readStderr reads stderr and optionally invokes callback.
+// readStderr reads stderr, keeping a bounded tail for ProcessError and
+// optionally invoking the callback for each line.
 func (t *SubprocessTransport) readStderr() {
-	defer t.wg.Done()
+	defer t.readers.Done()
 
-	scanner := bufio.NewScanner(t.stderr)
-	for scanner.Scan() {
-		line := scanner.Text()
+	reader := newLineReader(t.stderr, maxBufferSize)
+	for {
+		line, err := reader.next()
+		if err != nil {
+			var overflow *types.BufferOverflowError
+			if errors.As(err, &overflow) {
+				continue
+			}
+			return
+		}
+		t.stderrTail.writeLine(line)
 		if t.stderrCallback != nil {
-			t.stderrCallback(line)
+			t.stderrCallback(string(line))
 		}
 	}
 }
 
+// gracefulShutdownTimeout is the default for Options.ShutdownTimeout.
 const gracefulShutdownTimeout = 5 * time.Second
 
+// orphanPollInterval is how often Close checks whether the CLI's process group
+// has exited after SIGTERM.
+const orphanPollInterval = 10 * time.Millisecond
+
+// shutdownTimeout returns how long each step of stopping the CLI may take.
+func (t *SubprocessTransport) shutdownTimeout() time.Duration {
+	if t.options != nil && t.options.ShutdownTimeout > 0 {
+		return t.options.ShutdownTimeout
+	}
+	return gracefulShutdownTimeout
+}
+
 // Close terminates the subprocess and cleans up resources.
 // CRITICAL: Uses TOCTOU-safe pattern - state changes inside lock.
 func (t *SubprocessTransport) Close() error {
@@ -1067,14 +1363,17 @@ func (t *SubprocessTransport) Close() error {
 	// CRITICAL: Set closed and ready inside the lock to prevent TOCTOU
 	t.closed = true
 	t.ready = false
+	close(t.closing)
 	cancel := t.cancel
 	cmd := t.cmd
+	reaped := t.reaped
+	exited := t.exited
 	stdin := t.stdin
 	stdout := t.stdout
 	stderr := t.stderr
 	t.closeMu.Unlock()
 
-	// Cancel context to stop goroutines
+	// Cancel context to stop goroutines; the process is stopped below
 	if cancel != nil {
 		cancel()
 	}
@@ -1084,30 +1383,12 @@ func (t *SubprocessTransport) Close() error {
 		stdin.Close()
 	}
 
-	// Terminate process if running
-	if cmd != nil && cmd.Process != nil {
-		done := make(chan error, 1)
-		go func() {
-			done <- cmd.Wait()
-		}()
-
-		select {
-		case <-done:
-			// Process exited gracefully
-		case <-time.After(gracefulShutdownTimeout):
-			// Force kill
-			cmd.Process.Kill()
-			<-done
-		}
+	// Terminate process if running; waitForExit reaps it
+	if cmd != nil && cmd.Process != nil && exited != nil {
+		t.stopProcess(cmd.Process, reaped, exited, stdout, stderr)
 	}
 
-	// Close pipes
-	if stdout != nil {
-		stdout.Close()
-	}
-	if stderr != nil {
-		stderr.Close()
-	}
+	closePipes(stdout, stderr)
 
 	// Wait for goroutines
 	t.wg.Wait()
@@ -1120,16 +1401,76 @@ func (t *SubprocessTransport) Close() error {
 	t.tempFiles = nil
 	t.tempMu.Unlock()
 
+	// Release anything the launcher left behind
+	if t.launcher != nil && cmd != nil {
+		return t.launcher.Cleanup()
+	}
+
 	return nil
 }
 
-// Kill forcefully terminates the subprocess.
+// stopProcess returns as soon as the CLI exits after its input closed. If it
+// has not exited within the shutdown timeout, its process group gets SIGTERM,
+// then SIGKILL after another timeout. Processes the CLI left behind in the
+// group are stopped the same way, and the pipes are closed if something still
+// holds them a timeout later.
+func (t *SubprocessTransport) stopProcess(process *os.Process, reaped, exited <-chan struct{}, pipes ...io.Closer) {
+	timeout := t.shutdownTimeout()
+
+	select {
+	case <-reaped:
+	case <-time.After(timeout):
+		terminateProcessGroup(process)
+		select {
+		case <-reaped:
+		case <-time.After(timeout):
+			killProcessGroup(process)
+			<-reaped
+		}
+	}
+
+	t.stopOrphans(process, timeout)
+
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		// A process outside the group still holds the pipes
+		closePipes(pipes...)
+		<-exited
+	}
+}
+
+// stopOrphans stops processes left in the CLI's process group after it exited.
+func (t *SubprocessTransport) stopOrphans(process *os.Process, timeout time.Duration) {
+	if !processGroupAlive(process) {
+		return
+	}
+	terminateProcessGroup(process)
+	deadline := time.Now().Add(timeout)
+	for processGroupAlive(process) {
+		if time.Now().After(deadline) {
+			killProcessGroup(process)
+			return
+		}
+		time.Sleep(orphanPollInterval)
+	}
+}
+
+func closePipes(pipes ...io.Closer) {
+	for _, pipe := range pipes {
+		if pipe != nil {
+			pipe.Close()
+		}
+	}
+}
+
+// Kill forcefully terminates the subprocess and its process group.
 func (t *SubprocessTransport) Kill() error {
 	t.closeMu.Lock()
 	defer t.closeMu.Unlock()
 
 	if t.cmd != nil && t.cmd.Process != nil {
-		return t.cmd.Process.Kill()
+		return killProcessGroup(t.cmd.Process)
 	}
 	return nil
 }
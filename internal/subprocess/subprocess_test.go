@@ -830,6 +830,8 @@ while true; do sleep 0.1; done
 
 	opts := types.DefaultOptions()
 	opts.CLIPath = mockCLI
+	// The script ignores EOF, so Close waits out the timeout before SIGTERM
+	opts.ShutdownTimeout = 500 * time.Millisecond
 
 	transport := NewStreamingTransport(opts)
 	ctx := context.Background()
@@ -1048,3 +1050,34 @@ done
 		t.Errorf("expected at least 2 messages, got %d", len(messages))
 	}
 }
+
+func TestSameProcess(t *testing.T) {
+	base := func(opts ...types.Option) *types.Options {
+		o := types.DefaultOptions()
+		types.ApplyOptions(o, types.WithCwd("/work"), types.WithEnv(map[string]string{"A": "1", "B": "2"}))
+		types.ApplyOptions(o, opts...)
+		return o
+	}
+
+	same := base()
+	same.CanUseTool = func(string, map[string]any, *types.ToolPermissionContext) (types.PermissionResult, error) {
+		return nil, nil
+	}
+	same.ExtraArgs = map[string]string{"x": "1", "y": "2", "z": ""}
+	other := base()
+	other.ExtraArgs = map[string]string{"z": "", "y": "2", "x": "1"}
+	if !SameProcess(same, other) {
+		t.Error("expected options differing only in SDK-side settings to match")
+	}
+
+	for name, opts := range map[string]*types.Options{
+		"model":  base(types.WithModel("claude-opus-4-5")),
+		"cwd":    base(types.WithCwd("/elsewhere")),
+		"env":    base(types.WithEnv(map[string]string{"A": "changed"})),
+		"limits": base(types.WithResourceLimits(types.ResourceLimits{OpenFiles: 64})),
+	} {
+		if SameProcess(base(), opts) {
+			t.Errorf("expected a different %s to change the process", name)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package typesinternal gives other SDK packages access to unexported parts
+// of the types package without adding to its public API. The types package
+// sets the hooks when it is initialized, so any package that imports types
+// can call them.
+package typesinternal
+
+// ParseMessage parses a raw message map into a types.Message. It is the
+// parser behind the messages' UnmarshalJSON methods.
+var ParseMessage func(raw map[string]any) (any, error)
@@ -5,6 +5,7 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -111,6 +112,18 @@ func (c *Client) SessionID() string {
 	return c.sessionID
 }
 
+// ResourceLimits returns the resource limits in effect for the CLI process,
+// or nil if none were requested or the transport does not report them.
+func (c *Client) ResourceLimits() *types.ResourceLimits {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limited, ok := c.transport.(types.ResourceLimitTransport); ok {
+		return limited.ResourceLimits()
+	}
+	return nil
+}
+
 // WithClientMCPServer adds an MCP server to the client.
 func WithClientMCPServer(server *types.MCPServer) types.Option {
 	return func(o *types.Options) {
@@ -406,6 +419,10 @@ func (c *Client) connect(ctx context.Context) error {
 	if c.canUseTool != nil {
 		c.query.SetCanUseTool(c.canUseTool)
 	}
+	c.query.SetPermissionFallback(c.options.PermissionFallback)
+	c.query.SetStrictMessageParsing(c.options.StrictMessageParsing)
+	c.query.SetRetainRawJSON(c.options.RetainRawJSON)
+	c.query.SetSubagentEvents(c.options.SubagentEvents)
 
 	// Register MCP servers
 	for _, server := range c.mcpServers {
@@ -492,16 +509,67 @@ func RunQuery(ctx context.Context, prompt string, opts ...types.Option) ([]types
 	for msg := range msgChan {
 		messages = append(messages, msg)
 	}
-	if err, ok := <-errChan; ok && err != nil {
-		return messages, err
+	return messages, streamError(errChan)
+}
+
+// RunQueryInput performs a one-shot query with multimodal input and returns all messages.
+func RunQueryInput(ctx context.Context, input *types.UserInput, opts ...types.Option) ([]types.Message, error) {
+	msgChan, errChan := QueryStreamInput(ctx, input, opts...)
+	var messages []types.Message
+	for msg := range msgChan {
+		messages = append(messages, msg)
 	}
-	return messages, nil
+	return messages, streamError(errChan)
+}
+
+// streamError returns the error that ended a query stream, or else the first
+// skipped-message error, once the stream's error channel is closed. Warnings
+// are ignored.
+func streamError(errChan <-chan error) error {
+	var skipped error
+	for err := range errChan {
+		if isWarning(err) {
+			continue
+		}
+		if !isSkippedMessage(err) {
+			return err
+		}
+		skipped = err
+	}
+	return skipped
 }
 
 // QueryStream performs a query and streams messages back.
+//
+// The error channel carries the error that ended the query, if any. It may
+// first carry a *types.JSONDecodeError or *types.BufferOverflowError for the
+// first message the transport skipped, and warnings such as
+// *types.PermissionFallbackWarning and *types.CLIVersionWarning; neither ends
+// the stream.
 func QueryStream(ctx context.Context, prompt string, opts ...types.Option) (<-chan types.Message, <-chan error) {
+	return queryStream(ctx, prompt, opts)
+}
+
+// QueryStreamInput performs a query with multimodal input and streams messages back.
+func QueryStreamInput(ctx context.Context, input *types.UserInput, opts ...types.Option) (<-chan types.Message, <-chan error) {
+	content, err := input.Content()
+	if err != nil {
+		msgChan := make(chan types.Message)
+		errChan := make(chan error, 1)
+		errChan <- err
+		close(msgChan)
+		close(errChan)
+		return msgChan, errChan
+	}
+	return queryStream(ctx, content, opts)
+}
+
+// queryStream runs a one-shot query; content is a prompt string or a content block array.
+func queryStream(ctx context.Context, content any, opts []types.Option) (<-chan types.Message, <-chan error) {
 	msgChan := make(chan types.Message, 100)
-	errChan := make(chan error, 1)
+	// Slots for the first skipped message, the error that ends the query and
+	// the warnings, each sent at most once
+	errChan := make(chan error, 2+warningChannelBuffer)
 
 	go func() {
 		defer close(msgChan)
@@ -529,6 +597,9 @@ func QueryStream(ctx context.Context, prompt string, opts ...types.Option) (<-ch
 		if options.CanUseTool != nil {
 			query.SetCanUseTool(options.CanUseTool)
 		}
+		query.SetPermissionFallback(options.PermissionFallback)
+		query.SetStrictMessageParsing(options.StrictMessageParsing)
+		query.SetRetainRawJSON(options.RetainRawJSON)
 		for _, server := range options.SDKMCPServers {
 			query.RegisterMCPServer(server)
 		}
@@ -537,21 +608,15 @@ func QueryStream(ctx context.Context, prompt string, opts ...types.Option) (<-ch
 			return
 		}
 		defer query.Close()
+		// Warnings still pending when the query ends are delivered too
+		defer forwardWarnings(query, errChan)
 
 		if _, err := query.Initialize(options.Hooks); err != nil {
 			errChan <- err
 			return
 		}
 		input := make(chan map[string]any, 1)
-		input <- map[string]any{
-			"type": "user",
-			"message": map[string]any{
-				"role":    "user",
-				"content": prompt,
-			},
-			"parent_tool_use_id": nil,
-			"session_id":         "",
-		}
+		input <- userMessage(content, "")
 		close(input)
 
 		if err := query.StreamInputWithWait(input); err != nil {
@@ -563,16 +628,52 @@ func QueryStream(ctx context.Context, prompt string, opts ...types.Option) (<-ch
 			return
 		}
 
+		skipped := false
 		for {
 			select {
 			case <-ctx.Done():
 				errChan <- ctx.Err()
 				return
 			case err := <-query.Errors():
-				if err != nil {
+				if isSkippedMessage(err) {
+					// Reported once; later messages are still delivered
+					if !skipped {
+						skipped = true
+						errChan <- err
+					}
+					continue
+				}
+				if err != nil && !errors.Is(err, types.ErrProcess) {
 					errChan <- err
 					return
 				}
+			case warning := <-query.Warnings():
+				forwardWarning(warning, errChan)
+			case <-query.TransportDone():
+				// Deliver what arrived before the transport ended, then its exit error
+				for drained := false; !drained; {
+					select {
+					case msg, ok := <-query.Messages():
+						if !ok {
+							return
+						}
+						select {
+						case msgChan <- msg:
+						case <-ctx.Done():
+							errChan <- ctx.Err()
+							return
+						}
+						if _, ok := msg.(*types.ResultMessage); ok {
+							return
+						}
+					default:
+						drained = true
+					}
+				}
+				if err := query.ExitError(); err != nil {
+					errChan <- err
+				}
+				return
 			case msg, ok := <-query.Messages():
 				if !ok {
 					return
@@ -594,12 +695,51 @@ func QueryStream(ctx context.Context, prompt string, opts ...types.Option) (<-ch
 	return msgChan, errChan
 }
 
+// forwardWarnings moves the query's pending warnings to errChan.
+func forwardWarnings(query *Query, errChan chan<- error) {
+	for {
+		select {
+		case warning := <-query.Warnings():
+			forwardWarning(warning, errChan)
+		default:
+			return
+		}
+	}
+}
+
+// forwardWarning sends warning on errChan unless it is full; the channel is
+// sized for every warning a query sends, so this only guards the stream.
+func forwardWarning(warning error, errChan chan<- error) {
+	select {
+	case errChan <- warning:
+	default:
+	}
+}
+
 // SendQuery sends a query in streaming mode.
 func (c *Client) SendQuery(prompt string, sessionID ...string) error {
+	q, sid, err := c.sendTarget(sessionID)
+	if err != nil {
+		return err
+	}
+	return q.SendUserMessage(prompt, sid)
+}
+
+// SendInput sends a user message made of text, images and documents.
+func (c *Client) SendInput(input *types.UserInput, sessionID ...string) error {
+	q, sid, err := c.sendTarget(sessionID)
+	if err != nil {
+		return err
+	}
+	return q.SendUserInput(input, sid)
+}
+
+// sendTarget returns the query to send a user message on and its session ID.
+func (c *Client) sendTarget(sessionID []string) (*Query, string, error) {
 	c.mu.Lock()
 	if !c.connected || c.query == nil {
 		c.mu.Unlock()
-		return &types.ConnectionError{Message: "not connected"}
+		return nil, "", &types.ConnectionError{Message: "not connected"}
 	}
 	q := c.query
 	sid := c.sessionID
@@ -611,11 +751,14 @@ func (c *Client) SendQuery(prompt string, sessionID ...string) error {
 	if sid == "" {
 		sid = "default"
 	}
-
-	return q.SendUserMessage(prompt, sid)
+	return q, sid, nil
 }
 
 // ReceiveMessage receives the next message.
+//
+// A *types.JSONDecodeError or *types.BufferOverflowError reports a message the
+// transport skipped; the session is still usable and the next call returns the
+// following message.
 func (c *Client) ReceiveMessage() (types.Message, error) {
 	c.mu.Lock()
 	if !c.connected || c.query == nil {
@@ -625,28 +768,71 @@ func (c *Client) ReceiveMessage() (types.Message, error) {
 	q := c.query
 	c.mu.Unlock()
 
-	select {
-	case msg, ok := <-q.Messages():
-		if !ok {
-			return nil, &types.ConnectionError{Message: "channel closed"}
+	for {
+		select {
+		case msg, ok := <-q.Messages():
+			if !ok {
+				return nil, &types.ConnectionError{Message: "channel closed"}
+			}
+			return msg, nil
+		case err := <-q.Errors():
+			if errors.Is(err, types.ErrProcess) {
+				// Process exits are reported below, after buffered messages
+				continue
+			}
+			return nil, err
+		case <-q.TransportDone():
+			select {
+			case msg, ok := <-q.Messages():
+				if ok {
+					return msg, nil
+				}
+			default:
+			}
+			if err := q.ExitError(); err != nil {
+				return nil, err
+			}
+			return nil, &types.ConnectionError{Message: "transport closed"}
 		}
-		return msg, nil
-	case err := <-q.Errors():
-		return nil, err
 	}
 }
 
-// ReceiveAll receives all messages until result.
+// isWarning reports whether err is informational. Query sends warnings on its
+// warnings channel, and they never end a query.
+func isWarning(err error) bool {
+	var fallbackWarning *types.PermissionFallbackWarning
+	var versionWarning *types.CLIVersionWarning
+	return errors.As(err, &fallbackWarning) || errors.As(err, &versionWarning)
+}
+
+// isSkippedMessage reports whether err reports a single message the transport
+// could not read. Reception continues with the next message.
+func isSkippedMessage(err error) bool {
+	var decodeErr *types.JSONDecodeError
+	var overflow *types.BufferOverflowError
+	return errors.As(err, &decodeErr) || errors.As(err, &overflow)
+}
+
+// ReceiveAll receives all messages until result. Skipped messages do not stop
+// it; the first *types.JSONDecodeError or *types.BufferOverflowError is
+// returned with the messages once the result arrives.
 func (c *Client) ReceiveAll() ([]types.Message, error) {
 	var messages []types.Message
+	var skipped error
 	for {
 		msg, err := c.ReceiveMessage()
 		if err != nil {
+			if isSkippedMessage(err) {
+				if skipped == nil {
+					skipped = err
+				}
+				continue
+			}
 			return messages, err
 		}
 		messages = append(messages, msg)
 		if _, ok := msg.(*types.ResultMessage); ok {
-			return messages, nil
+			return messages, skipped
 		}
 	}
 }
@@ -918,6 +1104,46 @@ func (c *Client) LastResult() *types.ResultMessage {
 	return nil
 }
 
+// SystemInit returns the latest system init message, which describes the
+// session's tools, MCP servers, model and permission mode. The CLI sends it
+// when it starts processing a query, so it is nil until the first query.
+func (c *Client) SystemInit() *types.SystemInitMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query != nil {
+		return c.query.SystemInit()
+	}
+	return nil
+}
+
+// WaitForSystemInit waits for the first system init message of the session.
+// The CLI sends it once the first query has been sent.
+func (c *Client) WaitForSystemInit(ctx context.Context) (*types.SystemInitMessage, error) {
+	c.mu.Lock()
+	if !c.connected || c.query == nil {
+		c.mu.Unlock()
+		return nil, &types.ConnectionError{Message: "not connected"}
+	}
+	q := c.query
+	c.mu.Unlock()
+
+	select {
+	case <-q.WaitForSystemInit():
+		return q.SystemInit(), nil
+	case <-q.TransportDone():
+		if initMsg := q.SystemInit(); initMsg != nil {
+			return initMsg, nil
+		}
+		if err := q.ExitError(); err != nil {
+			return nil, err
+		}
+		return nil, &types.ConnectionError{Message: "transport closed before system init"}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // ClientFunc is a function that uses a client.
 type ClientFunc func(*Client) error
 
@@ -970,7 +1196,9 @@ func (c *Client) Messages() <-chan types.Message {
 	return q.Messages()
 }
 
-// RawMessages returns a channel of raw message maps.
+// RawMessages returns a channel of raw message maps. Messages are dropped
+// when the channel is full; use types.WithRetainRawJSON to get each parsed
+// message's JSON from the message itself instead.
 func (c *Client) RawMessages() <-chan map[string]any {
 	c.mu.Lock()
 	if !c.connected || c.query == nil {
@@ -985,6 +1213,68 @@ func (c *Client) RawMessages() <-chan map[string]any {
 	return q.RawMessages()
 }
 
+// SubagentEvents returns a channel of Task subagent events, enabled with
+// types.WithSubagentEvents. Each subagent is keyed by the ID of the Task
+// tool call that started it, and reports a start event, an event for each
+// of its messages, a stop event when the SubagentStop hook fires and a
+// result event when the Task call returns. Its messages are still delivered
+// on Messages too, so both channels must be drained:
+//
+//	go func() {
+//		for event := range client.SubagentEvents() {
+//			panes[event.Subagent.ParentToolUseID].Show(event)
+//		}
+//	}()
+//	for msg := range client.Messages() {
+//		...
+//	}
+//
+// The channel is closed when the client closes, or right away when
+// subagent events are not enabled.
+func (c *Client) SubagentEvents() <-chan *types.SubagentEvent {
+	c.mu.Lock()
+	if !c.connected || c.query == nil {
+		c.mu.Unlock()
+		ch := make(chan *types.SubagentEvent)
+		close(ch)
+		return ch
+	}
+	q := c.query
+	c.mu.Unlock()
+
+	return q.SubagentEvents()
+}
+
+// Subagents returns the Task subagents seen so far, in the order they
+// started. It requires types.WithSubagentEvents.
+func (c *Client) Subagents() []types.Subagent {
+	c.mu.Lock()
+	q := c.query
+	c.mu.Unlock()
+	if q == nil {
+		return nil
+	}
+	return q.Subagents()
+}
+
+// Warnings returns the warning channel. It carries a
+// *types.PermissionFallbackWarning the first time a permission request is
+// answered without a CanUseTool callback, and any *types.CLIVersionWarning.
+// ReceiveMessage and ReceiveAll leave it for the caller to read.
+func (c *Client) Warnings() <-chan error {
+	c.mu.Lock()
+	if !c.connected || c.query == nil {
+		c.mu.Unlock()
+		ch := make(chan error)
+		close(ch)
+		return ch
+	}
+	q := c.query
+	c.mu.Unlock()
+
+	return q.Warnings()
+}
+
 // Errors returns the error channel.
 func (c *Client) Errors() <-chan error {
 	c.mu.Lock()
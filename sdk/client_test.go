@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -159,6 +160,31 @@ func TestClient_SessionID(t *testing.T) {
 	}
 }
 
+type limitedTransport struct {
+	*MockTransport
+	limits *types.ResourceLimits
+}
+
+func (l *limitedTransport) ResourceLimits() *types.ResourceLimits {
+	return l.limits
+}
+
+func TestClient_ResourceLimits(t *testing.T) {
+	if limits := NewClient().ResourceLimits(); limits != nil {
+		t.Errorf("expected no limits before connecting, got %+v", limits)
+	}
+
+	if limits := NewClient(types.WithTransport(NewMockTransport())).ResourceLimits(); limits != nil {
+		t.Errorf("expected no limits from a transport that does not report them, got %+v", limits)
+	}
+
+	want := &types.ResourceLimits{OpenFiles: 1024, Nice: 5}
+	client := NewClient(types.WithTransport(&limitedTransport{NewMockTransport(), want}))
+	if got := client.ResourceLimits(); got != want {
+		t.Errorf("expected limits reported by the transport, got %+v", got)
+	}
+}
+
 func TestClient_IsConnected(t *testing.T) {
 	client := NewClient()
 
@@ -877,6 +903,77 @@ func TestClient_ReceiveMessage(t *testing.T) {
 	}
 }
 
+func TestClient_ReceiveMessage_ProcessExit(t *testing.T) {
+	transport := NewMockTransport()
+	client := NewClient(types.WithTransport(transport))
+	procErr := &types.ProcessError{ExitCode: 1, Stderr: "Error: invalid API key"}
+
+	// Respond to initialize, send one message, then crash
+	go func() {
+		for {
+			time.Sleep(10 * time.Millisecond)
+			written := transport.Written()
+			if len(written) == 0 {
+				continue
+			}
+
+			var req map[string]any
+			if err := json.Unmarshal([]byte(written[len(written)-1]), &req); err != nil {
+				continue
+			}
+			reqID, ok := req["request_id"].(string)
+			if !ok {
+				continue
+			}
+
+			transport.SendMessage(map[string]any{
+				"type": "control_response",
+				"response": map[string]any{
+					"subtype":    "success",
+					"request_id": reqID,
+					"response":   map[string]any{"session_id": "test_session"},
+				},
+			})
+
+			time.Sleep(10 * time.Millisecond)
+			transport.SendMessage(map[string]any{
+				"type": "assistant",
+				"message": map[string]any{
+					"content": []any{
+						map[string]any{"type": "text", "text": "Hello!"},
+					},
+				},
+			})
+			transport.SendError(procErr)
+			_ = transport.Close()
+			return
+		}
+	}()
+
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	messages, err := client.ReceiveAll()
+	if len(messages) != 1 {
+		t.Fatalf("expected the message sent before the crash, got %d", len(messages))
+	}
+	var gotErr *types.ProcessError
+	if !errors.As(err, &gotErr) {
+		t.Fatalf("expected ProcessError, got %v", err)
+	}
+	if gotErr.ExitCode != 1 || gotErr.Stderr != "Error: invalid API key" {
+		t.Fatalf("unexpected ProcessError: %+v", gotErr)
+	}
+
+	// Further receives keep reporting the exit instead of blocking
+	if _, err := client.ReceiveMessage(); !errors.Is(err, types.ErrProcess) {
+		t.Fatalf("expected ProcessError on subsequent receive, got %v", err)
+	}
+}
+
 func TestClient_ReceiveAll(t *testing.T) {
 	transport := NewMockTransport()
 	client := NewClient(types.WithTransport(transport))
@@ -1275,3 +1372,375 @@ func TestClient_GetServerInfo_WithPromptConnect(t *testing.T) {
 		t.Errorf("expected session_id 'test_prompt_session', got %v", info["session_id"])
 	}
 }
+
+func TestClient_ReceiveAll_SkippedMessage(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_skip")
+	defer stop()
+
+	client := NewClient(types.WithTransport(transport))
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// A malformed line arrives before the response to the query
+	transport.SendError(&types.JSONDecodeError{Line: "not json", OriginalError: errors.New("invalid character")})
+	messages, err := client.ReceiveResponse("Hello")
+
+	var decodeErr *types.JSONDecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("expected the JSONDecodeError to be returned, got %v", err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected messages after the malformed line")
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Errorf("expected the result to be delivered, got %T", messages[len(messages)-1])
+	}
+}
+
+func TestRunQuery_SkippedMessage(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_skip")
+	defer stop()
+
+	transport.SendError(&types.BufferOverflowError{Limit: 10, Size: 20, MessageType: "assistant"})
+	messages, err := RunQuery(context.Background(), "Hello", types.WithTransport(transport))
+
+	var overflow *types.BufferOverflowError
+	if !errors.As(err, &overflow) {
+		t.Errorf("expected the BufferOverflowError to be returned, got %v", err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected messages after the skipped one")
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Errorf("expected the result to be delivered, got %T", messages[len(messages)-1])
+	}
+}
+
+func TestClient_PermissionFallbackWarning(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_warn")
+	defer stop()
+
+	client := NewClient(types.WithTransport(transport))
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Answered by the fallback policy, since no CanUseTool callback is set
+	transport.SendMessage(map[string]any{
+		"type":       "control_request",
+		"request_id": "req_perm",
+		"request":    map[string]any{"subtype": "can_use_tool", "tool_name": "Bash", "input": map[string]any{}},
+	})
+	if _, err := client.ReceiveResponse("Hello"); err != nil {
+		t.Fatalf("ReceiveResponse failed: %v", err)
+	}
+
+	// Receiving messages leaves the warning for the caller
+	select {
+	case err := <-client.Warnings():
+		var warning *types.PermissionFallbackWarning
+		if !errors.As(err, &warning) || warning.ToolName != "Bash" {
+			t.Fatalf("expected *types.PermissionFallbackWarning, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the fallback warning")
+	}
+}
+
+func TestQueryStream_CLIVersionWarning(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_version")
+	defer stop()
+
+	// Reported by the transport on Connect, like an untested CLI version
+	transport.SendError(&types.CLIVersionWarning{InstalledVersion: "3.0.0", MaximumVersion: MaximumTestedCLIVersion})
+	msgChan, errChan := QueryStream(context.Background(), "Hello", types.WithTransport(transport))
+
+	var messages []types.Message
+	for msg := range msgChan {
+		messages = append(messages, msg)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected the stream to continue past the warning")
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Errorf("expected the result to be delivered, got %T", messages[len(messages)-1])
+	}
+
+	var warning *types.CLIVersionWarning
+	for err := range errChan {
+		if !errors.As(err, &warning) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if warning == nil || warning.InstalledVersion != "3.0.0" {
+		t.Fatalf("expected *types.CLIVersionWarning on the error channel, got %v", warning)
+	}
+}
+
+// startMockResponder answers control requests on transport and replies to each
+// user message with an assistant message and a result, until the returned
+// function is called.
+func startMockResponder(t *testing.T, transport *MockTransport, sessionID string) func() {
+	t.Helper()
+	stopCh := make(chan struct{})
+	go func() {
+		processed := 0
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+
+			if !transport.WaitForWrite(25 * time.Millisecond) {
+				continue
+			}
+
+			written := transport.Written()
+			for processed < len(written) {
+				raw := written[processed]
+				processed++
+
+				var msg map[string]any
+				if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+					continue
+				}
+
+				switch msg["type"] {
+				case "control_request":
+					reqID, _ := msg["request_id"].(string)
+					req, _ := msg["request"].(map[string]any)
+					subtype, _ := req["subtype"].(string)
+
+					response := map[string]any{}
+					if subtype == "initialize" {
+						response["session_id"] = sessionID
+					}
+
+					transport.SendMessage(map[string]any{
+						"type": "control_response",
+						"response": map[string]any{
+							"subtype":    "success",
+							"request_id": reqID,
+							"response":   response,
+						},
+					})
+
+				case "user":
+					transport.SendMessage(map[string]any{
+						"type":       "assistant",
+						"uuid":       "assistant_1",
+						"session_id": sessionID,
+						"message": map[string]any{
+							"model": "claude-sonnet-4-5",
+							"content": []any{
+								map[string]any{"type": "text", "text": "Hello from mock."},
+							},
+						},
+					})
+					transport.SendMessage(map[string]any{
+						"type":            "result",
+						"subtype":         "success",
+						"uuid":            "result_1",
+						"duration_ms":     float64(1),
+						"duration_api_ms": float64(1),
+						"is_error":        false,
+						"num_turns":       float64(1),
+						"session_id":      sessionID,
+						"result":          "ok",
+					})
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+	}
+}
+
+func TestRunQueryInput(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_run_input")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages, err := RunQueryInput(ctx, types.NewUserInput().Text("hi").TextDocument("notes", "notes.txt"), types.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("RunQueryInput failed: %v", err)
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Fatalf("expected a result message last, got %T", messages[len(messages)-1])
+	}
+
+	// Invalid input fails before a transport is used
+	if _, err := RunQueryInput(ctx, types.NewUserInput().Image("image/png", nil)); err == nil {
+		t.Fatal("expected an error for invalid input")
+	}
+}
+
+func TestClient_SystemInit(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_init")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewClient(types.WithTransport(transport))
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if client.SystemInit() != nil {
+		t.Fatal("expected no init message before the first query")
+	}
+
+	transport.SendMessage(map[string]any{
+		"type":        "system",
+		"subtype":     "init",
+		"session_id":  "sess_init",
+		"model":       "claude-sonnet-4-5",
+		"tools":       []any{"Read", "mcp__calc__add"},
+		"mcp_servers": []any{map[string]any{"name": "calc", "status": "connected"}},
+	})
+
+	initMsg, err := client.WaitForSystemInit(ctx)
+	if err != nil {
+		t.Fatalf("WaitForSystemInit failed: %v", err)
+	}
+	if initMsg.Model != "claude-sonnet-4-5" || !initMsg.HasTool("mcp__calc__add") || len(initMsg.UnavailableMCPServers()) != 0 {
+		t.Fatalf("unexpected init message: %+v", initMsg)
+	}
+	if client.SystemInit() != initMsg {
+		t.Error("SystemInit should return the latest init message")
+	}
+
+	// The init message is also delivered to the stream
+	select {
+	case msg := <-client.Messages():
+		if _, ok := msg.(*types.SystemInitMessage); !ok {
+			t.Errorf("expected *types.SystemInitMessage on the stream, got %T", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the init message")
+	}
+}
+
+func TestClient_UnknownMessageType(t *testing.T) {
+	unknown := map[string]any{"type": "future_event", "session_id": "sess_unknown", "detail": "x"}
+
+	t.Run("delivered by default", func(t *testing.T) {
+		transport := NewMockTransport()
+		stop := startMockResponder(t, transport, "sess_unknown")
+		defer stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client := NewClient(types.WithTransport(transport))
+		if err := client.Connect(ctx); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		defer client.Close()
+
+		transport.SendMessage(unknown)
+		select {
+		case msg := <-client.Messages():
+			got, ok := msg.(*types.UnknownMessage)
+			if !ok || got.Type != "future_event" || got.SessionID != "sess_unknown" || got.Raw["detail"] != "x" {
+				t.Fatalf("expected *types.UnknownMessage, got %#v", msg)
+			}
+		case err := <-client.Errors():
+			t.Fatalf("unexpected error: %v", err)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the unknown message")
+		}
+	})
+
+	t.Run("error when strict", func(t *testing.T) {
+		transport := NewMockTransport()
+		stop := startMockResponder(t, transport, "sess_unknown")
+		defer stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client := NewClient(types.WithTransport(transport), types.WithStrictMessageParsing(true))
+		if err := client.Connect(ctx); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		defer client.Close()
+
+		transport.SendMessage(unknown)
+		select {
+		case err := <-client.Errors():
+			if !errors.Is(err, types.ErrParse) {
+				t.Fatalf("expected a parse error, got %v", err)
+			}
+		case msg := <-client.Messages():
+			t.Fatalf("unexpected message %T in strict mode", msg)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the parse error")
+		}
+	})
+}
+
+func TestClient_RetainRawJSON(t *testing.T) {
+	for _, retain := range []bool{false, true} {
+		transport := NewMockTransport()
+		stop := startMockResponder(t, transport, "sess_raw")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		opts := []types.Option{types.WithTransport(transport)}
+		if retain {
+			opts = append(opts, types.WithRetainRawJSON())
+		}
+		client := NewClient(opts...)
+		if err := client.Connect(ctx); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+
+		transport.SendMessage(map[string]any{
+			"type":         "tool_progress",
+			"tool_use_id":  "tu_1",
+			"tool_name":    "Bash",
+			"session_id":   "sess_raw",
+			"future_field": "<kept>",
+		})
+		select {
+		case msg := <-client.Messages():
+			raw := types.MessageRawJSON(msg)
+			if !retain {
+				if raw != nil {
+					t.Errorf("expected no raw JSON by default, got %s", raw)
+				}
+				break
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("invalid raw JSON %q: %v", raw, err)
+			}
+			if decoded["future_field"] != "<kept>" || decoded["tool_use_id"] != "tu_1" {
+				t.Errorf("raw JSON lost fields: %s", raw)
+			}
+			if !strings.Contains(string(raw), "<kept>") {
+				t.Errorf("raw JSON should not escape HTML: %s", raw)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the message")
+		}
+
+		client.Close()
+		cancel()
+		stop()
+	}
+}
@@ -0,0 +1,542 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package sdk
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/internal/subprocess"
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// DefaultPoolSize is the number of transports a Pool keeps ready by default.
+const DefaultPoolSize = 1
+
+// Pool keeps streaming CLI transports connected ahead of time, so that a
+// Client or query starts without paying for CLI discovery, the version check
+// and process startup.
+//
+// Every pooled process is started with the pool's option template. A
+// transport that has been used carries conversation state, so it is closed
+// when released and the pool starts a replacement in the background; one
+// released unused is recycled.
+type Pool struct {
+	size     int
+	template []types.Option
+	options  *types.Options
+	factory  func(*types.Options) types.Transport
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	idle     []types.Transport
+	inUse    map[*PooledTransport]struct{}
+	starting int
+	waiting  int // Acquire calls waiting for a transport to start
+	closed   bool
+	changed  chan struct{} // Closed and replaced whenever a start finishes
+	lastErr  error
+	stats    PoolStats
+	startDur time.Duration
+}
+
+// PoolStats is a snapshot of a Pool's state and counters.
+type PoolStats struct {
+	Size     int // Configured number of ready transports
+	Idle     int // Transports ready to be acquired
+	InUse    int // Transports acquired and not yet released
+	Starting int // Transports being started
+
+	Hits          uint64 // Acquires served by a ready transport
+	Misses        uint64 // Acquires that had to start a transport
+	Started       uint64 // Transports started successfully
+	StartFailures uint64 // Transports that failed to start
+	Recycled      uint64 // Transports released unused and returned to the pool
+	Discarded     uint64 // Transports closed after use or found dead while idle
+
+	AverageStartTime time.Duration // Mean time to start a transport
+}
+
+// PoolOption configures a Pool.
+type PoolOption func(*Pool)
+
+// WithPoolOptions sets the option template every pooled CLI process is
+// started with.
+func WithPoolOptions(opts ...types.Option) PoolOption {
+	return func(p *Pool) {
+		p.template = append(p.template, opts...)
+	}
+}
+
+// WithPoolTransportFactory sets how pooled transports are created from the
+// template options (default: a local streaming subprocess transport). This
+// allows pooling remote or containerized transports.
+func WithPoolTransportFactory(factory func(*types.Options) types.Transport) PoolOption {
+	return func(p *Pool) {
+		p.factory = factory
+	}
+}
+
+// NewPool creates a pool that keeps size transports ready and starts filling
+// it in the background. Call Warm to wait until it is full, and Close to stop
+// every pooled process.
+func NewPool(size int, opts ...PoolOption) *Pool {
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+	p := &Pool{
+		size: size,
+		factory: func(options *types.Options) types.Transport {
+			return subprocess.NewStreamingTransport(options)
+		},
+		inUse:   make(map[*PooledTransport]struct{}),
+		changed: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.options = p.newOptions()
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.mu.Lock()
+	p.fill()
+	p.mu.Unlock()
+	return p
+}
+
+func (p *Pool) newOptions() *types.Options {
+	options := types.DefaultOptions()
+	types.ApplyOptions(options, p.template...)
+	return options
+}
+
+// Warm waits until the pool holds its configured number of ready transports.
+// It returns the error of a failed start, or ctx's error.
+func (p *Pool) Warm(ctx context.Context) error {
+	p.mu.Lock()
+	p.lastErr = nil
+	p.fill()
+	for {
+		if p.closed {
+			p.mu.Unlock()
+			return &types.ClosedError{Resource: "pool"}
+		}
+		if p.lastErr != nil {
+			err := p.lastErr
+			p.mu.Unlock()
+			return err
+		}
+		if len(p.idle) >= p.size {
+			p.mu.Unlock()
+			return nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		p.mu.Lock()
+	}
+}
+
+// Acquire returns a connected transport, waiting for one to start if none is
+// ready. It returns ctx's error if ctx ends first, and the error of a start
+// that fails while it waits. Closing the returned transport releases it to
+// the pool.
+func (p *Pool) Acquire(ctx context.Context) (*PooledTransport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	waiting := false
+	var failures uint64
+	defer func() {
+		if waiting {
+			p.waiting--
+		}
+	}()
+	for {
+		if p.closed {
+			return nil, &types.ClosedError{Resource: "pool"}
+		}
+		if pooled := p.take(); pooled != nil {
+			if waiting {
+				// Stop counting toward fill before refilling
+				p.waiting--
+				waiting = false
+			} else {
+				p.stats.Hits++
+			}
+			p.fill()
+			return pooled, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !waiting {
+			// Started transports serve waiting callers before the pool refills
+			waiting, failures = true, p.stats.StartFailures
+			p.stats.Misses++
+			p.waiting++
+			p.fill()
+		} else if p.stats.StartFailures != failures {
+			return nil, p.lastErr
+		}
+
+		changed := p.changed
+		p.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			p.mu.Lock()
+			return nil, ctx.Err()
+		}
+		p.mu.Lock()
+	}
+}
+
+// take checks out the first live idle transport, discarding dead ones, or
+// returns nil if there is none. Callers must hold p.mu.
+func (p *Pool) take() *PooledTransport {
+	for len(p.idle) > 0 {
+		transport := p.idle[0]
+		p.idle = p.idle[1:]
+		if transportAlive(transport) {
+			return p.checkout(transport)
+		}
+		p.stats.Discarded++
+		p.discard(transport)
+	}
+	return nil
+}
+
+// Connect returns a connected Client backed by a pooled transport. opts are
+// applied after the template and may only change SDK-side behavior, such as
+// hooks, CanUseTool and SDK MCP server handlers; options that would change the
+// CLI command line or environment are rejected.
+func (p *Pool) Connect(ctx context.Context, opts ...types.Option) (*Client, error) {
+	options, err := p.clientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(append(options, types.WithTransport(transport))...)
+	if err := client.Connect(ctx); err != nil {
+		transport.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// RunQuery performs a one-shot query like RunQuery, on a pooled transport.
+// opts follow the same rules as for Connect.
+func (p *Pool) RunQuery(ctx context.Context, prompt string, opts ...types.Option) ([]types.Message, error) {
+	options, err := p.clientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.Close()
+
+	return RunQuery(ctx, prompt, append(options, types.WithTransport(transport))...)
+}
+
+// clientOptions returns the template followed by opts, after checking that
+// opts leave the pooled CLI process unchanged.
+func (p *Pool) clientOptions(opts []types.Option) ([]types.Option, error) {
+	options := append(slices.Clip(p.template), opts...)
+	if len(opts) == 0 {
+		return options, nil
+	}
+	merged := types.DefaultOptions()
+	types.ApplyOptions(merged, options...)
+	if !subprocess.SameProcess(p.options, merged) {
+		return nil, &types.SDKError{Message: "options change the CLI process; add them to the pool's template instead"}
+	}
+	return options, nil
+}
+
+// Stats returns a snapshot of the pool's state and counters.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.Size = p.size
+	stats.Idle = len(p.idle)
+	stats.InUse = len(p.inUse)
+	stats.Starting = p.starting
+	if stats.Started > 0 {
+		stats.AverageStartTime = p.startDur / time.Duration(stats.Started)
+	}
+	return stats
+}
+
+// Close stops every pooled process, including those of transports still in use.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.notify()
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, transport := range idle {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transport.Close()
+		}()
+	}
+	wg.Wait()
+
+	// Stops starts in progress and the processes still in use
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+// fill starts transports in the background until the pool is full and every
+// waiting Acquire has one coming. Starts in progress count toward both, and
+// a start that finishes once the pool is full is discarded.
+// Callers must hold p.mu.
+func (p *Pool) fill() {
+	for !p.closed && len(p.idle)+p.starting < p.size+p.waiting {
+		p.starting++
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			transport, err := p.start()
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.starting--
+			p.record(err)
+			switch {
+			case err != nil:
+			case p.closed:
+				p.discard(transport)
+			case len(p.idle) >= p.size+p.waiting:
+				p.stats.Discarded++
+				p.discard(transport)
+			default:
+				p.idle = append(p.idle, transport)
+			}
+			p.notify()
+		}()
+	}
+}
+
+// start creates and connects a transport; its process lives until the pool closes.
+func (p *Pool) start() (types.Transport, error) {
+	begin := time.Now()
+	transport := p.factory(p.newOptions())
+	if err := transport.Connect(p.ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.startDur += time.Since(begin)
+	p.mu.Unlock()
+	return transport, nil
+}
+
+// record counts the outcome of a start. Callers must hold p.mu.
+func (p *Pool) record(err error) {
+	if err != nil {
+		p.stats.StartFailures++
+		p.lastErr = err
+		return
+	}
+	p.stats.Started++
+}
+
+// notify wakes Warm callers. Callers must hold p.mu.
+func (p *Pool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// checkout hands transport out. Callers must hold p.mu.
+func (p *Pool) checkout(transport types.Transport) *PooledTransport {
+	pooled := &PooledTransport{Transport: transport, pool: p}
+	p.inUse[pooled] = struct{}{}
+	return pooled
+}
+
+// release takes back a transport handed out by checkout.
+func (p *Pool) release(pooled *PooledTransport, used bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.inUse, pooled)
+	if used || p.closed || !transportAlive(pooled.Transport) {
+		p.stats.Discarded++
+		p.discard(pooled.Transport)
+	} else {
+		p.stats.Recycled++
+		p.idle = append(p.idle, pooled.Transport)
+		p.notify()
+	}
+	p.fill()
+}
+
+// discard closes transport in the background. Callers must hold p.mu.
+func (p *Pool) discard(transport types.Transport) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		transport.Close()
+	}()
+}
+
+// transportAlive reports whether an unused transport can still be handed out.
+// An idle CLI produces no output, so any message means it exited or failed.
+func transportAlive(transport types.Transport) bool {
+	if !transport.IsReady() {
+		return false
+	}
+	// Probe the channel Query will read, so Messages does not start consuming it
+	var wire <-chan types.WireMessage
+	if wt, ok := transport.(types.WireMessageTransport); ok {
+		wire = wt.WireMessages()
+	}
+	if wire != nil {
+		select {
+		case <-wire:
+			return false
+		default:
+			return true
+		}
+	}
+	select {
+	case <-transport.Messages():
+		return false
+	default:
+		return true
+	}
+}
+
+// PooledTransport is a transport handed out by a Pool. Closing it releases
+// the transport to the pool instead of stopping the CLI directly.
+type PooledTransport struct {
+	types.Transport
+	pool *Pool
+
+	mu       sync.Mutex
+	used     bool
+	released bool
+}
+
+// Write sends data to the CLI.
+func (t *PooledTransport) Write(data string) error {
+	if err := t.use(); err != nil {
+		return err
+	}
+	return t.Transport.Write(data)
+}
+
+// EndInput signals that no more input will be sent.
+func (t *PooledTransport) EndInput() error {
+	if err := t.use(); err != nil {
+		return err
+	}
+	return t.Transport.EndInput()
+}
+
+// Messages returns the channel of messages from the CLI.
+func (t *PooledTransport) Messages() <-chan map[string]any {
+	// A reader may consume messages meant for the next user, so a transport
+	// that has been read from is never recycled
+	t.use()
+	return t.Transport.Messages()
+}
+
+// WireMessages returns the channel of messages with their original JSON, or
+// nil if the transport does not provide it.
+func (t *PooledTransport) WireMessages() <-chan types.WireMessage {
+	t.use()
+	if wire, ok := t.Transport.(types.WireMessageTransport); ok {
+		return wire.WireMessages()
+	}
+	return nil
+}
+
+// Errors returns the channel of errors from the transport, if it reports them.
+func (t *PooledTransport) Errors() <-chan error {
+	t.use()
+	if withErrors, ok := t.Transport.(types.ErrorTransport); ok {
+		return withErrors.Errors()
+	}
+	return nil
+}
+
+// ExitError returns why the CLI exited, if the transport reports it.
+func (t *PooledTransport) ExitError() error {
+	if withExit, ok := t.Transport.(types.ExitErrorTransport); ok {
+		return withExit.ExitError()
+	}
+	return nil
+}
+
+// ResourceLimits returns the resource limits in effect, if the transport reports them.
+func (t *PooledTransport) ResourceLimits() *types.ResourceLimits {
+	if limited, ok := t.Transport.(types.ResourceLimitTransport); ok {
+		return limited.ResourceLimits()
+	}
+	return nil
+}
+
+// Close releases the transport to the pool. It is safe to call more than once.
+func (t *PooledTransport) Close() error {
+	t.mu.Lock()
+	if t.released {
+		t.mu.Unlock()
+		return nil
+	}
+	t.released = true
+	used := t.used
+	t.mu.Unlock()
+
+	t.pool.release(t, used)
+	return nil
+}
+
+// use marks the transport as used, or fails if it was released.
+func (t *PooledTransport) use() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.released {
+		return &types.ClosedError{Resource: "pooled transport"}
+	}
+	t.used = true
+	return nil
+}
+
+var (
+	_ types.Transport              = (*PooledTransport)(nil)
+	_ types.ErrorTransport         = (*PooledTransport)(nil)
+	_ types.ExitErrorTransport     = (*PooledTransport)(nil)
+	_ types.ResourceLimitTransport = (*PooledTransport)(nil)
+	_ types.WireMessageTransport   = (*PooledTransport)(nil)
+)
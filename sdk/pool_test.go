@@ -0,0 +1,377 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package sdk
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// mockFactory creates MockTransports and remembers them.
+type mockFactory struct {
+	mu         sync.Mutex
+	transports []*MockTransport
+	connectErr error
+}
+
+func (f *mockFactory) create(*types.Options) types.Transport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	transport := NewMockTransport()
+	transport.SetConnectError(f.connectErr)
+	f.transports = append(f.transports, transport)
+	return transport
+}
+
+func (f *mockFactory) transport(i int) *MockTransport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.transports[i]
+}
+
+func (f *mockFactory) created() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.transports)
+}
+
+func newMockPool(t *testing.T, size int) (*Pool, *mockFactory) {
+	t.Helper()
+	factory := &mockFactory{}
+	pool := NewPool(size, WithPoolTransportFactory(factory.create))
+	t.Cleanup(func() { pool.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Warm(ctx); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	return pool, factory
+}
+
+// waitForStats polls until cond holds for the pool's stats.
+func waitForStats(t *testing.T, pool *Pool, cond func(PoolStats) bool) PoolStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := pool.Stats()
+		if cond(stats) {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool did not reach expected state: %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPool_WarmFillsPool(t *testing.T) {
+	pool, factory := newMockPool(t, 3)
+
+	stats := pool.Stats()
+	if stats.Size != 3 || stats.Idle != 3 || stats.Started != 3 || stats.InUse != 0 {
+		t.Fatalf("unexpected stats after Warm: %+v", stats)
+	}
+	if factory.created() != 3 {
+		t.Fatalf("expected 3 transports, created %d", factory.created())
+	}
+}
+
+func TestPool_AcquireServesReadyTransportAndRefills(t *testing.T) {
+	pool, factory := newMockPool(t, 2)
+
+	transport, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if !transport.IsReady() {
+		t.Fatal("acquired transport is not connected")
+	}
+
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Idle == 2 })
+	if stats.Hits != 1 || stats.Misses != 0 || stats.InUse != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if factory.created() != 3 {
+		t.Fatalf("expected a replacement to be started, created %d", factory.created())
+	}
+}
+
+func TestPool_UsedTransportIsDiscarded(t *testing.T) {
+	pool, factory := newMockPool(t, 1)
+
+	transport, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	transport.Close()
+
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Discarded == 1 && s.Idle == 1 })
+	if stats.Recycled != 0 || stats.InUse != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	first := factory.transport(0)
+	waitForStats(t, pool, func(PoolStats) bool { return !first.IsReady() })
+
+	if err := transport.Write("late"); !errors.Is(err, types.ErrClosed) {
+		t.Fatalf("expected ErrClosed writing to a released transport, got %v", err)
+	}
+}
+
+func TestPool_UnusedTransportIsRecycled(t *testing.T) {
+	pool, factory := newMockPool(t, 1)
+
+	transport, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	transport.Close()
+	transport.Close()
+
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Recycled == 1 })
+	if stats.Discarded != 0 || stats.InUse != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if !factory.transport(0).IsReady() {
+		t.Fatal("recycled transport was closed")
+	}
+}
+
+func TestPool_DeadIdleTransportIsSkipped(t *testing.T) {
+	pool, factory := newMockPool(t, 1)
+
+	// The CLI exits while idle
+	factory.transport(0).Close()
+
+	transport, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer transport.Close()
+	if !transport.IsReady() {
+		t.Fatal("acquired a dead transport")
+	}
+
+	stats := pool.Stats()
+	if stats.Discarded != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestPool_AcquireStartsTransportWhenEmpty(t *testing.T) {
+	pool, _ := newMockPool(t, 1)
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer first.Close()
+	second, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer second.Close()
+
+	stats := pool.Stats()
+	if stats.InUse != 2 || stats.Hits+stats.Misses != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+// gatedTransport connects only once its gate is closed.
+type gatedTransport struct {
+	*MockTransport
+	gate <-chan struct{}
+}
+
+func (t *gatedTransport) Connect(ctx context.Context) error {
+	select {
+	case <-t.gate:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.MockTransport.Connect(ctx)
+}
+
+// newGatedPool returns an unwarmed pool whose starts wait for the returned
+// function to be called.
+func newGatedPool(t *testing.T, size int) (*Pool, *mockFactory, func()) {
+	t.Helper()
+	factory := &mockFactory{}
+	gate := make(chan struct{})
+	pool := NewPool(size, WithPoolTransportFactory(func(options *types.Options) types.Transport {
+		return &gatedTransport{MockTransport: factory.create(options).(*MockTransport), gate: gate}
+	}))
+	t.Cleanup(func() { pool.Close() })
+	return pool, factory, func() { close(gate) }
+}
+
+func TestPool_AcquireHonoursContextWhileStarting(t *testing.T) {
+	pool, factory, open := newGatedPool(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context's error, got %v", err)
+	}
+
+	// The start made for the abandoned Acquire does not grow the pool
+	open()
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Starting == 0 })
+	if stats.Idle != 1 || stats.Discarded != 1 || factory.created() != 2 {
+		t.Fatalf("unexpected stats: %+v, %d created", stats, factory.created())
+	}
+}
+
+func TestPool_ConcurrentMissesCountStartsTowardSize(t *testing.T) {
+	pool, factory, open := newGatedPool(t, 1)
+
+	const callers = 3
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			transport, err := pool.Acquire(context.Background())
+			if err == nil {
+				defer transport.Close()
+			}
+			errs <- err
+		}()
+	}
+	// One start fills the pool and one serves each waiting caller
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Misses == callers })
+	if stats.Starting != callers+1 {
+		t.Fatalf("expected %d starts in progress, got %+v", callers+1, stats)
+	}
+
+	open()
+	for range callers {
+		if err := <-errs; err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+	}
+	if factory.created() != callers+1 {
+		t.Fatalf("expected %d transports started, got %d", callers+1, factory.created())
+	}
+}
+
+func TestPool_StartFailure(t *testing.T) {
+	factory := &mockFactory{connectErr: &types.CLINotFoundError{CLIPath: "/missing/claude"}}
+	pool := NewPool(2, WithPoolTransportFactory(factory.create))
+	defer pool.Close()
+
+	if err := pool.Warm(context.Background()); !errors.Is(err, types.ErrCLINotFound) {
+		t.Fatalf("expected Warm to report the start failure, got %v", err)
+	}
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, types.ErrCLINotFound) {
+		t.Fatalf("expected Acquire to report the start failure, got %v", err)
+	}
+	if stats := pool.Stats(); stats.StartFailures == 0 || stats.Started != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	pool, factory := newMockPool(t, 2)
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for i, transport := range factory.transports {
+		if transport.IsReady() {
+			t.Errorf("transport %d still open after Close", i)
+		}
+	}
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, types.ErrClosed) {
+		t.Fatalf("expected ErrClosed from a closed pool, got %v", err)
+	}
+	if err := pool.Warm(context.Background()); !errors.Is(err, types.ErrClosed) {
+		t.Fatalf("expected ErrClosed from Warm, got %v", err)
+	}
+}
+
+func TestPool_RejectsOptionsThatChangeTheProcess(t *testing.T) {
+	pool, _ := newMockPool(t, 1)
+
+	_, err := pool.Connect(context.Background(), types.WithModel("claude-opus-4-5"))
+	var sdkErr *types.SDKError
+	if !errors.As(err, &sdkErr) {
+		t.Fatalf("expected *types.SDKError, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Hits != 0 || stats.Idle != 1 {
+		t.Fatalf("rejected options should not acquire a transport: %+v", stats)
+	}
+
+	// SDK-side options leave the process unchanged
+	if _, err := pool.clientOptions([]types.Option{
+		WithCanUseTool(func(string, map[string]any, *types.ToolPermissionContext) (types.PermissionResult, error) {
+			return nil, nil
+		}),
+		WithPreToolUseHook(nil, func(any, *string, *types.HookContext) (*types.HookOutput, error) {
+			return nil, nil
+		}),
+	}); err != nil {
+		t.Fatalf("expected SDK-side options to be accepted, got %v", err)
+	}
+}
+
+func TestPool_ConnectAndRunQuery_E2E(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script subprocess harness is not supported on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	cliPath := filepath.Join(tmpDir, "claude")
+	writeFakeCLIScript(t, cliPath)
+
+	pool := NewPool(1, WithPoolOptions(
+		types.WithCLIPath(cliPath),
+		types.WithEnv(map[string]string{"LOG_FILE": filepath.Join(tmpDir, "input.log")}),
+	))
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := pool.Warm(ctx); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+
+	client, err := pool.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if sid := client.SessionID(); sid != "sess_e2e" {
+		t.Fatalf("expected session ID sess_e2e, got %q", sid)
+	}
+	messages, err := client.ReceiveResponse("hello")
+	if err != nil {
+		t.Fatalf("ReceiveResponse failed: %v", err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected messages from the pooled CLI")
+	}
+	client.Close()
+
+	messages, err = pool.RunQuery(ctx, "hello again")
+	if err != nil {
+		t.Fatalf("RunQuery failed: %v", err)
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Fatalf("expected a result message last, got %T", messages[len(messages)-1])
+	}
+
+	stats := waitForStats(t, pool, func(s PoolStats) bool { return s.Discarded == 2 && s.Idle == 1 })
+	if stats.Hits != 2 || stats.InUse != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -4,10 +4,12 @@
 package sdk
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -32,6 +34,10 @@ const MessageChannelBuffer = 100
 // consistent buffering behavior for both message processing paths.
 const RawMessageChannelBuffer = 100
 
+// warningChannelBuffer is the buffer size for the warnings channel. Each kind
+// of warning is sent at most once per query, so a few slots hold them all.
+const warningChannelBuffer = 4
+
 // Query handles the bidirectional control protocol.
 type Query struct {
 	transport types.Transport
@@ -49,6 +55,16 @@ type Query struct {
 
 	// Permission callback
 	canUseTool types.CanUseToolCallback
+	// Policy applied when canUseTool is unset
+	permissionFallback *types.PermissionFallback
+	fallbackWarned     atomic.Bool
+
+	// Report unknown message types as errors
+	strictMessageParsing bool
+	// Attach each message's JSON to the parsed message
+	retainRawJSON bool
+	// Follows Task subagents when subagent events are enabled
+	subagents *subagentRouter
 
 	// MCP server registry
 	mcpServers   map[string]*types.MCPServer
@@ -58,6 +74,7 @@ type Query struct {
 	messages    chan types.Message  // Parsed messages
 	rawMessages chan map[string]any // Raw messages for custom handling
 	errors      chan error
+	warnings    chan error
 
 	// Result tracking
 	resultReceived  atomic.Bool
@@ -66,6 +83,12 @@ type Query struct {
 	firstResultChan chan struct{} // Closed when first result is received
 	firstResultOnce sync.Once     // Ensures channel is closed only once
 
+	// System init tracking
+	systemInit     *types.SystemInitMessage
+	systemInitMu   sync.RWMutex
+	systemInitChan chan struct{} // Closed when the first init message is received
+	systemInitOnce sync.Once
+
 	// Stream close timeout for waiting for first result
 	streamCloseTimeout time.Duration
 	// Initialize request timeout
@@ -79,6 +102,11 @@ type Query struct {
 	wg     sync.WaitGroup
 	closed atomic.Bool
 
+	// Transport shutdown
+	transportDone chan struct{} // Closed when the transport message stream ends
+	exitErr       error
+	exitMu        sync.Mutex
+
 	// Initialization result
 	initResult map[string]any
 	initMu     sync.RWMutex
@@ -95,7 +123,10 @@ func NewQuery(transport types.Transport, streaming bool) *Query {
 		messages:           make(chan types.Message, MessageChannelBuffer),
 		rawMessages:        make(chan map[string]any, RawMessageChannelBuffer),
 		errors:             make(chan error, 1),
+		warnings:           make(chan error, warningChannelBuffer),
 		firstResultChan:    make(chan struct{}),
+		systemInitChan:     make(chan struct{}),
+		transportDone:      make(chan struct{}),
 		streamCloseTimeout: DefaultStreamCloseTimeout,
 		initializeTimeout:  60 * time.Second,
 		agents:             make(map[string]types.AgentDefinition),
@@ -131,6 +162,13 @@ func (q *Query) Errors() <-chan error {
 	return q.errors
 }
 
+// Warnings returns the channel of warnings, such as
+// *types.PermissionFallbackWarning and *types.CLIVersionWarning. They are
+// kept off Errors so they never take the place of an error.
+func (q *Query) Warnings() <-chan error {
+	return q.warnings
+}
+
 // ResultReceived returns true if a result message has been received.
 func (q *Query) ResultReceived() bool {
 	return q.resultReceived.Load()
@@ -143,6 +181,18 @@ func (q *Query) LastResult() *types.ResultMessage {
 	return q.lastResult
 }
 
+// SystemInit returns the latest system init message, or nil if none has been received.
+func (q *Query) SystemInit() *types.SystemInitMessage {
+	q.systemInitMu.RLock()
+	defer q.systemInitMu.RUnlock()
+	return q.systemInit
+}
+
+// WaitForSystemInit returns a channel that is closed when the first system init message is received.
+func (q *Query) WaitForSystemInit() <-chan struct{} {
+	return q.systemInitChan
+}
+
 // Close stops the query.
 func (q *Query) Close() error {
 	if q.closed.Swap(true) {
@@ -152,12 +202,19 @@ func (q *Query) Close() error {
 	if q.cancel != nil {
 		q.cancel()
 	}
+	// routeMessages may be blocked sending a subagent event nobody reads
+	if q.subagents != nil {
+		q.subagents.stopSending()
+	}
 
 	q.wg.Wait()
 
 	// Close channels after goroutines finish
 	close(q.messages)
 	close(q.rawMessages)
+	if q.subagents != nil {
+		q.subagents.close()
+	}
 
 	return nil
 }
@@ -169,6 +226,17 @@ func (q *Query) routeMessages() {
 	if withErrors, ok := q.transport.(types.ErrorTransport); ok {
 		transportErrors = withErrors.Errors()
 	}
+	// Prefer the stream that carries each message's original JSON
+	var wire <-chan types.WireMessage
+	var messages <-chan map[string]any
+	if wt, ok := q.transport.(types.WireMessageTransport); ok {
+		wire = wt.WireMessages()
+	}
+	if wire == nil {
+		messages = q.transport.Messages()
+	}
+	// processErr remembers a process failure reported before the stream closed
+	var processErr error
 
 	for {
 		select {
@@ -183,31 +251,97 @@ func (q *Query) routeMessages() {
 			if err == nil {
 				continue
 			}
-			select {
-			case q.errors <- err:
-			default:
+			if errors.Is(err, types.ErrProcess) {
+				processErr = err
+			}
+			q.sendError(err)
+		case raw, ok := <-messages:
+			if !ok {
+				q.handleTransportClosed(transportErrors, processErr)
+				return
 			}
-		case raw, ok := <-q.transport.Messages():
+			q.routeMessage(raw, nil)
+		case msg, ok := <-wire:
 			if !ok {
-				q.failPendingRequests(fmt.Errorf("transport message stream closed"))
+				q.handleTransportClosed(transportErrors, processErr)
 				return
 			}
+			q.routeMessage(msg.Message, msg.JSON)
+		}
+	}
+}
 
-			msgType, _ := raw["type"].(string)
-
-			switch msgType {
-			case "control_response":
-				q.handleControlResponse(raw)
-			case "control_request":
-				go q.handleControlRequest(raw)
-			case "control_cancel_request":
-				q.handleCancelRequest(raw)
-			default:
-				// Parse and route regular messages
-				q.handleSDKMessage(raw)
+// routeMessage dispatches one message from the transport. data is the JSON it
+// was decoded from, or nil if the transport does not provide it.
+func (q *Query) routeMessage(raw map[string]any, data json.RawMessage) {
+	msgType, _ := raw["type"].(string)
+
+	switch msgType {
+	case "control_response":
+		q.handleControlResponse(raw)
+	case "control_request":
+		go q.handleControlRequest(raw)
+	case "control_cancel_request":
+		q.handleCancelRequest(raw)
+	default:
+		// Parse and route regular messages
+		q.handleSDKMessage(raw, data)
+	}
+}
+
+// handleTransportClosed records why the transport ended and wakes up receivers.
+// Errors the transport reported just before closing are forwarded first, so a
+// *types.ProcessError is not lost to the race between the two channels.
+func (q *Query) handleTransportClosed(transportErrors <-chan error, exitErr error) {
+	for drained := false; transportErrors != nil && !drained; {
+		select {
+		case err, ok := <-transportErrors:
+			if !ok {
+				drained = true
+				continue
 			}
+			if err == nil {
+				continue
+			}
+			if errors.Is(err, types.ErrProcess) {
+				exitErr = err
+			}
+			q.sendError(err)
+		default:
+			drained = true
 		}
 	}
+	if exitErr == nil {
+		if withExit, ok := q.transport.(types.ExitErrorTransport); ok {
+			exitErr = withExit.ExitError()
+		}
+	}
+
+	q.exitMu.Lock()
+	q.exitErr = exitErr
+	q.exitMu.Unlock()
+	close(q.transportDone)
+
+	if exitErr != nil {
+		q.failPendingRequests(exitErr)
+		return
+	}
+	q.failPendingRequests(fmt.Errorf("transport message stream closed"))
+}
+
+// TransportDone returns a channel that is closed when the transport stops
+// delivering messages, e.g. because the CLI process exited.
+func (q *Query) TransportDone() <-chan struct{} {
+	return q.transportDone
+}
+
+// ExitError returns why the transport stopped, typically a *types.ProcessError
+// when the CLI crashed. It is nil while the transport is running and after a
+// clean shutdown.
+func (q *Query) ExitError() error {
+	q.exitMu.Lock()
+	defer q.exitMu.Unlock()
+	return q.exitErr
 }
 
 // failPendingRequests unblocks all pending control requests with an error.
@@ -226,7 +360,7 @@ func (q *Query) failPendingRequests(err error) {
 }
 
 // handleSDKMessage parses and routes an SDK message.
-func (q *Query) handleSDKMessage(raw map[string]any) {
+func (q *Query) handleSDKMessage(raw map[string]any, data json.RawMessage) {
 	// Send to raw channel for custom handling
 	select {
 	case q.rawMessages <- raw:
@@ -236,15 +370,36 @@ func (q *Query) handleSDKMessage(raw map[string]any) {
 
 	// Parse message
 	msg, err := parser.ParseMessage(raw)
-	if err != nil {
-		// Still send to channel for unknown message types
-		select {
-		case q.errors <- err:
-		default:
+	if unknown, ok := msg.(*types.UnknownMessage); ok && q.strictMessageParsing {
+		msg, err = nil, &types.MessageParseError{
+			Message: fmt.Sprintf("unknown message type: %s", unknown.Type),
+			Data:    raw,
 		}
+	}
+	if err != nil {
+		q.sendError(err)
 		return
 	}
 
+	if q.retainRawJSON {
+		if m, ok := msg.(types.RawJSONMessage); ok {
+			if data == nil {
+				data = encodeRawJSON(raw)
+			}
+			m.SetRawJSON(data)
+		}
+	}
+
+	// Track the session configuration
+	if initMsg, ok := msg.(*types.SystemInitMessage); ok {
+		q.systemInitMu.Lock()
+		q.systemInit = initMsg
+		q.systemInitMu.Unlock()
+		q.systemInitOnce.Do(func() {
+			close(q.systemInitChan)
+		})
+	}
+
 	// Track result messages
 	if result, ok := msg.(*types.ResultMessage); ok {
 		q.resultMu.Lock()
@@ -257,6 +412,10 @@ func (q *Query) handleSDKMessage(raw map[string]any) {
 		})
 	}
 
+	if q.subagents != nil {
+		q.subagents.observe(msg)
+	}
+
 	// Send parsed message
 	select {
 	case q.messages <- msg:
@@ -437,12 +596,7 @@ func (q *Query) hookOutputToResponse(output *types.HookOutput) map[string]any {
 // handleCanUseToolTyped handles tool permission requests using typed request.
 func (q *Query) handleCanUseToolTyped(req *types.SDKControlPermissionRequest) (map[string]any, error) {
 	if q.canUseTool == nil {
-		// Default: allow all
-		resp := map[string]any{"behavior": "allow"}
-		if req.ToolUseID != "" {
-			resp["toolUseID"] = req.ToolUseID
-		}
-		return resp, nil
+		return q.handleUnconfiguredPermission(req), nil
 	}
 
 	ctx := &types.ToolPermissionContext{
@@ -471,6 +625,47 @@ func (q *Query) handleCanUseToolTyped(req *types.SDKControlPermissionRequest) (m
 	return resp, nil
 }
 
+// sendError reports err on the warnings channel if it is a warning, or else
+// on the errors channel. It drops err rather than block when the channel is full.
+func (q *Query) sendError(err error) {
+	errs := q.errors
+	if isWarning(err) {
+		errs = q.warnings
+	}
+	select {
+	case errs <- err:
+	default:
+	}
+}
+
+// handleUnconfiguredPermission answers a permission request using the fallback
+// policy and warns once on the warnings channel that no callback is configured.
+func (q *Query) handleUnconfiguredPermission(req *types.SDKControlPermissionRequest) map[string]any {
+	behavior := types.PermissionFallbackAllow
+	message := ""
+	// Fail closed: only an explicit allow policy allows
+	if q.permissionFallback != nil && q.permissionFallback.Behavior != types.PermissionFallbackAllow {
+		behavior = types.PermissionFallbackDeny
+		message = q.permissionFallback.Message
+		if message == "" {
+			message = types.DefaultPermissionFallbackMessage
+		}
+	}
+
+	if !q.fallbackWarned.Swap(true) {
+		q.sendError(&types.PermissionFallbackWarning{ToolName: req.ToolName, Behavior: behavior})
+	}
+
+	resp := map[string]any{"behavior": string(behavior)}
+	if behavior == types.PermissionFallbackDeny {
+		resp["message"] = message
+	}
+	if req.ToolUseID != "" {
+		resp["toolUseID"] = req.ToolUseID
+	}
+	return resp
+}
+
 // permissionResultToResponse converts a permission result to a response map.
 func (q *Query) permissionResultToResponse(result types.PermissionResult) (map[string]any, error) {
 	switch r := result.(type) {
@@ -540,6 +735,65 @@ func (q *Query) SetCanUseTool(callback types.CanUseToolCallback) {
 	q.canUseTool = callback
 }
 
+// SetPermissionFallback sets the policy used when no tool permission callback is set.
+func (q *Query) SetPermissionFallback(fallback *types.PermissionFallback) {
+	q.permissionFallback = fallback
+}
+
+// SetStrictMessageParsing makes messages of unknown types errors instead of
+// *types.UnknownMessage values.
+func (q *Query) SetStrictMessageParsing(strict bool) {
+	q.strictMessageParsing = strict
+}
+
+// SetRetainRawJSON attaches each message's JSON to the parsed message.
+func (q *Query) SetRetainRawJSON(retain bool) {
+	q.retainRawJSON = retain
+}
+
+// SetSubagentEvents enables reporting Task subagents on SubagentEvents. It
+// must be called before Initialize, which registers the hooks it relies on.
+func (q *Query) SetSubagentEvents(enabled bool) {
+	if enabled && q.subagents == nil {
+		q.subagents = newSubagentRouter()
+	} else if !enabled {
+		q.subagents = nil
+	}
+}
+
+// SubagentEvents returns the channel of subagent events, which is closed
+// immediately when subagent events are disabled.
+func (q *Query) SubagentEvents() <-chan *types.SubagentEvent {
+	if q.subagents == nil {
+		ch := make(chan *types.SubagentEvent)
+		close(ch)
+		return ch
+	}
+	return q.subagents.events
+}
+
+// Subagents returns the subagents seen so far, in the order they started.
+func (q *Query) Subagents() []types.Subagent {
+	if q.subagents == nil {
+		return nil
+	}
+	return q.subagents.subagents()
+}
+
+// encodeRawJSON re-encodes a message from a transport that does not implement
+// types.WireMessageTransport, so its original JSON is not available. The
+// result is not the CLI's JSON: keys come out sorted and numbers are
+// reformatted, though no field is lost.
+func encodeRawJSON(raw map[string]any) json.RawMessage {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(raw); err != nil {
+		return nil
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
 // SendMessage sends a single message to the CLI.
 func (q *Query) SendMessage(msg map[string]any) error {
 	data, err := json.Marshal(msg)
@@ -552,7 +806,21 @@ func (q *Query) SendMessage(msg map[string]any) error {
 
 // SendUserMessage sends a user message.
 func (q *Query) SendUserMessage(content string, sessionID string) error {
-	msg := map[string]any{
+	return q.SendMessage(userMessage(content, sessionID))
+}
+
+// SendUserInput sends a user message made of the input's text, images and documents.
+func (q *Query) SendUserInput(input *types.UserInput, sessionID string) error {
+	content, err := input.Content()
+	if err != nil {
+		return err
+	}
+	return q.SendMessage(userMessage(content, sessionID))
+}
+
+// userMessage builds a user message; content is a string or a content block array.
+func userMessage(content any, sessionID string) map[string]any {
+	return map[string]any{
 		"type": "user",
 		"message": map[string]any{
 			"role":    "user",
@@ -561,7 +829,6 @@ func (q *Query) SendUserMessage(content string, sessionID string) error {
 		"parent_tool_use_id": nil,
 		"session_id":         sessionID,
 	}
-	return q.SendMessage(msg)
 }
 
 // StreamInput streams messages from a channel to the CLI.
@@ -933,6 +1200,9 @@ func (q *Query) Initialize(hooks map[types.HookEvent][]types.HookMatcher) (map[s
 	}
 
 	// Build hooks configuration
+	if q.subagents != nil {
+		hooks = q.subagents.withHooks(hooks)
+	}
 	hooksConfig := q.buildHooksConfig(hooks)
 
 	request := map[string]any{
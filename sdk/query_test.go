@@ -6,6 +6,7 @@ package sdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -1337,6 +1338,99 @@ func TestQuery_TransportErrorDoesNotAbortRouting(t *testing.T) {
 	}
 }
 
+// exitingTransport is a MockTransport that reports an exit error once closed.
+type exitingTransport struct {
+	*MockTransport
+	exitErr error
+}
+
+func (e *exitingTransport) ExitError() error {
+	return e.exitErr
+}
+
+func TestQuery_TransportExitSurfacesProcessError(t *testing.T) {
+	procErr := &types.ProcessError{ExitCode: 2, Stderr: "fatal: out of memory"}
+	transport := &exitingTransport{MockTransport: NewMockTransport(), exitErr: procErr}
+	query := NewQuery(transport, true)
+
+	if err := query.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	if query.ExitError() != nil {
+		t.Fatal("ExitError should be nil while the transport is running")
+	}
+	_ = transport.Close()
+
+	select {
+	case <-query.TransportDone():
+	case <-time.After(time.Second):
+		t.Fatal("TransportDone was not closed")
+	}
+	if !errors.Is(query.ExitError(), types.ErrProcess) {
+		t.Fatalf("expected ProcessError, got %v", query.ExitError())
+	}
+
+	// Pending and new control requests fail with the exit error
+	_, err := query.sendControlRequest(map[string]any{"subtype": "interrupt"}, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected control request to fail after transport exit")
+	}
+}
+
+func TestQuery_TransportExitForwardsReportedProcessError(t *testing.T) {
+	transport := NewMockTransport()
+	query := NewQuery(transport, true)
+
+	if err := query.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	// Transports report the error and then close, as SubprocessTransport does
+	procErr := &types.ProcessError{ExitCode: 1, Stderr: "boom"}
+	transport.SendError(procErr)
+	_ = transport.Close()
+
+	select {
+	case <-query.TransportDone():
+	case <-time.After(time.Second):
+		t.Fatal("TransportDone was not closed")
+	}
+	if query.ExitError() != procErr {
+		t.Fatalf("expected reported ProcessError, got %v", query.ExitError())
+	}
+	select {
+	case err := <-query.Errors():
+		if err != procErr {
+			t.Fatalf("expected ProcessError on Errors(), got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ProcessError on Errors()")
+	}
+}
+
+func TestQuery_TransportCleanCloseHasNoExitError(t *testing.T) {
+	transport := NewMockTransport()
+	query := NewQuery(transport, true)
+
+	if err := query.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	_ = transport.Close()
+	select {
+	case <-query.TransportDone():
+	case <-time.After(time.Second):
+		t.Fatal("TransportDone was not closed")
+	}
+	if err := query.ExitError(); err != nil {
+		t.Fatalf("expected nil ExitError, got %v", err)
+	}
+}
+
 func TestQuery_HandleHookCallback(t *testing.T) {
 	transport := NewMockTransport()
 	query := NewQuery(transport, true)
@@ -1639,6 +1733,151 @@ func TestQuery_HandleCanUseTool_ContextAndToolUseID(t *testing.T) {
 	}
 }
 
+func TestQuery_HandleCanUseTool_UnsetDefaultsToAllowWithWarning(t *testing.T) {
+	transport := NewMockTransport()
+	query := NewQuery(transport, true)
+
+	ctx := context.Background()
+	if err := query.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	for i := 0; i < 2; i++ {
+		transport.SendMessage(map[string]any{
+			"type":       "control_request",
+			"request_id": fmt.Sprintf("req_unset_%d", i),
+			"request": map[string]any{
+				"subtype":     "can_use_tool",
+				"tool_name":   "Bash",
+				"input":       map[string]any{"command": "ls"},
+				"tool_use_id": "tool_1",
+			},
+		})
+		if !transport.WaitForWrite(time.Second) {
+			t.Fatal("timeout waiting for permission response")
+		}
+	}
+
+	for _, data := range transport.Written() {
+		var resp map[string]any
+		json.Unmarshal([]byte(data), &resp)
+		respData := resp["response"].(map[string]any)["response"].(map[string]any)
+		if respData["behavior"] != "allow" {
+			t.Fatalf("expected allow behavior, got %v", respData["behavior"])
+		}
+		if respData["toolUseID"] != "tool_1" {
+			t.Fatalf("expected toolUseID passthrough, got %v", respData["toolUseID"])
+		}
+	}
+
+	select {
+	case err := <-query.Warnings():
+		var warning *types.PermissionFallbackWarning
+		if !errors.As(err, &warning) {
+			t.Fatalf("expected PermissionFallbackWarning, got %T: %v", err, err)
+		}
+		if warning.ToolName != "Bash" || warning.Behavior != types.PermissionFallbackAllow {
+			t.Fatalf("unexpected warning: %+v", warning)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected fallback warning on warnings channel")
+	}
+
+	select {
+	case err := <-query.Warnings():
+		t.Fatalf("expected a single warning, got a second one: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestQuery_SendErrorKeepsWarningsOffErrors(t *testing.T) {
+	query := NewQuery(NewMockTransport(), true)
+	warning := &types.PermissionFallbackWarning{ToolName: "Bash", Behavior: types.PermissionFallbackAllow}
+	first := errors.New("first")
+
+	query.sendError(warning)
+	query.sendError(first)
+	query.sendError(errors.New("second"))
+
+	if err := <-query.Errors(); err != first {
+		t.Fatalf("expected the first error, got %v", err)
+	}
+	if err := <-query.Warnings(); err != warning {
+		t.Fatalf("expected the warning on the warnings channel, got %v", err)
+	}
+}
+
+func TestQuery_HandleCanUseTool_UnsetStrictDenies(t *testing.T) {
+	tests := []struct {
+		name     string
+		fallback *types.PermissionFallback
+		want     string
+	}{
+		{
+			name:     "default message",
+			fallback: &types.PermissionFallback{Behavior: types.PermissionFallbackDeny},
+			want:     types.DefaultPermissionFallbackMessage,
+		},
+		{
+			name:     "custom message",
+			fallback: &types.PermissionFallback{Behavior: types.PermissionFallbackDeny, Message: "configure a policy"},
+			want:     "configure a policy",
+		},
+		{
+			name:     "unknown behavior",
+			fallback: &types.PermissionFallback{Behavior: "Deny"},
+			want:     types.DefaultPermissionFallbackMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewMockTransport()
+			query := NewQuery(transport, true)
+			query.SetPermissionFallback(tt.fallback)
+
+			if err := query.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			defer query.Close()
+
+			transport.SendMessage(map[string]any{
+				"type":       "control_request",
+				"request_id": "req_strict",
+				"request": map[string]any{
+					"subtype":   "can_use_tool",
+					"tool_name": "Write",
+					"input":     map[string]any{"file_path": "/etc/passwd"},
+				},
+			})
+			if !transport.WaitForWrite(time.Second) {
+				t.Fatal("timeout waiting for permission response")
+			}
+
+			var resp map[string]any
+			json.Unmarshal([]byte(transport.Written()[0]), &resp)
+			respData := resp["response"].(map[string]any)["response"].(map[string]any)
+			if respData["behavior"] != "deny" {
+				t.Fatalf("expected deny behavior, got %v", respData["behavior"])
+			}
+			if respData["message"] != tt.want {
+				t.Fatalf("expected message %q, got %v", tt.want, respData["message"])
+			}
+
+			select {
+			case err := <-query.Warnings():
+				var warning *types.PermissionFallbackWarning
+				if !errors.As(err, &warning) || warning.Behavior != types.PermissionFallbackDeny {
+					t.Fatalf("expected deny fallback warning, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected fallback warning on warnings channel")
+			}
+		})
+	}
+}
+
 // Task 8: Stream Input and Message Sending Tests
 
 func TestQuery_SendMessage(t *testing.T) {
@@ -1996,3 +2235,60 @@ func TestQuery_ChannelBufferConstants(t *testing.T) {
 		t.Errorf("rawMessages channel capacity = %d, want %d", cap(query.rawMessages), RawMessageChannelBuffer)
 	}
 }
+
+// wireMockTransport delivers messages with their original JSON.
+type wireMockTransport struct {
+	*MockTransport
+	wire chan types.WireMessage
+}
+
+func (m *wireMockTransport) WireMessages() <-chan types.WireMessage {
+	return m.wire
+}
+
+func TestQuery_RetainRawJSONFromWire(t *testing.T) {
+	transport := &wireMockTransport{MockTransport: NewMockTransport(), wire: make(chan types.WireMessage, 1)}
+	query := NewQuery(transport, true)
+	query.SetRetainRawJSON(true)
+	if err := query.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	// Re-encoding the decoded map would reorder keys, drop the ".0" and round the cost
+	line := `{"type":"result","subtype":"success","session_id":"s","total_cost_usd":1.0,"big":12345678901234567890,"a":1}`
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	transport.wire <- types.WireMessage{Message: decoded, JSON: json.RawMessage(line)}
+
+	select {
+	case msg := <-query.Messages():
+		if got := string(types.MessageRawJSON(msg)); got != line {
+			t.Errorf("RawJSON = %s, want %s", got, line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+}
+
+func TestQuery_NilWireMessagesUsesMessages(t *testing.T) {
+	transport := &wireMockTransport{MockTransport: NewMockTransport()}
+	query := NewQuery(transport, true)
+	query.SetRetainRawJSON(true)
+	if err := query.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer query.Close()
+
+	transport.SendMessage(map[string]any{"type": "result", "subtype": "success", "session_id": "s"})
+	select {
+	case msg := <-query.Messages():
+		if raw := types.MessageRawJSON(msg); !strings.Contains(string(raw), `"subtype":"success"`) {
+			t.Errorf("expected re-encoded JSON, got %s", raw)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+}
@@ -63,6 +63,14 @@ func (s *UnstableV2Session) Send(message string) error {
 	return s.client.SendQuery(message)
 }
 
+// SendInput sends a user message made of text, images and documents.
+func (s *UnstableV2Session) SendInput(input *types.UserInput) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("session is not initialized")
+	}
+	return s.client.SendInput(input)
+}
+
 // Stream returns the streaming output channel for the session.
 func (s *UnstableV2Session) Stream() <-chan types.Message {
 	if s == nil || s.client == nil {
@@ -198,3 +198,59 @@ func TestUnstableV2Prompt(t *testing.T) {
 		t.Fatalf("expected session_id=sess_prompt, got %s", result.SessionID)
 	}
 }
+
+func TestUnstableV2SessionSendInput(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startUnstableV2MockResponder(t, transport, "sess_input")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := UnstableV2CreateSession(ctx, types.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("UnstableV2CreateSession failed: %v", err)
+	}
+	defer session.Close()
+
+	input := types.NewUserInput().Text("Describe this").ImageURL("https://example.com/a.png")
+	if err := session.SendInput(input); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	for done := false; !done; {
+		select {
+		case msg := <-session.Stream():
+			_, done = msg.(*types.ResultMessage)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the result")
+		}
+	}
+
+	var sent map[string]any
+	for _, raw := range transport.Written() {
+		var msg map[string]any
+		if json.Unmarshal([]byte(raw), &msg) == nil && msg["type"] == "user" {
+			sent = msg
+		}
+	}
+	if sent == nil {
+		t.Fatal("no user message written")
+	}
+	content, ok := sent["message"].(map[string]any)["content"].([]any)
+	if !ok || len(content) != 2 {
+		t.Fatalf("expected a 2-part content array, got %v", sent["message"])
+	}
+	if part := content[1].(map[string]any); part["type"] != "image" {
+		t.Errorf("expected an image part, got %v", part)
+	}
+	if sent["session_id"] != "sess_input" {
+		t.Errorf("expected session_id sess_input, got %v", sent["session_id"])
+	}
+
+	if err := session.SendInput(types.NewUserInput()); err == nil {
+		t.Error("expected an error for empty input")
+	}
+	if err := session.SendInput(nil); err == nil {
+		t.Error("expected an error for nil input")
+	}
+}
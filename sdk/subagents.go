@@ -0,0 +1,259 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package sdk
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+	"github.com/victorarias/claude-agent-sdk-go/types/tools"
+)
+
+// subagentRouter follows Task subagents through the message stream and the
+// SubagentStart/SubagentStop hooks, and reports them as SubagentEvents.
+//
+// Subagents are keyed by the ID of their Task tool call. Hooks are matched to
+// them by the tool use ID when the CLI sends one, and otherwise by agent ID,
+// then by agent type in the order the subagents started.
+type subagentRouter struct {
+	mu     sync.Mutex
+	agents map[string]*types.Subagent
+	order  []string
+	// pending holds SubagentStart inputs that arrived before their Task call.
+	pending []*types.SubagentStartHookInput
+
+	// emitMu is taken before mu is released, so events are sent in the
+	// order they were produced without blocking state reads.
+	emitMu   sync.Mutex
+	events   chan *types.SubagentEvent
+	stop     chan struct{}
+	stopOnce sync.Once
+	closed   bool
+}
+
+func newSubagentRouter() *subagentRouter {
+	return &subagentRouter{
+		agents: make(map[string]*types.Subagent),
+		events: make(chan *types.SubagentEvent, MessageChannelBuffer),
+		stop:   make(chan struct{}),
+	}
+}
+
+// observe records msg and reports the subagent events it causes.
+func (r *subagentRouter) observe(msg types.Message) {
+	r.mu.Lock()
+	var events []*types.SubagentEvent
+
+	if parentID := subagentParentID(msg); parentID != "" {
+		agent, started := r.agent(parentID)
+		if started {
+			events = append(events, newSubagentEvent(types.SubagentEventStart, agent, nil))
+		}
+		events = append(events, newSubagentEvent(types.SubagentEventMessage, agent, msg))
+	}
+
+	switch m := msg.(type) {
+	case *types.AssistantMessage:
+		for _, use := range m.ToolCalls() {
+			if use.Name != tools.NameTask {
+				continue
+			}
+			agent, started := r.agent(use.ID)
+			if task, ok := decodeTaskInput(use); ok {
+				agent.AgentType = task.SubagentType
+				agent.Description = task.Description
+				agent.Prompt = task.Prompt
+			}
+			r.matchPending(agent)
+			if started {
+				events = append(events, newSubagentEvent(types.SubagentEventStart, agent, nil))
+			}
+		}
+	case *types.UserMessage:
+		for _, block := range m.Content {
+			result, ok := block.(*types.ToolResultBlock)
+			if !ok {
+				continue
+			}
+			if agent, ok := r.agents[result.ToolUseID]; ok && agent.Result == nil {
+				agent.Result = result
+				events = append(events, newSubagentEvent(types.SubagentEventResult, agent, nil))
+			}
+		}
+	}
+
+	r.emitLocked(events)
+}
+
+// agent returns the subagent of a Task call, creating it if needed.
+func (r *subagentRouter) agent(parentToolUseID string) (*types.Subagent, bool) {
+	if agent, ok := r.agents[parentToolUseID]; ok {
+		return agent, false
+	}
+	agent := &types.Subagent{ParentToolUseID: parentToolUseID}
+	r.agents[parentToolUseID] = agent
+	r.order = append(r.order, parentToolUseID)
+	return agent, true
+}
+
+// matchPending gives agent the ID of a SubagentStart hook that fired before
+// its Task call was seen.
+func (r *subagentRouter) matchPending(agent *types.Subagent) {
+	if agent.AgentID != "" {
+		return
+	}
+	for i, input := range r.pending {
+		if input.AgentType == agent.AgentType {
+			agent.AgentID = input.AgentID
+			r.pending = slices.Delete(r.pending, i, i+1)
+			return
+		}
+	}
+}
+
+// find returns the subagent a hook refers to, or nil.
+func (r *subagentRouter) find(toolUseID *string, agentID, agentType string, match func(*types.Subagent) bool) *types.Subagent {
+	if toolUseID != nil {
+		if agent, ok := r.agents[*toolUseID]; ok {
+			return agent
+		}
+	}
+	for _, id := range r.order {
+		if agent := r.agents[id]; agentID != "" && agent.AgentID == agentID {
+			return agent
+		}
+	}
+	for _, id := range r.order {
+		if agent := r.agents[id]; agent.AgentType == agentType && match(agent) {
+			return agent
+		}
+	}
+	return nil
+}
+
+func (r *subagentRouter) onStart(input *types.SubagentStartHookInput, toolUseID *string, _ *types.HookContext) (*types.HookOutput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent := r.find(toolUseID, input.AgentID, input.AgentType, func(a *types.Subagent) bool {
+		return a.AgentID == "" && !a.Done()
+	})
+	if agent == nil {
+		r.pending = append(r.pending, input)
+		return nil, nil
+	}
+	agent.AgentID = input.AgentID
+	if agent.AgentType == "" {
+		agent.AgentType = input.AgentType
+	}
+	return nil, nil
+}
+
+func (r *subagentRouter) onStop(input *types.SubagentStopHookInput, toolUseID *string, _ *types.HookContext) (*types.HookOutput, error) {
+	r.mu.Lock()
+	agent := r.find(toolUseID, input.AgentID, input.AgentType, func(a *types.Subagent) bool {
+		return !a.Stopped && !a.Done()
+	})
+	if agent == nil || agent.Stopped {
+		r.mu.Unlock()
+		return nil, nil
+	}
+	agent.Stopped = true
+	agent.TranscriptPath = input.AgentTranscriptPath
+	if agent.AgentID == "" {
+		agent.AgentID = input.AgentID
+	}
+	r.emitLocked([]*types.SubagentEvent{newSubagentEvent(types.SubagentEventStop, agent, nil)})
+	return nil, nil
+}
+
+// withHooks returns hooks with the router's SubagentStart and SubagentStop
+// hooks added. The caller's map and slices are left unchanged.
+func (r *subagentRouter) withHooks(hooks map[types.HookEvent][]types.HookMatcher) map[types.HookEvent][]types.HookMatcher {
+	merged := make(map[types.HookEvent][]types.HookMatcher, len(hooks)+2)
+	for event, matchers := range hooks {
+		merged[event] = matchers
+	}
+	merged[types.HookSubagentStart] = append(slices.Clip(merged[types.HookSubagentStart]), types.HookMatcher{
+		Hooks: []types.HookCallback{types.ToGenericCallback(r.onStart)},
+	})
+	merged[types.HookSubagentStop] = append(slices.Clip(merged[types.HookSubagentStop]), types.HookMatcher{
+		Hooks: []types.HookCallback{types.ToGenericCallback(r.onStop)},
+	})
+	return merged
+}
+
+// emitLocked sends events and releases mu. It must be called with mu held.
+func (r *subagentRouter) emitLocked(events []*types.SubagentEvent) {
+	r.emitMu.Lock()
+	r.mu.Unlock()
+	defer r.emitMu.Unlock()
+
+	if r.closed {
+		return
+	}
+	for _, event := range events {
+		select {
+		case r.events <- event:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// subagents returns copies of the subagents seen, in the order they started.
+func (r *subagentRouter) subagents() []types.Subagent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	agents := make([]types.Subagent, 0, len(r.order))
+	for _, id := range r.order {
+		agents = append(agents, *r.agents[id])
+	}
+	return agents
+}
+
+// stopSending unblocks pending sends and drops later events. It is safe to
+// call more than once.
+func (r *subagentRouter) stopSending() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// close stops sending and closes the events channel.
+func (r *subagentRouter) close() {
+	r.stopSending()
+	r.emitMu.Lock()
+	defer r.emitMu.Unlock()
+	r.closed = true
+	close(r.events)
+}
+
+func newSubagentEvent(eventType types.SubagentEventType, agent *types.Subagent, msg types.Message) *types.SubagentEvent {
+	return &types.SubagentEvent{Type: eventType, Subagent: *agent, Message: msg}
+}
+
+func decodeTaskInput(use *types.ToolUseBlock) (*tools.TaskInput, bool) {
+	input, err := tools.DecodeToolUse(use)
+	if err != nil {
+		return nil, false
+	}
+	task, ok := input.(*tools.TaskInput)
+	return task, ok
+}
+
+func subagentParentID(msg types.Message) string {
+	var id *string
+	switch m := msg.(type) {
+	case *types.AssistantMessage:
+		id = m.ParentToolUseID
+	case *types.UserMessage:
+		id = m.ParentToolUseID
+	case *types.StreamEvent:
+		id = m.ParentToolUseID
+	}
+	if id == nil {
+		return ""
+	}
+	return *id
+}
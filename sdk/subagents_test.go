@@ -0,0 +1,343 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func taskCall(id, agentType string) *types.AssistantMessage {
+	return &types.AssistantMessage{Content: []types.ContentBlock{&types.ToolUseBlock{
+		ID:        id,
+		Name:      "Task",
+		ToolInput: map[string]any{"description": "Find tests", "prompt": "Look for tests", "subagent_type": agentType},
+	}}}
+}
+
+func subagentText(parentID, text string) *types.AssistantMessage {
+	return &types.AssistantMessage{ParentToolUseID: &parentID, Content: []types.ContentBlock{&types.TextBlock{TextContent: text}}}
+}
+
+func taskResult(id string) *types.UserMessage {
+	return &types.UserMessage{Content: []types.ContentBlock{&types.ToolResultBlock{ToolUseID: id, ResultContent: "done"}}}
+}
+
+func drainSubagentEvents(r *subagentRouter) []*types.SubagentEvent {
+	var events []*types.SubagentEvent
+	for {
+		select {
+		case event := <-r.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSubagentRouter(t *testing.T) {
+	r := newSubagentRouter()
+	defer r.close()
+
+	r.observe(taskCall("tu_1", "explorer"))
+	r.observe(taskCall("tu_2", "explorer"))
+	// Hooks without a tool use ID are matched in start order
+	r.onStart(&types.SubagentStartHookInput{AgentID: "agent_1", AgentType: "explorer"}, nil, nil)
+	r.onStart(&types.SubagentStartHookInput{AgentID: "agent_2", AgentType: "explorer"}, nil, nil)
+	r.observe(subagentText("tu_2", "second"))
+	r.observe(subagentText("tu_1", "first"))
+	r.observe(&types.AssistantMessage{Content: []types.ContentBlock{&types.TextBlock{TextContent: "main thread"}}})
+	r.onStop(&types.SubagentStopHookInput{AgentID: "agent_1", AgentType: "explorer", AgentTranscriptPath: "/tmp/agent_1.jsonl"}, nil, nil)
+	r.observe(taskResult("tu_1"))
+
+	want := []struct {
+		eventType types.SubagentEventType
+		parentID  string
+		agentID   string
+	}{
+		{types.SubagentEventStart, "tu_1", ""},
+		{types.SubagentEventStart, "tu_2", ""},
+		{types.SubagentEventMessage, "tu_2", "agent_2"},
+		{types.SubagentEventMessage, "tu_1", "agent_1"},
+		{types.SubagentEventStop, "tu_1", "agent_1"},
+		{types.SubagentEventResult, "tu_1", "agent_1"},
+	}
+	events := drainSubagentEvents(r)
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, w := range want {
+		got := events[i]
+		if got.Type != w.eventType || got.Subagent.ParentToolUseID != w.parentID || got.Subagent.AgentID != w.agentID {
+			t.Errorf("event %d: got %s %+v, want %s %s %s", i, got.Type, got.Subagent, w.eventType, w.parentID, w.agentID)
+		}
+	}
+	if events[0].Subagent.AgentType != "explorer" || events[0].Subagent.Description != "Find tests" || events[0].Subagent.Prompt != "Look for tests" {
+		t.Errorf("expected the Task input on the start event, got %+v", events[0].Subagent)
+	}
+	if msg, ok := events[3].Message.(*types.AssistantMessage); !ok || msg.Text() != "first" {
+		t.Errorf("unexpected message event: %+v", events[3].Message)
+	}
+	if stop := events[4].Subagent; !stop.Stopped || stop.TranscriptPath != "/tmp/agent_1.jsonl" {
+		t.Errorf("unexpected stop event: %+v", stop)
+	}
+	if result := events[5].Subagent; !result.Done() || result.Result.ResultContent != "done" {
+		t.Errorf("unexpected result event: %+v", result)
+	}
+
+	agents := r.subagents()
+	if len(agents) != 2 || !agents[0].Done() || agents[1].Done() || agents[1].AgentID != "agent_2" {
+		t.Errorf("unexpected subagents: %+v", agents)
+	}
+}
+
+func TestSubagentRouter_OutOfOrder(t *testing.T) {
+	r := newSubagentRouter()
+	defer r.close()
+
+	// A start hook before the Task call waits for it
+	r.onStart(&types.SubagentStartHookInput{AgentID: "agent_1", AgentType: "reviewer"}, nil, nil)
+	r.observe(taskCall("tu_1", "reviewer"))
+	// A message for a Task call not seen yet starts its subagent
+	r.observe(subagentText("tu_9", "orphan"))
+	// Hooks with a tool use ID go to that call
+	toolUseID := "tu_9"
+	r.onStop(&types.SubagentStopHookInput{AgentID: "agent_9"}, &toolUseID, nil)
+	// Stops for unknown agents are ignored
+	r.onStop(&types.SubagentStopHookInput{AgentID: "agent_x", AgentType: "other"}, nil, nil)
+
+	events := drainSubagentEvents(r)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Subagent.AgentID != "agent_1" {
+		t.Errorf("expected the pending start hook to be matched, got %+v", events[0].Subagent)
+	}
+	if events[1].Type != types.SubagentEventStart || events[2].Type != types.SubagentEventMessage || events[1].Subagent.ParentToolUseID != "tu_9" {
+		t.Errorf("unexpected events for the orphan message: %+v, %+v", events[1], events[2])
+	}
+	if events[3].Type != types.SubagentEventStop || events[3].Subagent.AgentID != "agent_9" {
+		t.Errorf("unexpected stop event: %+v", events[3])
+	}
+}
+
+func TestSubagentRouter_WithHooks(t *testing.T) {
+	r := newSubagentRouter()
+	defer r.close()
+
+	user := types.HookMatcher{Hooks: []types.HookCallback{func(any, *string, *types.HookContext) (*types.HookOutput, error) {
+		return nil, nil
+	}}}
+	hooks := map[types.HookEvent][]types.HookMatcher{
+		types.HookSubagentStart: make([]types.HookMatcher, 1, 4),
+		types.HookPreToolUse:    {user},
+	}
+	hooks[types.HookSubagentStart][0] = user
+
+	merged := r.withHooks(hooks)
+	if len(merged[types.HookSubagentStart]) != 2 || len(merged[types.HookSubagentStop]) != 1 || len(merged[types.HookPreToolUse]) != 1 {
+		t.Errorf("unexpected merged hooks: %+v", merged)
+	}
+	if len(hooks[types.HookSubagentStart]) != 1 || len(hooks[types.HookSubagentStart][:2][1].Hooks) != 0 || len(hooks) != 2 {
+		t.Error("withHooks modified the caller's hooks")
+	}
+}
+
+func TestSubagentRouter_CloseUnblocksSend(t *testing.T) {
+	r := newSubagentRouter()
+	// The first message also starts the subagent, filling the buffer exactly
+	for i := 0; i < MessageChannelBuffer-1; i++ {
+		r.observe(subagentText("tu_1", "filler"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.observe(subagentText("tu_1", "blocked"))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	r.close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("close did not unblock a pending send")
+	}
+	r.observe(subagentText("tu_1", "after close"))
+}
+
+func TestClient_SubagentEvents(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_sub")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := NewClient(types.WithTransport(transport), types.WithSubagentEvents())
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// Find the callback IDs of the hooks registered for subagent events
+	callbackIDs := map[string]string{}
+	for _, raw := range transport.Written() {
+		var msg struct {
+			Request struct {
+				Subtype string `json:"subtype"`
+				Hooks   map[string][]struct {
+					HookCallbackIDs []string `json:"hookCallbackIds"`
+				} `json:"hooks"`
+			} `json:"request"`
+		}
+		if json.Unmarshal([]byte(raw), &msg) != nil || msg.Request.Subtype != "initialize" {
+			continue
+		}
+		for _, event := range []string{"SubagentStart", "SubagentStop"} {
+			if matchers := msg.Request.Hooks[event]; len(matchers) == 1 && len(matchers[0].HookCallbackIDs) == 1 {
+				callbackIDs[event] = matchers[0].HookCallbackIDs[0]
+			}
+		}
+	}
+	if len(callbackIDs) != 2 {
+		t.Fatalf("expected SubagentStart and SubagentStop hooks in initialize, got %v", callbackIDs)
+	}
+
+	go func() {
+		for range client.Messages() {
+		}
+	}()
+	transport.SendMessage(map[string]any{
+		"type":       "assistant",
+		"session_id": "sess_sub",
+		"message": map[string]any{"model": "m", "content": []any{map[string]any{
+			"type": "tool_use", "id": "tu_task", "name": "Task",
+			"input": map[string]any{"description": "d", "prompt": "p", "subagent_type": "explorer"},
+		}}},
+	})
+	transport.SendMessage(map[string]any{
+		"type":       "control_request",
+		"request_id": "req_start",
+		"request": map[string]any{
+			"subtype":     "hook_callback",
+			"callback_id": callbackIDs["SubagentStart"],
+			"input":       map[string]any{"hook_event_name": "SubagentStart", "agent_id": "agent_1", "agent_type": "explorer"},
+		},
+	})
+	// The hook is answered before the subagent's messages are sent
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.Subagents()) != 1 || client.Subagents()[0].AgentID != "agent_1" {
+		if time.Now().After(deadline) {
+			t.Fatalf("start hook was not correlated: %+v", client.Subagents())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	transport.SendMessage(map[string]any{
+		"type":               "assistant",
+		"session_id":         "sess_sub",
+		"parent_tool_use_id": "tu_task",
+		"message":            map[string]any{"model": "m", "content": []any{map[string]any{"type": "text", "text": "searching"}}},
+	})
+	transport.SendMessage(map[string]any{
+		"type":       "user",
+		"session_id": "sess_sub",
+		"message": map[string]any{"role": "user", "content": []any{map[string]any{
+			"type": "tool_result", "tool_use_id": "tu_task", "content": "found it",
+		}}},
+	})
+
+	wantTypes := []types.SubagentEventType{types.SubagentEventStart, types.SubagentEventMessage, types.SubagentEventResult}
+	for i, want := range wantTypes {
+		select {
+		case event := <-client.SubagentEvents():
+			if event.Type != want || event.Subagent.ParentToolUseID != "tu_task" || event.Subagent.AgentType != "explorer" {
+				t.Fatalf("event %d: got %s %+v, want %s", i, event.Type, event.Subagent, want)
+			}
+			if want != types.SubagentEventStart && event.Subagent.AgentID != "agent_1" {
+				t.Errorf("event %d: expected agent_1, got %+v", i, event.Subagent)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+
+	events := client.SubagentEvents()
+	client.Close()
+	for range events {
+	}
+}
+
+func TestClient_SubagentEventsCloseWhileBlocked(t *testing.T) {
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_sub")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := NewClient(types.WithTransport(transport), types.WithSubagentEvents())
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// Read messages but not subagent events, so the router fills up and blocks
+	received := make(chan struct{}, MessageChannelBuffer+1)
+	go func() {
+		for range client.Messages() {
+			received <- struct{}{}
+		}
+	}()
+	for i := 0; i < MessageChannelBuffer+1; i++ {
+		transport.SendMessage(map[string]any{
+			"type":               "assistant",
+			"session_id":         "sess_sub",
+			"parent_tool_use_id": "tu_task",
+			"message":            map[string]any{"model": "m", "content": []any{map[string]any{"type": "text", "text": "working"}}},
+		})
+	}
+	for i := 0; i < MessageChannelBuffer-1; i++ {
+		select {
+		case <-received:
+		case <-ctx.Done():
+			t.Fatalf("timed out after %d messages", i)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		client.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close hung while a subagent event send was blocked")
+	}
+}
+
+func TestClient_SubagentEventsDisabled(t *testing.T) {
+	client := NewClient()
+	if _, ok := <-client.SubagentEvents(); ok {
+		t.Error("expected a closed channel before Connect")
+	}
+	if client.Subagents() != nil {
+		t.Error("expected no subagents before Connect")
+	}
+
+	transport := NewMockTransport()
+	stop := startMockResponder(t, transport, "sess_sub")
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client = NewClient(types.WithTransport(transport))
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	if _, ok := <-client.SubagentEvents(); ok {
+		t.Error("expected a closed channel when subagent events are disabled")
+	}
+}
@@ -12,15 +12,31 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/victorarias/claude-agent-sdk-go/internal/subprocess"
 	"github.com/victorarias/claude-agent-sdk-go/types"
 )
 
 // Re-export version constants for convenience
 const (
-	Version           = types.Version
-	MinimumCLIVersion = types.MinimumCLIVersion
+	Version                 = types.Version
+	MinimumCLIVersion       = types.MinimumCLIVersion
+	MaximumTestedCLIVersion = types.MaximumTestedCLIVersion
 )
 
+// ResolveCLI locates the CLI the way Connect would with the given options and
+// reports its path, version and runtime executable. Discovery and version
+// results are cached for the life of the process, keyed on the CLI file's
+// path and modification time, so resolving ahead of time makes later
+// connections start faster.
+//
+// A version outside the CLIVersionPolicy is returned with a
+// *types.CLIVersionError alongside the CLIInfo.
+func ResolveCLI(opts ...types.Option) (*types.CLIInfo, error) {
+	options := types.DefaultOptions()
+	types.ApplyOptions(options, opts...)
+	return subprocess.ResolveCLI(options)
+}
+
 // parseVersion parses a semantic version string.
 func parseVersion(version string) ([3]int, error) {
 	parts := strings.Split(version, ".")
@@ -4,7 +4,10 @@
 package sdk
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
 )
 
 func TestParseVersion(t *testing.T) {
@@ -87,3 +90,16 @@ func TestCheckMinimumVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveCLI(t *testing.T) {
+	_, err := ResolveCLI(types.WithCLIPath("/nonexistent/claude"))
+	if !errors.Is(err, types.ErrCLINotFound) {
+		t.Fatalf("expected ErrCLINotFound, got %v", err)
+	}
+}
+
+func TestCLIVersionWarningIsWarning(t *testing.T) {
+	if !isWarning(&types.CLIVersionWarning{InstalledVersion: "9.0.0", MaximumVersion: MaximumTestedCLIVersion}) {
+		t.Error("expected a CLI version warning not to abort message reception")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package container provides a Transport that runs the Claude CLI inside a
+// container, one container per session.
+//
+// The CLI is started with an OCI runtime's command line (docker, podman or
+// nerdctl "run -i"), so isolation comes from the runtime rather than from
+// SandboxSettings:
+//
+//	opts := types.DefaultOptions()
+//	types.ApplyOptions(opts, types.WithCwd("/src/repo"))
+//	transport := container.NewTransport("ghcr.io/acme/claude-cli:2", opts,
+//		container.WithRuntime("podman"),
+//		container.WithRunArgs("--network", "none", "--memory", "2g"),
+//	)
+//	client := sdk.NewClient(types.WithTransport(transport))
+//
+// Options.Cwd and Options.AddDirs are bind-mounted at the same paths inside
+// the container, so file paths in tool calls match the host. Other paths in
+// Options (settings files, plugins) refer to the container's filesystem.
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/internal/subprocess"
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Defaults for Transport.
+const (
+	DefaultRuntime = "docker"
+	DefaultCLIPath = "claude"
+
+	// cleanupTimeout bounds the "rm -f" run when the transport closes.
+	cleanupTimeout = 10 * time.Second
+)
+
+// Transport runs the CLI in a container and otherwise behaves exactly like the
+// subprocess transport: same message parsing, error reporting and shutdown.
+type Transport struct {
+	proc *subprocess.SubprocessTransport
+
+	runtime    string
+	image      string
+	cliPath    string
+	name       string
+	runArgs    []string
+	forwardEnv []string
+	options    *types.Options
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithRuntime sets the container runtime executable (default: "docker").
+// Any runtime accepting docker's "run" flags works, e.g. "podman" or "nerdctl".
+func WithRuntime(runtime string) Option {
+	return func(t *Transport) {
+		t.runtime = runtime
+	}
+}
+
+// WithCLIPath sets the CLI path inside the image (default: "claude").
+func WithCLIPath(path string) Option {
+	return func(t *Transport) {
+		t.cliPath = path
+	}
+}
+
+// WithName sets the container name. By default a unique name is generated.
+func WithName(name string) Option {
+	return func(t *Transport) {
+		t.name = name
+	}
+}
+
+// WithRunArgs adds flags to "run", before the image name, e.g. resource
+// limits, "--network none" or "--user".
+func WithRunArgs(args ...string) Option {
+	return func(t *Transport) {
+		t.runArgs = append(t.runArgs, args...)
+	}
+}
+
+// WithForwardEnv forwards host environment variables by name, in addition to
+// Options.Env. Values are never placed on the runtime's command line.
+func WithForwardEnv(names ...string) Option {
+	return func(t *Transport) {
+		t.forwardEnv = append(t.forwardEnv, names...)
+	}
+}
+
+// NewTransport creates a streaming transport running image with opts.
+func NewTransport(image string, opts *types.Options, copts ...Option) *Transport {
+	if opts == nil {
+		opts = types.DefaultOptions()
+	}
+
+	t := &Transport{
+		proc:    subprocess.NewStreamingTransport(opts),
+		runtime: DefaultRuntime,
+		image:   image,
+		cliPath: DefaultCLIPath,
+		options: opts,
+	}
+	for _, opt := range copts {
+		opt(t)
+	}
+	if t.name == "" {
+		t.name = generateName()
+	}
+	t.proc.SetLauncher(launcher{t})
+	return t
+}
+
+// Name returns the container name.
+func (t *Transport) Name() string {
+	return t.name
+}
+
+// Connect starts the container.
+func (t *Transport) Connect(ctx context.Context) error {
+	return t.proc.Connect(ctx)
+}
+
+// Close stops the CLI and removes the container.
+func (t *Transport) Close() error {
+	return t.proc.Close()
+}
+
+// Kill forcefully terminates the runtime process; Close still removes the container.
+func (t *Transport) Kill() error {
+	return t.proc.Kill()
+}
+
+// Write sends data to the CLI.
+func (t *Transport) Write(data string) error {
+	return t.proc.Write(data)
+}
+
+// EndInput closes the CLI's stdin.
+func (t *Transport) EndInput() error {
+	return t.proc.EndInput()
+}
+
+// Messages returns the channel of messages from the CLI.
+func (t *Transport) Messages() <-chan map[string]any {
+	return t.proc.Messages()
+}
+
+// WireMessages returns the channel of messages from the CLI with the JSON
+// they were decoded from. Use either it or Messages, not both.
+func (t *Transport) WireMessages() <-chan types.WireMessage {
+	return t.proc.WireMessages()
+}
+
+// Errors returns the channel of errors from the CLI.
+func (t *Transport) Errors() <-chan error {
+	return t.proc.Errors()
+}
+
+// ExitError returns the exit error if the container has exited.
+func (t *Transport) ExitError() error {
+	return t.proc.ExitError()
+}
+
+// IsReady returns true if the transport is connected and not closed.
+func (t *Transport) IsReady() bool {
+	return t.proc.IsReady()
+}
+
+// SetStderrCallback sets a callback for the container's stderr output.
+func (t *Transport) SetStderrCallback(callback func(string)) {
+	t.proc.SetStderrCallback(callback)
+}
+
+// launcher runs the CLI through "<runtime> run".
+type launcher struct {
+	t *Transport
+}
+
+func (l launcher) CLIPath() string {
+	return l.t.cliPath
+}
+
+func (l launcher) Command(cli []string, env []string) ([]string, []string, error) {
+	t := l.t
+	args := []string{t.runtime, "run", "-i", "--rm", "--name", t.name}
+
+	mounts, err := t.mounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range mounts {
+		args = append(args, "-v", dir+":"+dir)
+	}
+	if len(mounts) > 0 && t.options.Cwd != "" {
+		args = append(args, "-w", mounts[0])
+	}
+
+	// "-e NAME" makes the runtime copy the value from its own environment,
+	// keeping secrets out of the process list
+	hostEnv := os.Environ()
+	seen := make(map[string]bool)
+	forward := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			args = append(args, "-e", name)
+		}
+	}
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		hostEnv = append(hostEnv, kv)
+		forward(name)
+	}
+	for _, name := range t.forwardEnv {
+		forward(name)
+	}
+
+	args = append(args, t.runArgs...)
+	args = append(args, t.image)
+	args = append(args, cli...)
+	return args, hostEnv, nil
+}
+
+// mounts returns the absolute host directories to bind-mount, Cwd first.
+func (t *Transport) mounts() ([]string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+
+	paths := make([]string, 0, 1+len(t.options.AddDirs))
+	if t.options.Cwd != "" {
+		paths = append(paths, t.options.Cwd)
+	}
+	paths = append(paths, t.options.AddDirs...)
+
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(abs, ":") {
+			return nil, fmt.Errorf("cannot mount %q: path contains ':'", abs)
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			dirs = append(dirs, abs)
+		}
+	}
+	return dirs, nil
+}
+
+// Cleanup removes the container in case it outlived the runtime process,
+// e.g. after a forced kill.
+func (l launcher) Cleanup() error {
+	t := l.t
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, t.runtime, "rm", "-f", t.name).CombinedOutput()
+	if err != nil && !bytes.Contains(bytes.ToLower(output), []byte("no such container")) {
+		return fmt.Errorf("failed to remove container %s: %w: %s", t.name, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+func generateName() string {
+	var raw [6]byte
+	_, _ = rand.Read(raw[:])
+	return "claude-agent-" + hex.EncodeToString(raw[:])
+}
+
+var (
+	_ types.Transport            = (*Transport)(nil)
+	_ types.ErrorTransport       = (*Transport)(nil)
+	_ types.ExitErrorTransport   = (*Transport)(nil)
+	_ types.WireMessageTransport = (*Transport)(nil)
+	_ subprocess.Launcher        = launcher{}
+)
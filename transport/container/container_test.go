@@ -0,0 +1,246 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package container
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// fakeRuntime logs its arguments and, for "run", executes the command after
+// the image in the working directory given by -w, like a container would.
+const fakeRuntime = `#!/bin/sh
+log="$FAKE_RUNTIME_LOG"
+printf '%s\n' "$*" >> "$log"
+if [ "$1" = "rm" ]; then
+  exit 0
+fi
+shift
+workdir=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -w) workdir="$2"; shift 2 ;;
+    -v|-e|--name) shift 2 ;;
+    -*) shift ;;
+    test-image) shift; break ;;
+    *) shift ;;
+  esac
+done
+[ -n "$workdir" ] && cd "$workdir"
+exec "$@"
+`
+
+// fakeCLI answers each user message with the forwarded variable and its
+// working directory, and exits with status 3 on "crash".
+const fakeCLI = `#!/bin/sh
+while IFS= read -r line; do
+  case "$line" in
+    *crash*)
+      echo "fatal: simulated crash" >&2
+      exit 3
+      ;;
+    *)
+      printf '{"type":"assistant","message":{"content":[{"type":"text","text":"%s %s"}]}}\n' "$SECRET_TOKEN" "$(pwd)"
+      ;;
+  esac
+done
+`
+
+func writeScript(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakeSetup struct {
+	dir     string
+	cwd     string
+	logPath string
+	opts    *types.Options
+	copts   []Option
+}
+
+func newFakeSetup(t *testing.T) *fakeSetup {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script runtime is not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	cwd := filepath.Join(dir, "work")
+	if err := os.Mkdir(cwd, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runtimePath := filepath.Join(dir, "fake-runtime")
+	cliPath := filepath.Join(dir, "claude")
+	writeScript(t, runtimePath, fakeRuntime)
+	writeScript(t, cliPath, fakeCLI)
+
+	logPath := filepath.Join(dir, "runtime.log")
+	t.Setenv("FAKE_RUNTIME_LOG", logPath)
+
+	opts := types.DefaultOptions()
+	opts.Cwd = cwd
+	opts.AddDirs = []string{dir}
+	opts.Env = map[string]string{"SECRET_TOKEN": "s3cret"}
+
+	return &fakeSetup{
+		dir:     dir,
+		cwd:     cwd,
+		logPath: logPath,
+		opts:    opts,
+		copts:   []Option{WithRuntime(runtimePath), WithCLIPath(cliPath), WithName("session-1")},
+	}
+}
+
+func (f *fakeSetup) logLines(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(f.logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func receive(t *testing.T, transport *Transport) map[string]any {
+	t.Helper()
+	select {
+	case msg, ok := <-transport.Messages():
+		if !ok {
+			t.Fatal("messages channel closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return nil
+}
+
+func TestTransport_RunsCLIThroughRuntime(t *testing.T) {
+	setup := newFakeSetup(t)
+	transport := NewTransport("test-image", setup.opts, setup.copts...)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	msg := receive(t, transport)
+	content := msg["message"].(map[string]any)["content"].([]any)
+	text := content[0].(map[string]any)["text"]
+	if text != "s3cret "+setup.cwd {
+		t.Fatalf("expected forwarded env and working directory, got %q", text)
+	}
+
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	lines := setup.logLines(t)
+	run := lines[0]
+	for _, want := range []string{
+		"run -i --rm --name session-1",
+		"-v " + setup.cwd + ":" + setup.cwd,
+		"-v " + setup.dir + ":" + setup.dir,
+		"-w " + setup.cwd,
+		"-e SECRET_TOKEN",
+		"-e CLAUDE_CODE_ENTRYPOINT",
+		"test-image",
+		"--input-format stream-json",
+	} {
+		if !strings.Contains(run, want) {
+			t.Errorf("run command missing %q: %s", want, run)
+		}
+	}
+	if strings.Contains(run, "s3cret") {
+		t.Errorf("secret value leaked onto the command line: %s", run)
+	}
+	if last := lines[len(lines)-1]; last != "rm -f session-1" {
+		t.Errorf("expected container removal on close, got %q", last)
+	}
+}
+
+func TestTransport_ReportsContainerExitAsProcessError(t *testing.T) {
+	setup := newFakeSetup(t)
+	transport := NewTransport("test-image", setup.opts, setup.copts...)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	if err := transport.Write(`{"type":"user","crash":true}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for range transport.Messages() {
+	}
+
+	var procErr *types.ProcessError
+	if !errors.As(transport.ExitError(), &procErr) {
+		t.Fatalf("expected *types.ProcessError, got %v", transport.ExitError())
+	}
+	if procErr.ExitCode != 3 || !strings.Contains(procErr.Stderr, "simulated crash") {
+		t.Fatalf("unexpected process error: %+v", procErr)
+	}
+}
+
+func TestLauncher_Command(t *testing.T) {
+	opts := types.DefaultOptions()
+	opts.Cwd = "/src/repo"
+	opts.AddDirs = []string{"/src/shared", "/src/repo"}
+
+	transport := NewTransport("img:1", opts,
+		WithName("n"),
+		WithRunArgs("--network", "none"),
+		WithForwardEnv("ANTHROPIC_API_KEY"),
+	)
+	args, hostEnv, err := launcher{transport}.Command([]string{"claude", "--verbose"}, []string{"FOO=bar", "FOO=baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"docker", "run", "-i", "--rm", "--name", "n",
+		"-v", "/src/repo:/src/repo", "-v", "/src/shared:/src/shared", "-w", "/src/repo",
+		"-e", "FOO", "-e", "ANTHROPIC_API_KEY",
+		"--network", "none",
+		"img:1", "claude", "--verbose",
+	}
+	if !slices.Equal(args, want) {
+		t.Fatalf("unexpected command:\n got %q\nwant %q", args, want)
+	}
+	// The later value wins, as with a local CLI
+	if i := slices.Index(hostEnv, "FOO=baz"); i < 0 || slices.Index(hostEnv, "FOO=bar") > i {
+		t.Fatalf("expected FOO=baz last in host environment")
+	}
+}
+
+func TestLauncher_RejectsUnmountablePath(t *testing.T) {
+	opts := types.DefaultOptions()
+	opts.AddDirs = []string{"/data/a:b"}
+
+	_, _, err := launcher{NewTransport("img", opts)}.Command([]string{"claude"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "a:b") {
+		t.Fatalf("expected error naming the path, got %v", err)
+	}
+}
+
+func TestNewTransport_GeneratesUniqueNames(t *testing.T) {
+	a, b := NewTransport("img", nil), NewTransport("img", nil)
+	if a.Name() == b.Name() || !strings.HasPrefix(a.Name(), "claude-agent-") {
+		t.Fatalf("unexpected generated names %q and %q", a.Name(), b.Name())
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package ssh provides a Transport that runs the Claude CLI on a remote host
+// through the system ssh client.
+//
+// The CLI command line is built exactly as for a local CLI and executed in a
+// non-interactive SSH session whose stdin, stdout and stderr carry the
+// stream-json protocol:
+//
+//	opts := types.DefaultOptions()
+//	types.ApplyOptions(opts, types.WithCwd("/srv/build/repo"))
+//	transport := ssh.NewTransport("ci@buildhost", opts,
+//		ssh.WithSSHArgs("-i", "/etc/agent/id_ed25519", "-p", "2222"),
+//	)
+//	client := sdk.NewClient(types.WithTransport(transport))
+//
+// Options.Cwd and other paths in Options refer to the remote host. Options.Env
+// and the SDK's own variables are set with env(1) in the remote command, so
+// their values are visible in the process list on both hosts; use
+// WithSendEnv for secrets when the server's AcceptEnv allows them.
+//
+// The session uses BatchMode, so authentication must not require a prompt.
+// If ssh itself fails (e.g. the host is unreachable) the transport reports a
+// *types.ProcessError with exit code 255 and ssh's error message.
+package ssh
+
+import (
+	"context"
+	"strings"
+
+	"github.com/victorarias/claude-agent-sdk-go/internal/subprocess"
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Defaults for Transport.
+const (
+	DefaultSSHPath = "ssh"
+	DefaultCLIPath = "claude"
+)
+
+// Transport runs the CLI over SSH and otherwise behaves exactly like the
+// subprocess transport: same message parsing, error reporting and shutdown.
+type Transport struct {
+	proc *subprocess.SubprocessTransport
+
+	host    string
+	sshPath string
+	cliPath string
+	sshArgs []string
+	sendEnv []string
+	options *types.Options
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithSSHPath sets the ssh client executable (default: "ssh").
+func WithSSHPath(path string) Option {
+	return func(t *Transport) {
+		t.sshPath = path
+	}
+}
+
+// WithCLIPath sets the CLI path on the remote host (default: "claude").
+func WithCLIPath(path string) Option {
+	return func(t *Transport) {
+		t.cliPath = path
+	}
+}
+
+// WithSSHArgs adds ssh client flags, placed before the host, e.g. "-i key",
+// "-p port" or "-o ControlPath=...".
+func WithSSHArgs(args ...string) Option {
+	return func(t *Transport) {
+		t.sshArgs = append(t.sshArgs, args...)
+	}
+}
+
+// WithSendEnv forwards host environment variables by name with ssh's SendEnv,
+// keeping their values out of the command line. The server must accept them
+// (AcceptEnv in sshd_config); others are silently dropped by sshd.
+func WithSendEnv(names ...string) Option {
+	return func(t *Transport) {
+		t.sendEnv = append(t.sendEnv, names...)
+	}
+}
+
+// NewTransport creates a streaming transport running the CLI on host, given as
+// "host" or "user@host" (or any destination ssh accepts, such as a config alias).
+func NewTransport(host string, opts *types.Options, sopts ...Option) *Transport {
+	if opts == nil {
+		opts = types.DefaultOptions()
+	}
+
+	t := &Transport{
+		proc:    subprocess.NewStreamingTransport(opts),
+		host:    host,
+		sshPath: DefaultSSHPath,
+		cliPath: DefaultCLIPath,
+		options: opts,
+	}
+	for _, opt := range sopts {
+		opt(t)
+	}
+	t.proc.SetLauncher(launcher{t})
+	return t
+}
+
+// Connect starts the SSH session and the remote CLI.
+func (t *Transport) Connect(ctx context.Context) error {
+	return t.proc.Connect(ctx)
+}
+
+// Close ends the remote CLI's input, waits for it to exit and closes the session.
+func (t *Transport) Close() error {
+	return t.proc.Close()
+}
+
+// Kill forcefully terminates the ssh client.
+func (t *Transport) Kill() error {
+	return t.proc.Kill()
+}
+
+// Write sends data to the CLI.
+func (t *Transport) Write(data string) error {
+	return t.proc.Write(data)
+}
+
+// EndInput closes the CLI's stdin.
+func (t *Transport) EndInput() error {
+	return t.proc.EndInput()
+}
+
+// Messages returns the channel of messages from the CLI.
+func (t *Transport) Messages() <-chan map[string]any {
+	return t.proc.Messages()
+}
+
+// WireMessages returns the channel of messages from the CLI with the JSON
+// they were decoded from. Use either it or Messages, not both.
+func (t *Transport) WireMessages() <-chan types.WireMessage {
+	return t.proc.WireMessages()
+}
+
+// Errors returns the channel of errors from the CLI.
+func (t *Transport) Errors() <-chan error {
+	return t.proc.Errors()
+}
+
+// ExitError returns the exit error if the session has ended.
+func (t *Transport) ExitError() error {
+	return t.proc.ExitError()
+}
+
+// IsReady returns true if the transport is connected and not closed.
+func (t *Transport) IsReady() bool {
+	return t.proc.IsReady()
+}
+
+// SetStderrCallback sets a callback for stderr output from ssh and the CLI.
+func (t *Transport) SetStderrCallback(callback func(string)) {
+	t.proc.SetStderrCallback(callback)
+}
+
+// launcher runs the CLI through "ssh -- <host> <command>". The "--" keeps a
+// host starting with "-" from being read as an ssh option.
+type launcher struct {
+	t *Transport
+}
+
+func (l launcher) CLIPath() string {
+	return l.t.cliPath
+}
+
+func (l launcher) Command(cli []string, env []string) ([]string, []string, error) {
+	t := l.t
+	args := []string{t.sshPath, "-T", "-o", "BatchMode=yes"}
+	for _, name := range t.sendEnv {
+		args = append(args, "-o", "SendEnv="+name)
+	}
+	args = append(args, t.sshArgs...)
+	args = append(args, "--", t.host, remoteCommand(t.options.Cwd, env, cli))
+
+	// The ssh client itself runs with the caller's environment
+	return args, nil, nil
+}
+
+func (l launcher) Cleanup() error {
+	// The remote CLI exits when its input closes; nothing outlives the session
+	return nil
+}
+
+// remoteCommand builds the shell command run by the remote login shell.
+func remoteCommand(cwd string, env []string, cli []string) string {
+	var b strings.Builder
+	if cwd != "" {
+		b.WriteString("cd ")
+		b.WriteString(shellQuote(cwd))
+		b.WriteString(" && ")
+	}
+	b.WriteString("exec env")
+	for _, kv := range env {
+		b.WriteByte(' ')
+		b.WriteString(shellQuote(kv))
+	}
+	for _, arg := range cli {
+		b.WriteByte(' ')
+		b.WriteString(shellQuote(arg))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, needsQuoting) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func needsQuoting(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./=:,@%+", r)
+}
+
+var (
+	_ types.Transport            = (*Transport)(nil)
+	_ types.ErrorTransport       = (*Transport)(nil)
+	_ types.ExitErrorTransport   = (*Transport)(nil)
+	_ types.WireMessageTransport = (*Transport)(nil)
+	_ subprocess.Launcher        = launcher{}
+)
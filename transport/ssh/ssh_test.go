@@ -0,0 +1,217 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// fakeSSH logs its arguments and runs the remote command with a local shell,
+// the way sshd hands it to the user's login shell.
+const fakeSSH = `#!/bin/sh
+printf '%s\n' "$@" > "$FAKE_SSH_LOG"
+while [ $# -gt 0 ] && [ "$1" != "buildhost" ]; do
+  shift
+done
+shift
+exec sh -c "$1"
+`
+
+// fakeCLI echoes the forwarded variable and working directory for each user
+// message, and exits with status 2 on "crash".
+const fakeCLI = `#!/bin/sh
+while IFS= read -r line; do
+  case "$line" in
+    *crash*)
+      echo "remote: simulated crash" >&2
+      exit 2
+      ;;
+    *)
+      printf '{"type":"assistant","greeting":"%s","cwd":"%s","entrypoint":"%s"}\n' "$GREETING" "$(pwd)" "$CLAUDE_CODE_ENTRYPOINT"
+      ;;
+  esac
+done
+`
+
+func setupFakeSSH(t *testing.T) (*types.Options, []Option, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script ssh is not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	// A directory name that needs quoting on the remote command line
+	cwd := filepath.Join(dir, "it's a repo")
+	if err := os.Mkdir(cwd, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sshPath := filepath.Join(dir, "ssh")
+	cliPath := filepath.Join(dir, "claude")
+	for path, content := range map[string]string{sshPath: fakeSSH, cliPath: fakeCLI} {
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logPath := filepath.Join(dir, "ssh.log")
+	t.Setenv("FAKE_SSH_LOG", logPath)
+
+	opts := types.DefaultOptions()
+	opts.Cwd = cwd
+	opts.Env = map[string]string{"GREETING": `hello 'world' $HOME; true`}
+
+	return opts, []Option{WithSSHPath(sshPath), WithCLIPath(cliPath)}, logPath
+}
+
+func receive(t *testing.T, transport *Transport) map[string]any {
+	t.Helper()
+	select {
+	case msg, ok := <-transport.Messages():
+		if !ok {
+			t.Fatal("messages channel closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return nil
+}
+
+func TestTransport_RunsCLIOverSSH(t *testing.T) {
+	opts, sopts, logPath := setupFakeSSH(t)
+	sopts = append(sopts, WithSSHArgs("-p", "2222"), WithSendEnv("ANTHROPIC_API_KEY"))
+	transport := NewTransport("buildhost", opts, sopts...)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	if err := transport.Write(`{"type":"user"}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	msg := receive(t, transport)
+	if msg["greeting"] != `hello 'world' $HOME; true` {
+		t.Errorf("env value not forwarded verbatim: %q", msg["greeting"])
+	}
+	if msg["cwd"] != opts.Cwd {
+		t.Errorf("expected remote cwd %q, got %q", opts.Cwd, msg["cwd"])
+	}
+	if msg["entrypoint"] != "sdk-go" {
+		t.Errorf("SDK environment not forwarded, entrypoint %q", msg["entrypoint"])
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	prefix := []string{"-T", "-o", "BatchMode=yes", "-o", "SendEnv=ANTHROPIC_API_KEY", "-p", "2222", "--", "buildhost"}
+	if len(args) < len(prefix) || strings.Join(args[:len(prefix)], " ") != strings.Join(prefix, " ") {
+		t.Fatalf("unexpected ssh arguments: %q", args)
+	}
+}
+
+func TestTransport_GracefulCloseEndsRemoteCLI(t *testing.T) {
+	opts, sopts, _ := setupFakeSSH(t)
+	transport := NewTransport("buildhost", opts, sopts...)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- transport.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	if transport.IsReady() {
+		t.Fatal("transport still ready after Close")
+	}
+}
+
+func TestTransport_ReportsRemoteExitAsProcessError(t *testing.T) {
+	opts, sopts, _ := setupFakeSSH(t)
+	transport := NewTransport("buildhost", opts, sopts...)
+
+	if err := transport.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	if err := transport.Write(`{"type":"user","crash":true}`); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for range transport.Messages() {
+	}
+
+	var procErr *types.ProcessError
+	if !errors.As(transport.ExitError(), &procErr) {
+		t.Fatalf("expected *types.ProcessError, got %v", transport.ExitError())
+	}
+	if procErr.ExitCode != 2 || !strings.Contains(procErr.Stderr, "simulated crash") {
+		t.Fatalf("unexpected process error: %+v", procErr)
+	}
+}
+
+func TestRemoteCommand_QuotesForShell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	cli := []string{"printf", "%s|", "two words", "it's", "$(whoami)", "", "--flag=a;b"}
+	cmd := remoteCommand("/", []string{"A=1"}, cli)
+
+	out, err := exec.Command("sh", "-c", cmd).Output()
+	if err != nil {
+		t.Fatalf("command %q failed: %v", cmd, err)
+	}
+	if want := "two words|it's|$(whoami)||--flag=a;b|"; string(out) != want {
+		t.Fatalf("arguments not preserved through the shell:\n got %q\nwant %q\ncommand %s", out, want, cmd)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"claude":          "claude",
+		"--model=opus":    "--model=opus",
+		"/usr/local/bin":  "/usr/local/bin",
+		"":                "''",
+		"two words":       "'two words'",
+		"it's":            `'it'\''s'`,
+		`{"type":"user"}`: `'{"type":"user"}'`,
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestLauncher_HostIsNotAnOption(t *testing.T) {
+	transport := NewTransport("-oProxyCommand=touch /tmp/pwned", types.DefaultOptions())
+	args, _, err := launcher{transport}.Command([]string{"claude"}, nil)
+	if err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	i := slices.Index(args, "-oProxyCommand=touch /tmp/pwned")
+	if i < 1 || args[i-1] != "--" {
+		t.Fatalf("expected the host after \"--\", got %q", args)
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Frame opcodes (RFC 6455 section 5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes (RFC 6455 section 7.4.1).
+const (
+	closeNormal        = 1000
+	closeGoingAway     = 1001
+	closeProtocolError = 1002
+	closeNoStatus      = 1005
+)
+
+// maxControlPayload is the largest payload allowed in a control frame.
+const maxControlPayload = 125
+
+// closeError is returned by readMessage once the peer has sent a close frame.
+type closeError struct {
+	Code   int
+	Reason string
+}
+
+func (e *closeError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("websocket closed with status %d: %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("websocket closed with status %d", e.Code)
+}
+
+// messageTooLargeError is returned by readMessage when a message exceeds the
+// read limit. The message has been skipped and the connection remains usable.
+type messageTooLargeError struct {
+	Limit int
+	Size  int
+}
+
+func (e *messageTooLargeError) Error() string {
+	return fmt.Sprintf("websocket message of %d bytes exceeds limit of %d bytes", e.Size, e.Limit)
+}
+
+var errProtocol = errors.New("websocket protocol error")
+
+// conn is a minimal RFC 6455 connection carrying text messages.
+//
+// Reads must come from a single goroutine. Writes are serialized internally,
+// so control replies from the reader can interleave safely with data writes
+// from other goroutines.
+type conn struct {
+	netConn net.Conn
+	br      *bufio.Reader
+	client  bool // Clients mask outgoing frames and expect unmasked frames
+
+	readLimit    int
+	readTimeout  time.Duration // Maximum wait for each frame; zero waits indefinitely
+	writeTimeout time.Duration
+
+	writeMu sync.Mutex
+	closeMu sync.Mutex
+	sentCls bool
+}
+
+// newConn wraps netConn. A readLimit of zero or less means
+// defaultMaxMessageSize, since a frame's length is chosen by the peer.
+func newConn(netConn net.Conn, br *bufio.Reader, client bool, readLimit int) *conn {
+	if br == nil {
+		br = bufio.NewReader(netConn)
+	}
+	if readLimit <= 0 {
+		readLimit = defaultMaxMessageSize
+	}
+	return &conn{
+		netConn:   netConn,
+		br:        br,
+		client:    client,
+		readLimit: readLimit,
+	}
+}
+
+// readMessage returns the next complete text or binary message. Ping frames are
+// answered and pong frames ignored while waiting.
+func (c *conn) readMessage() (int, []byte, error) {
+	var (
+		opcode  int
+		payload []byte
+		size    int
+		skip    bool
+	)
+
+	for {
+		fin, op, length, mask, err := c.readHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if op >= opClose {
+			if !fin || length > maxControlPayload {
+				return 0, nil, c.fail(closeProtocolError, "invalid control frame")
+			}
+			data, err := c.readPayload(length, mask)
+			if err != nil {
+				return 0, nil, err
+			}
+			if err := c.handleControl(op, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch {
+		case op == opContinuation && opcode == 0:
+			return 0, nil, c.fail(closeProtocolError, "unexpected continuation frame")
+		case op != opContinuation && opcode != 0:
+			return 0, nil, c.fail(closeProtocolError, "expected continuation frame")
+		case op != opContinuation && op != opText && op != opBinary:
+			return 0, nil, c.fail(closeProtocolError, "unknown opcode")
+		case op != opContinuation:
+			opcode = op
+		}
+
+		// Checked before allocating; the length comes from the peer
+		size += int(length)
+		if size > c.readLimit || size < 0 {
+			skip = true
+			payload = nil
+		}
+		if skip {
+			if _, err := io.CopyN(io.Discard, c.br, int64(length)); err != nil {
+				return 0, nil, err
+			}
+		} else {
+			data, err := c.readPayload(length, mask)
+			if err != nil {
+				return 0, nil, err
+			}
+			payload = append(payload, data...)
+		}
+
+		if fin {
+			if skip {
+				return opcode, nil, &messageTooLargeError{Limit: c.readLimit, Size: size}
+			}
+			return opcode, payload, nil
+		}
+	}
+}
+
+func (c *conn) readHeader() (fin bool, op int, length uint64, mask []byte, err error) {
+	if c.readTimeout > 0 {
+		_ = c.netConn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	}
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		err = c.fail(closeProtocolError, "reserved bits set")
+		return
+	}
+	op = int(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+	length = uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+		if length>>63 != 0 {
+			err = c.fail(closeProtocolError, "invalid payload length")
+			return
+		}
+	}
+
+	// Servers only accept masked frames and clients only unmasked ones
+	if masked == c.client {
+		err = c.fail(closeProtocolError, "invalid frame masking")
+		return
+	}
+	if masked {
+		mask = make([]byte, 4)
+		if _, err = io.ReadFull(c.br, mask); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (c *conn) readPayload(length uint64, mask []byte) ([]byte, error) {
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.br, data); err != nil {
+		return nil, err
+	}
+	if mask != nil {
+		maskBytes(mask, data)
+	}
+	return data, nil
+}
+
+func (c *conn) handleControl(op int, data []byte) error {
+	switch op {
+	case opPing:
+		return c.writeFrame(opPong, data)
+	case opPong:
+		return nil
+	case opClose:
+		code, reason := closeNoStatus, ""
+		if len(data) >= 2 {
+			code = int(binary.BigEndian.Uint16(data))
+			reason = string(data[2:])
+		}
+		_ = c.writeClose(code, "")
+		return &closeError{Code: code, Reason: reason}
+	default:
+		return c.fail(closeProtocolError, "unknown control opcode")
+	}
+}
+
+// fail sends a close frame with the given status and returns errProtocol.
+func (c *conn) fail(code int, reason string) error {
+	_ = c.writeClose(code, reason)
+	return fmt.Errorf("%w: %s", errProtocol, reason)
+}
+
+// writeText sends data as a single text frame.
+func (c *conn) writeText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// ping sends a ping frame.
+func (c *conn) ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// writeClose sends a close frame once; later calls are no-ops.
+func (c *conn) writeClose(code int, reason string) error {
+	c.closeMu.Lock()
+	if c.sentCls {
+		c.closeMu.Unlock()
+		return nil
+	}
+	c.sentCls = true
+	c.closeMu.Unlock()
+
+	if code == closeNoStatus {
+		// 1005 must not appear on the wire; reply with an empty close frame
+		return c.writeFrame(opClose, nil)
+	}
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	data := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(data, uint16(code))
+	copy(data[2:], reason)
+	return c.writeFrame(opClose, data)
+}
+
+func (c *conn) writeFrame(op int, data []byte) error {
+	header := make([]byte, 0, 14)
+	header = append(header, 0x80|byte(op))
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(data); {
+	case n <= 125:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	payload := data
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		payload = make([]byte, len(data))
+		copy(payload, data)
+		maskBytes(key[:], payload)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeTimeout > 0 {
+		_ = c.netConn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	// net.Buffers uses writev where available, avoiding a copy of large payloads
+	bufs := net.Buffers{header, payload}
+	_, err := bufs.WriteTo(c.netConn)
+	return err
+}
+
+// close sends a close frame (if none was sent yet) and closes the connection.
+func (c *conn) close(code int, reason string) error {
+	_ = c.writeClose(code, reason)
+	return c.netConn.Close()
+}
+
+func maskBytes(key []byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
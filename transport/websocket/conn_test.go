@@ -0,0 +1,169 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func connPair(t *testing.T, readLimit int) (client, server *conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return newConn(a, nil, true, readLimit), newConn(b, nil, false, readLimit)
+}
+
+func TestConn_RoundTripPayloadLengths(t *testing.T) {
+	// Cover the 7-bit, 16-bit and 64-bit length encodings
+	for _, size := range []int{0, 1, 125, 126, 0xFFFF, 0x10000, 200000} {
+		client, server := connPair(t, 0)
+		payload := bytes.Repeat([]byte("a"), size)
+
+		go func() { _ = client.writeText(payload) }()
+		op, got, err := server.readMessage()
+		if err != nil {
+			t.Fatalf("size %d: read failed: %v", size, err)
+		}
+		if op != opText || !bytes.Equal(got, payload) {
+			t.Fatalf("size %d: got opcode %d and %d bytes", size, op, len(got))
+		}
+
+		go func() { _ = server.writeText(payload) }()
+		if _, got, err = client.readMessage(); err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("size %d: server to client failed: %v", size, err)
+		}
+	}
+}
+
+func TestConn_AssemblesFragmentsAndAnswersPing(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	server := newConn(b, nil, false, 0)
+
+	// Unmasked server-side frames written by hand, read by a client conn
+	client := newConn(a, nil, true, 0)
+	go func() {
+		raw := newConn(b, nil, false, 0)
+		_ = writeRawFrame(raw, false, opText, []byte(`{"type":`))
+		_ = writeRawFrame(raw, true, opPing, []byte("hi"))
+		_ = writeRawFrame(raw, true, opContinuation, []byte(`"result"}`))
+	}()
+
+	pong := make(chan []byte, 1)
+	go func() {
+		// The client answers the ping with a masked pong before the message completes
+		_, data, _ := server.readHeaderAndPayload()
+		pong <- data
+	}()
+
+	_, got, err := client.readMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(got) != `{"type":"result"}` {
+		t.Fatalf("unexpected message %q", got)
+	}
+	if data := <-pong; string(data) != "hi" {
+		t.Fatalf("expected pong echoing ping payload, got %q", data)
+	}
+}
+
+func TestConn_SkipsMessageOverLimit(t *testing.T) {
+	client, server := connPair(t, 10)
+
+	go func() {
+		_ = client.writeText([]byte(strings.Repeat("x", 11)))
+		_ = client.writeText([]byte("ok"))
+	}()
+
+	_, _, err := server.readMessage()
+	var tooLarge *messageTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 11 || tooLarge.Limit != 10 {
+		t.Fatalf("expected messageTooLargeError, got %v", err)
+	}
+
+	_, got, err := server.readMessage()
+	if err != nil || string(got) != "ok" {
+		t.Fatalf("expected next message after skip, got %q, %v", got, err)
+	}
+}
+
+func TestConn_HugeFrameLengthWithoutLimit(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	client := newConn(b, nil, true, 0)
+
+	go func() {
+		// A 64-bit length far beyond any allocation, then nothing
+		_, _ = a.Write([]byte{0x80 | opText, 127, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+		a.Close()
+	}()
+
+	_, got, err := client.readMessage()
+	if err == nil || got != nil {
+		t.Fatalf("expected the oversized frame to be skipped, got %d bytes, %v", len(got), err)
+	}
+}
+
+func TestConn_RejectsUnmaskedClientFrame(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	server := newConn(b, nil, false, 0)
+
+	go func() {
+		// Servers must reject a text frame without the mask bit
+		_, _ = a.Write([]byte{0x80 | opText, 1, 'x'})
+		_, _ = io.Copy(io.Discard, a) // Absorb the close frame
+	}()
+
+	if _, _, err := server.readMessage(); !errors.Is(err, errProtocol) {
+		t.Fatalf("expected protocol error, got %v", err)
+	}
+}
+
+func TestConn_CloseFrame(t *testing.T) {
+	client, server := connPair(t, 0)
+
+	go func() {
+		_ = client.writeClose(closeNormal, "bye")
+		_, _, _ = client.readMessage() // Absorb the echoed close
+	}()
+
+	_, _, err := server.readMessage()
+	var closeErr *closeError
+	if !errors.As(err, &closeErr) || closeErr.Code != closeNormal || closeErr.Reason != "bye" {
+		t.Fatalf("expected close 1000 bye, got %v", err)
+	}
+}
+
+// writeRawFrame writes a frame with an explicit FIN bit.
+func writeRawFrame(c *conn, fin bool, op int, data []byte) error {
+	head := []byte{byte(op), byte(len(data))}
+	if fin {
+		head[0] |= 0x80
+	}
+	_, err := c.netConn.Write(append(head, data...))
+	return err
+}
+
+// readHeaderAndPayload reads one frame of any type.
+func (c *conn) readHeaderAndPayload() (int, []byte, error) {
+	_, op, length, mask, err := c.readHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := c.readPayload(length, mask)
+	return op, data, err
+}
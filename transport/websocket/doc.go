@@ -0,0 +1,44 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package websocket provides a Transport that reaches a Claude CLI running on
+// another host, and the Relay that serves it there.
+//
+// The relay runs next to the CLI (for example in a sandboxed worker pod) and
+// wraps a SubprocessTransport per session:
+//
+//	relay, err := websocket.NewRelay(
+//		websocket.WithAuthorizer(checkBearerToken),
+//		websocket.WithSessionOptions(func(r *http.Request) (*types.Options, error) {
+//			opts := types.DefaultOptions()
+//			opts.Cwd = "/workspace"
+//			return opts, nil
+//		}),
+//	)
+//	http.Handle("/claude", relay)
+//
+// The service holding the Client dials it:
+//
+//	transport := websocket.NewTransport("wss://worker:8443/claude",
+//		websocket.WithHeader(http.Header{"Authorization": {"Bearer " + token}}),
+//	)
+//	client := sdk.NewClient(types.WithTransport(transport))
+//
+// # Protocol
+//
+// Every WebSocket text message holds one stream-json message exactly as the
+// CLI reads or writes it, so control requests, hooks and SDK MCP servers work
+// as with a local process. Messages whose "type" is "relay" are relay events:
+// acknowledgements of client messages, asynchronous errors, end of input and
+// the CLI's exit status, which is always the last message of a session.
+//
+// Both sides count the stream-json messages they receive. When the connection
+// drops, the client redials with the session ID and its count in the
+// X-Claude-Relay-Session and X-Claude-Relay-Received headers; the relay
+// answers with its own count and each side replays what the other missed.
+// While no client is attached the relay stops reading CLI output, so a slow
+// or absent consumer applies backpressure to the CLI rather than growing
+// buffers.
+//
+// The package uses only the standard library.
+package websocket
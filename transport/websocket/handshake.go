@@ -0,0 +1,233 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// acceptGUID is the fixed suffix used to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError is returned when the relay rejects the WebSocket upgrade.
+type HandshakeError struct {
+	StatusCode int    // HTTP status returned by the relay
+	Body       string // Leading portion of the response body
+}
+
+func (e *HandshakeError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("websocket handshake failed with status %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("websocket handshake failed with status %d", e.StatusCode)
+}
+
+// retryable reports whether reconnecting may succeed. Authentication failures
+// and unknown or expired sessions are permanent.
+func (e *HandshakeError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden,
+		http.StatusNotFound, http.StatusGone:
+		return false
+	}
+	return true
+}
+
+func computeAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// dial opens a client connection to rawURL, sending header with the upgrade
+// request. It returns the connection and the relay's response headers.
+func dial(ctx context.Context, rawURL string, header http.Header, tlsConfig *tls.Config, readLimit int) (*conn, http.Header, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secure := false
+	switch u.Scheme {
+	case "ws", "http":
+	case "wss", "https":
+		secure = true
+	default:
+		return nil, nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Abort the handshake if ctx is cancelled while it is in progress
+	stop := context.AfterFunc(ctx, func() {
+		_ = netConn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if secure {
+		cfg := tlsConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(netConn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		netConn.Close()
+		return nil, nil, &HandshakeError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	if !headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != computeAccept(key) {
+		netConn.Close()
+		return nil, nil, fmt.Errorf("%w: invalid handshake response", errProtocol)
+	}
+
+	if !stop() {
+		// ctx fired while the handshake completed; its deadline is already set
+		netConn.Close()
+		return nil, nil, ctx.Err()
+	}
+	_ = netConn.SetDeadline(time.Time{})
+
+	return newConn(netConn, br, true, readLimit), resp.Header, nil
+}
+
+// upgrade completes the server side of the handshake on an HTTP request,
+// adding header to the 101 response.
+func upgrade(w http.ResponseWriter, r *http.Request, header http.Header, readLimit int) (*conn, error) {
+	if err := checkUpgrade(w, r); err != nil {
+		return nil, err
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.WriteString("Upgrade: websocket\r\n")
+	resp.WriteString("Connection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + computeAccept(key) + "\r\n")
+	for k, values := range header {
+		for _, v := range values {
+			resp.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	resp.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(resp.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, rw.Reader, false, readLimit), nil
+}
+
+// checkUpgrade reports whether r is a valid WebSocket handshake, answering
+// it with an HTTP error if not.
+func checkUpgrade(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return fmt.Errorf("%w: method %s", errProtocol, r.Method)
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || r.Header.Get("Sec-WebSocket-Key") == "" {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return fmt.Errorf("%w: not a websocket upgrade", errProtocol)
+	}
+	return nil
+}
+
+// headerContainsToken reports whether the comma-separated header name contains
+// token, ignoring case.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
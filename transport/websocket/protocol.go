@@ -0,0 +1,164 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Handshake headers used to establish and resume relay sessions.
+const (
+	// HeaderSession carries the relay session ID. The relay sets it on every
+	// 101 response; the client sends it back to resume after a disconnect.
+	HeaderSession = "X-Claude-Relay-Session"
+
+	// HeaderReceived carries the number of data messages the sender has
+	// received from its peer in this session. Each side replays what the other
+	// has not seen yet.
+	HeaderReceived = "X-Claude-Relay-Received"
+)
+
+// relayMessageType is the "type" of relay events. Every other message on the
+// socket is a stream-json message passed through unchanged.
+const relayMessageType = "relay"
+
+// Relay event names.
+const (
+	eventAck      = "ack"       // relay → client: client messages received so far
+	eventEndInput = "end_input" // client → relay: close the CLI's stdin
+	eventError    = "error"     // relay → client: asynchronous transport error
+	eventExit     = "exit"      // relay → client: the CLI exited; sent last
+)
+
+// Remote error kinds carried by error events.
+const (
+	KindParse          = "parse"
+	KindBufferOverflow = "buffer_overflow"
+	KindTransport      = "transport"
+)
+
+// relayEvent is the envelope for relay-level signalling. It is distinguished
+// from stream-json traffic by its "type" field.
+type relayEvent struct {
+	Type     string `json:"type"`
+	Event    string `json:"event"`
+	Received int64  `json:"received,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Message  string `json:"message,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+func (e *relayEvent) encode() []byte {
+	e.Type = relayMessageType
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// decodeRelayEvent returns the event carried by data, or nil if data is an
+// ordinary stream-json message.
+func decodeRelayEvent(data []byte) *relayEvent {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil || probe.Type != relayMessageType {
+		return nil
+	}
+	var event relayEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil
+	}
+	return &event
+}
+
+// errorEvent describes err for delivery to the client.
+func errorEvent(err error) *relayEvent {
+	kind := KindTransport
+	switch {
+	case errors.Is(err, types.ErrBufferOverflow):
+		kind = KindBufferOverflow
+	case errors.Is(err, types.ErrParse):
+		kind = KindParse
+	}
+	return &relayEvent{Event: eventError, Kind: kind, Message: err.Error()}
+}
+
+// exitEvent describes how the relayed transport ended. A nil exitErr reports
+// a clean exit.
+func exitEvent(exitErr error) *relayEvent {
+	event := &relayEvent{Event: eventExit}
+	code := 0
+
+	var procErr *types.ProcessError
+	switch {
+	case exitErr == nil:
+	case errors.As(exitErr, &procErr):
+		code = procErr.ExitCode
+		if procErr.Signal != nil {
+			event.Signal = procErr.Signal.String()
+		}
+		event.Stderr = procErr.Stderr
+	default:
+		code = -1
+		event.Kind = KindTransport
+		event.Message = exitErr.Error()
+	}
+	event.ExitCode = &code
+	return event
+}
+
+// exitError converts an exit event back into the error reported by ExitError.
+func (e *relayEvent) exitError() error {
+	code := 0
+	if e.ExitCode != nil {
+		code = *e.ExitCode
+	}
+	if e.Message != "" {
+		return &RemoteError{Kind: e.Kind, Message: e.Message}
+	}
+	if code == 0 && e.Signal == "" {
+		return nil
+	}
+	procErr := &types.ProcessError{ExitCode: code, Stderr: e.Stderr}
+	if e.Signal != "" {
+		procErr.Signal = remoteSignal(e.Signal)
+	}
+	return procErr
+}
+
+// RemoteError is an error reported by the relay for its side of the session.
+// It matches the SDK sentinel for its kind, so errors.Is(err, types.ErrParse)
+// works the same as with a local transport.
+type RemoteError struct {
+	Kind    string // KindParse, KindBufferOverflow or KindTransport
+	Message string // Error text from the relay
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("relay: %s", e.Message)
+}
+
+func (e *RemoteError) Is(target error) bool {
+	switch e.Kind {
+	case KindParse:
+		return target == types.ErrParse
+	case KindBufferOverflow:
+		return target == types.ErrBufferOverflow
+	}
+	return target == types.ErrConnection
+}
+
+// remoteSignal is a signal that terminated the CLI on the relay host.
+type remoteSignal string
+
+func (s remoteSignal) String() string { return string(s) }
+func (s remoteSignal) Signal()        {}
+
+var _ os.Signal = remoteSignal("")
@@ -0,0 +1,576 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/internal/subprocess"
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Defaults for Relay.
+const (
+	defaultResumeTimeout = 30 * time.Second
+	defaultReplayBuffer  = 1024
+	defaultIdleTimeout   = 2 * defaultPingInterval // Two missed client pings
+)
+
+// Relay is an http.Handler that runs one CLI session per WebSocket client.
+//
+// A new connection starts a streaming SubprocessTransport built from the
+// relay's session options; the CLI is stopped when the client closes normally
+// or fails to resume within the resume timeout. The CLI's command line is
+// decided here, not by the client: set Options.PermissionPromptToolName to
+// "stdio" if clients answer permission prompts with a CanUseTool callback.
+//
+// Every request must pass the relay's authorizer, and be a valid WebSocket
+// handshake, before a CLI process is started for it.
+type Relay struct {
+	options       func(r *http.Request) (*types.Options, error)
+	authorize     func(r *http.Request) error
+	newTransport  func(opts *types.Options) types.Transport
+	resumeTimeout time.Duration
+	replayBuffer  int
+	idleTimeout   time.Duration
+	writeTimeout  time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*relaySession
+	closed   bool
+}
+
+// RelayOption configures a Relay.
+type RelayOption func(*Relay)
+
+// WithSessionOptions sets the function that builds CLI options for a new
+// session from its handshake request. Returning an error rejects the request.
+func WithSessionOptions(fn func(r *http.Request) (*types.Options, error)) RelayOption {
+	return func(r *Relay) {
+		r.options = fn
+	}
+}
+
+// WithAuthorizer sets a check run on every handshake, including resumes.
+// Returning an error rejects the request with 401 Unauthorized. A relay
+// requires one; pass a function that returns nil to accept every request,
+// for example when the relay is only reachable from a trusted network.
+func WithAuthorizer(fn func(r *http.Request) error) RelayOption {
+	return func(r *Relay) {
+		r.authorize = fn
+	}
+}
+
+// WithTransportFactory replaces the SubprocessTransport the relay starts for
+// each session.
+func WithTransportFactory(fn func(opts *types.Options) types.Transport) RelayOption {
+	return func(r *Relay) {
+		r.newTransport = fn
+	}
+}
+
+// WithResumeTimeout sets how long a disconnected session is kept for the
+// client to resume (default: 30s).
+func WithResumeTimeout(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.resumeTimeout = d
+	}
+}
+
+// WithReplayBuffer sets how many sent messages are kept for replay after a
+// reconnect (default: 1024). A client that fell further behind cannot resume.
+func WithReplayBuffer(n int) RelayOption {
+	return func(r *Relay) {
+		r.replayBuffer = n
+	}
+}
+
+// WithIdleTimeout sets how long a client may send nothing, not even a ping,
+// before its connection is treated as dropped and the session waits for a
+// resume (default: 30s, two of the Transport's default ping intervals). Zero
+// waits indefinitely.
+func WithIdleTimeout(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.idleTimeout = d
+	}
+}
+
+// WithClientWriteTimeout bounds each write to a client (default: 10s). A
+// client that stops reading is treated as dropped.
+func WithClientWriteTimeout(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.writeTimeout = d
+	}
+}
+
+// ErrNoAuthorizer is returned by NewRelay when no WithAuthorizer option is given.
+var ErrNoAuthorizer = errors.New("websocket: relay requires an authorizer")
+
+// NewRelay creates a relay. It fails with ErrNoAuthorizer unless
+// WithAuthorizer is given, since every request may start a CLI process.
+func NewRelay(opts ...RelayOption) (*Relay, error) {
+	r := &Relay{
+		options: func(*http.Request) (*types.Options, error) {
+			return types.DefaultOptions(), nil
+		},
+		newTransport: func(opts *types.Options) types.Transport {
+			return subprocess.NewStreamingTransport(opts)
+		},
+		resumeTimeout: defaultResumeTimeout,
+		replayBuffer:  defaultReplayBuffer,
+		idleTimeout:   defaultIdleTimeout,
+		writeTimeout:  defaultWriteTimeout,
+		sessions:      make(map[string]*relaySession),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.authorize == nil {
+		return nil, ErrNoAuthorizer
+	}
+	return r, nil
+}
+
+// ServeHTTP upgrades the request and starts or resumes a session.
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := checkUpgrade(w, req); err != nil {
+		return
+	}
+	if err := r.authorize(req); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if id := req.Header.Get(HeaderSession); id != "" {
+		r.resume(w, req, id)
+		return
+	}
+
+	opts, err := r.options(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts == nil {
+		opts = types.DefaultOptions()
+	}
+
+	transport := r.newTransport(opts)
+	if err := transport.Connect(context.Background()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s := r.register(transport, readLimit(opts))
+	if s == nil {
+		_ = transport.Close()
+		http.Error(w, "relay closed", http.StatusServiceUnavailable)
+		return
+	}
+	go s.pump()
+
+	s.attach(w, req, 0)
+}
+
+func (r *Relay) resume(w http.ResponseWriter, req *http.Request, id string) {
+	lastSeen, err := strconv.ParseInt(req.Header.Get(HeaderReceived), 10, 64)
+	if err != nil || lastSeen < 0 {
+		http.Error(w, "invalid "+HeaderReceived+" header", http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	s := r.sessions[id]
+	r.mu.Unlock()
+	if s == nil {
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return
+	}
+
+	s.attach(w, req, lastSeen)
+}
+
+func (r *Relay) register(transport types.Transport, limit int) *relaySession {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil
+	}
+
+	s := &relaySession{
+		id:        hex.EncodeToString(raw[:]),
+		relay:     r,
+		transport: transport,
+		readLimit: limit,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	// Expire the session if the initial handshake never completes
+	s.timer = time.AfterFunc(r.resumeTimeout, s.expire)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.sessions[s.id] = s
+	return s
+}
+
+func (r *Relay) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+// Sessions returns the number of live sessions.
+func (r *Relay) Sessions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// Close stops every session and rejects new ones.
+func (r *Relay) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	sessions := make([]*relaySession, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	r.mu.Unlock()
+
+	for _, s := range sessions {
+		s.shutdown(closeGoingAway)
+	}
+	return nil
+}
+
+// readLimit is the largest client message accepted for a session.
+func readLimit(opts *types.Options) int {
+	return max(opts.MaxBufferSize, defaultMaxMessageSize)
+}
+
+// relaySession is one CLI process and the client connection currently
+// attached to it.
+type relaySession struct {
+	id        string
+	relay     *Relay
+	transport types.Transport
+	readLimit int
+
+	mu        sync.Mutex
+	cond      *sync.Cond // Signalled when a connection attaches or the session ends
+	conn      *conn      // nil while the client is disconnected
+	ring      [][]byte   // Most recent sent messages; the last one has index sent
+	sent      int64      // Data messages sent to the client
+	received  int64      // Data messages received from the client
+	exit      *relayEvent
+	finished  bool
+	attaching bool        // A handshake is in progress
+	timer     *time.Timer // Expires a disconnected session
+}
+
+// attach completes the handshake, replays what the client missed and serves
+// the connection until it closes. Network writes happen without s.mu held:
+// while attaching, s.conn is nil, so pump waits instead of sending.
+func (s *relaySession) attach(w http.ResponseWriter, req *http.Request, lastSeen int64) {
+	s.mu.Lock()
+
+	if s.finished {
+		s.mu.Unlock()
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return
+	}
+	if s.attaching {
+		s.mu.Unlock()
+		http.Error(w, "session is being resumed", http.StatusConflict)
+		return
+	}
+	oldest := s.sent - int64(len(s.ring)) + 1
+	if lastSeen > s.sent || lastSeen+1 < oldest {
+		s.mu.Unlock()
+		http.Error(w, "messages since the last received one are no longer available", http.StatusGone)
+		s.shutdown(closeNormal)
+		return
+	}
+
+	// A resume supersedes a connection the relay still believes is alive
+	old := s.conn
+	s.conn = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.attaching = true
+	header := make(http.Header)
+	header.Set(HeaderSession, s.id)
+	header.Set(HeaderReceived, strconv.FormatInt(s.received, 10))
+	missed := s.ring[len(s.ring)-int(s.sent-lastSeen):]
+	s.mu.Unlock()
+
+	if old != nil {
+		_ = old.netConn.Close()
+	}
+
+	c, err := upgrade(w, req, header, s.readLimit)
+	if err == nil {
+		c.readTimeout = s.relay.idleTimeout
+		c.writeTimeout = s.relay.writeTimeout
+		for _, msg := range missed {
+			if err = c.writeText(msg); err != nil {
+				_ = c.netConn.Close()
+				break
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.attaching = false
+	if err != nil || s.finished {
+		if err != nil && !s.finished {
+			s.timer = time.AfterFunc(s.relay.resumeTimeout, s.expire)
+		}
+		s.mu.Unlock()
+		if err == nil {
+			_ = c.close(closeNormal, "")
+		}
+		return
+	}
+	s.conn = c
+	s.cond.Broadcast()
+	exit := s.exit
+	s.mu.Unlock()
+
+	if exit != nil {
+		s.sendExit(c, exit)
+		return
+	}
+	s.serve(c)
+}
+
+// serve forwards client messages to the CLI until c fails or closes.
+func (s *relaySession) serve(c *conn) {
+	for {
+		_, data, err := c.readMessage()
+		if err != nil {
+			var tooLarge *messageTooLargeError
+			if errors.As(err, &tooLarge) {
+				s.mu.Lock()
+				s.received++
+				s.mu.Unlock()
+				overflow := &types.BufferOverflowError{Limit: tooLarge.Limit, Size: tooLarge.Size}
+				_ = c.writeText(errorEvent(overflow).encode())
+				continue
+			}
+			var closeErr *closeError
+			if errors.As(err, &closeErr) && closeErr.Code == closeNormal {
+				// The client is done with the session
+				s.shutdown(closeNormal)
+				return
+			}
+			s.detach(c)
+			return
+		}
+
+		if event := decodeRelayEvent(data); event != nil {
+			if event.Event == eventEndInput {
+				if err := s.transport.EndInput(); err != nil {
+					_ = c.writeText(errorEvent(err).encode())
+				}
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		s.received++
+		received := s.received
+		s.mu.Unlock()
+
+		if err := s.transport.Write(string(data) + "\n"); err != nil {
+			_ = c.writeText(errorEvent(err).encode())
+		}
+		if err := c.writeText((&relayEvent{Event: eventAck, Received: received}).encode()); err != nil {
+			s.detach(c)
+			return
+		}
+	}
+}
+
+// pump forwards CLI output to whichever connection is attached. While the
+// client is disconnected it stops reading, so the CLI blocks on a full pipe
+// instead of the relay buffering without bound.
+func (s *relaySession) pump() {
+	// Forward the CLI's own JSON when the transport provides it
+	var wire <-chan types.WireMessage
+	var messages <-chan map[string]any
+	if wt, ok := s.transport.(types.WireMessageTransport); ok {
+		wire = wt.WireMessages()
+	}
+	if wire == nil {
+		messages = s.transport.Messages()
+	}
+	var errs <-chan error
+	if et, ok := s.transport.(types.ErrorTransport); ok {
+		errs = et.Errors()
+	}
+
+	for {
+		var data []byte
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				s.drainErrors(errs)
+				s.finish()
+				return
+			}
+			encoded, err := json.Marshal(msg)
+			if err != nil {
+				s.forwardError(err)
+				continue
+			}
+			data = encoded
+		case msg, ok := <-wire:
+			if !ok {
+				s.drainErrors(errs)
+				s.finish()
+				return
+			}
+			data = msg.JSON
+		case err := <-errs:
+			s.forwardError(err)
+			continue
+		}
+		if !s.forward(data) {
+			return
+		}
+	}
+}
+
+func (s *relaySession) forward(data []byte) bool {
+	s.mu.Lock()
+	for s.conn == nil && !s.finished {
+		s.cond.Wait()
+	}
+	if s.finished {
+		s.mu.Unlock()
+		return false
+	}
+	s.sent++
+	s.ring = append(s.ring, data)
+	if len(s.ring) > s.relay.replayBuffer {
+		s.ring = s.ring[len(s.ring)-s.relay.replayBuffer:]
+	}
+	c := s.conn
+	s.mu.Unlock()
+
+	if err := c.writeText(data); err != nil {
+		// Kept in the ring; replayed when the client resumes
+		s.detach(c)
+	}
+	return true
+}
+
+func (s *relaySession) forwardError(err error) {
+	if err == nil || errors.Is(err, types.ErrProcess) {
+		// Process exits are reported by the exit event
+		return
+	}
+	s.mu.Lock()
+	c := s.conn
+	s.mu.Unlock()
+	if c != nil {
+		_ = c.writeText(errorEvent(err).encode())
+	}
+}
+
+func (s *relaySession) drainErrors(errs <-chan error) {
+	for {
+		select {
+		case err := <-errs:
+			s.forwardError(err)
+		default:
+			return
+		}
+	}
+}
+
+// finish records how the CLI exited and reports it to the attached client,
+// or to the next one that resumes.
+func (s *relaySession) finish() {
+	var exitErr error
+	if et, ok := s.transport.(types.ExitErrorTransport); ok {
+		exitErr = et.ExitError()
+	}
+
+	s.mu.Lock()
+	s.exit = exitEvent(exitErr)
+	c := s.conn
+	s.mu.Unlock()
+
+	if c != nil {
+		s.sendExit(c, s.exit)
+	}
+}
+
+// sendExit delivers the exit event on c and ends the session. If the write
+// fails the session stays available for the client to resume.
+func (s *relaySession) sendExit(c *conn, exit *relayEvent) {
+	if err := c.writeText(exit.encode()); err != nil {
+		s.detach(c)
+		return
+	}
+	s.shutdown(closeNormal)
+}
+
+// detach forgets c and starts the resume timer.
+func (s *relaySession) detach(c *conn) {
+	_ = c.netConn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != c || s.finished {
+		return
+	}
+	s.conn = nil
+	s.timer = time.AfterFunc(s.relay.resumeTimeout, s.expire)
+}
+
+func (s *relaySession) expire() {
+	s.mu.Lock()
+	expired := s.conn == nil && !s.attaching
+	s.mu.Unlock()
+	if expired {
+		s.shutdown(closeNormal)
+	}
+}
+
+// shutdown stops the CLI and closes the attached connection with code.
+func (s *relaySession) shutdown(code int) {
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	c := s.conn
+	s.conn = nil
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	if c != nil {
+		_ = c.close(code, "")
+	}
+	_ = s.transport.Close()
+	s.relay.remove(s.id)
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/sdk"
+	"github.com/victorarias/claude-agent-sdk-go/transport/websocket"
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+const relayFakeCLI = `#!/bin/sh
+if [ "$1" = "-v" ]; then
+  echo "2.0.0"
+  exit 0
+fi
+
+while IFS= read -r line; do
+  case "$line" in
+    *"\"subtype\":\"initialize\""*)
+      req_id=$(printf '%s\n' "$line" | sed -n 's/.*"request_id":"\([^"]*\)".*/\1/p')
+      printf '{"type":"control_response","response":{"subtype":"success","request_id":"%s","response":{"session_id":"sess_relay"}}}\n' "$req_id"
+      ;;
+    *"\"type\":\"user\""*)
+      printf '{"type":"assistant","session_id":"sess_relay","message":{"model":"claude-sonnet-4-5","content":[{"type":"text","text":"hello over the relay"}]}}\n'
+      printf '{"type":"result","subtype":"success","duration_ms":1,"duration_api_ms":1,"is_error":false,"num_turns":1,"session_id":"sess_relay","result":"done"}\n'
+      ;;
+  esac
+done
+`
+
+func TestRelay_ClientEndToEnd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell-script CLI is not supported on Windows")
+	}
+
+	cliPath := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cliPath, []byte(relayFakeCLI), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	relay, err := websocket.NewRelay(
+		websocket.WithAuthorizer(func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				return &types.ConnectionError{Message: "bad token"}
+			}
+			return nil
+		}),
+		websocket.WithSessionOptions(func(*http.Request) (*types.Options, error) {
+			opts := types.DefaultOptions()
+			opts.CLIPath = cliPath
+			return opts, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(relay)
+	defer server.Close()
+	defer relay.Close()
+
+	transport := websocket.NewTransport("ws"+strings.TrimPrefix(server.URL, "http"),
+		websocket.WithHeader(http.Header{"Authorization": {"Bearer token"}}),
+	)
+	client := sdk.NewClient(types.WithTransport(transport))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	if sid := client.SessionID(); sid != "sess_relay" {
+		t.Fatalf("expected session ID from initialize response, got %q", sid)
+	}
+
+	if err := client.SendQuery("hello"); err != nil {
+		t.Fatalf("SendQuery failed: %v", err)
+	}
+	messages, err := client.ReceiveAll()
+	if err != nil {
+		t.Fatalf("ReceiveAll failed: %v", err)
+	}
+
+	var text string
+	var gotResult bool
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case *types.AssistantMessage:
+			text = m.Text()
+		case *types.ResultMessage:
+			gotResult = m.Subtype == "success"
+		}
+	}
+	if text != "hello over the relay" || !gotResult {
+		t.Fatalf("unexpected messages over relay: %+v", messages)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for relay.Sessions() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("relay session not stopped after client close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
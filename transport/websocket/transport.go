@@ -0,0 +1,603 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Defaults for Transport.
+const (
+	defaultMaxMessageSize    = 1024 * 1024 // Matches types.DefaultOptions().MaxBufferSize
+	defaultPingInterval      = 15 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultReconnectAttempts = 5
+	defaultReconnectBackoff  = 250 * time.Millisecond
+	maxReconnectBackoff      = 5 * time.Second
+	defaultMaxPending        = 256
+)
+
+// Transport is a types.Transport that talks to a CLI behind a Relay.
+//
+// Each WebSocket text message carries one stream-json message, so Query and
+// Client work unchanged, including control requests in both directions.
+//
+// If the connection drops, Transport redials with the relay session ID and both
+// sides replay the messages the other has not seen. Messages written while
+// reconnecting are queued and sent once the session resumes.
+type Transport struct {
+	url          string
+	header       http.Header
+	tlsConfig    *tls.Config
+	maxMessage   int
+	pingInterval time.Duration
+	writeTimeout time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxPending   int
+
+	// wire carries every message; messages is fed from it once Messages is called
+	wire         chan types.WireMessage
+	messages     chan map[string]any
+	messagesOnce sync.Once
+	closing      chan struct{} // Closed by Close
+	errors       chan error
+
+	// writeMu keeps Write and EndInput in order on the wire. It is taken
+	// before mu and, unlike mu, held while they write to the socket.
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	cond       *sync.Cond // Signalled when pending shrinks or the transport stops
+	conn       *conn      // nil while reconnecting
+	sessionID  string
+	ready      bool
+	closed     bool
+	inputEnded bool
+	exited     bool     // The relay reported the CLI's exit
+	exitErr    error    // Why the session ended; nil for a clean exit
+	sent       int64    // Data messages written by the caller
+	pending    [][]byte // Unacknowledged messages; the last one has index sent
+
+	received int64 // Data messages received from the relay; owned by readLoop
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithHeader adds headers to every handshake request, e.g. for authentication.
+func WithHeader(header http.Header) Option {
+	return func(t *Transport) {
+		for k, v := range header {
+			t.header[k] = append(t.header[k], v...)
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for wss:// URLs.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(t *Transport) {
+		t.tlsConfig = cfg
+	}
+}
+
+// WithReconnect sets how many times a dropped connection is redialled and the
+// initial backoff between attempts, which doubles up to 5s. Zero attempts
+// disables reconnection.
+func WithReconnect(attempts int, backoff time.Duration) Option {
+	return func(t *Transport) {
+		t.maxAttempts = attempts
+		t.backoff = backoff
+	}
+}
+
+// WithPingInterval sets how often the connection is probed. A connection with
+// no traffic for two intervals is treated as dropped. The relay does the same
+// after its idle timeout, so keep the interval well below it.
+func WithPingInterval(d time.Duration) Option {
+	return func(t *Transport) {
+		t.pingInterval = d
+	}
+}
+
+// WithWriteTimeout bounds each socket write.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(t *Transport) {
+		t.writeTimeout = d
+	}
+}
+
+// WithMaxMessageSize sets the largest message accepted from the relay (default:
+// 1MB). Larger messages are skipped and reported as *types.BufferOverflowError.
+// A size of zero or less means the default.
+func WithMaxMessageSize(size int) Option {
+	return func(t *Transport) {
+		t.maxMessage = size
+	}
+}
+
+// WithMaxPending sets how many written messages may await acknowledgement from
+// the relay before Write blocks (default: 256).
+func WithMaxPending(n int) Option {
+	return func(t *Transport) {
+		t.maxPending = n
+	}
+}
+
+// NewTransport creates a transport for the relay at url (ws://, wss://,
+// http:// or https://).
+func NewTransport(url string, opts ...Option) *Transport {
+	t := &Transport{
+		url:          url,
+		header:       make(http.Header),
+		maxMessage:   defaultMaxMessageSize,
+		pingInterval: defaultPingInterval,
+		writeTimeout: defaultWriteTimeout,
+		maxAttempts:  defaultReconnectAttempts,
+		backoff:      defaultReconnectBackoff,
+		maxPending:   defaultMaxPending,
+		wire:         make(chan types.WireMessage, 100),
+		messages:     make(chan map[string]any, 100),
+		closing:      make(chan struct{}),
+		errors:       make(chan error, 1),
+	}
+	t.cond = sync.NewCond(&t.mu)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// SessionID returns the relay session ID, or "" before Connect.
+func (t *Transport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// IsReady returns true if the transport is connected and not closed.
+func (t *Transport) IsReady() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ready && !t.closed
+}
+
+// Messages returns the channel of messages from the CLI.
+func (t *Transport) Messages() <-chan map[string]any {
+	t.messagesOnce.Do(func() { go t.forwardMessages() })
+	return t.messages
+}
+
+// WireMessages returns the channel of messages from the CLI with the JSON
+// they were decoded from. Use either it or Messages, not both.
+func (t *Transport) WireMessages() <-chan types.WireMessage {
+	return t.wire
+}
+
+// forwardMessages feeds Messages from the wire channel, closing it once the
+// wire channel closes.
+func (t *Transport) forwardMessages() {
+	defer close(t.messages)
+	for msg := range t.wire {
+		select {
+		case t.messages <- msg.Message:
+		case <-t.closing:
+			// Nobody is reading; drop the rest until the stream ends
+		}
+	}
+}
+
+// Errors returns the channel of asynchronous transport and relay errors.
+func (t *Transport) Errors() <-chan error {
+	return t.errors
+}
+
+// ExitError returns why the session ended: the CLI's *types.ProcessError, a
+// *RemoteError, or a *types.ConnectionError if the relay became unreachable.
+// It is nil after a clean exit.
+func (t *Transport) ExitError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exitErr
+}
+
+// Connect dials the relay, which starts a CLI session for this transport.
+func (t *Transport) Connect(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ready {
+		return nil
+	}
+	if t.closed {
+		return &types.ClosedError{Resource: "websocket transport"}
+	}
+
+	c, header, err := dial(ctx, t.url, t.header, t.tlsConfig, t.maxMessage)
+	if err != nil {
+		return &types.ConnectionError{Message: "failed to connect to relay", Cause: err}
+	}
+	t.configure(c)
+	t.conn = c
+	t.sessionID = header.Get(HeaderSession)
+
+	t.ctx, t.cancel = context.WithCancel(ctx)
+
+	t.wg.Add(2)
+	go t.readLoop()
+	go t.pingLoop()
+
+	t.ready = true
+	return nil
+}
+
+func (t *Transport) configure(c *conn) {
+	c.writeTimeout = t.writeTimeout
+	if t.pingInterval > 0 {
+		c.readTimeout = 2 * t.pingInterval
+	}
+}
+
+// Write sends one or more newline-separated stream-json messages.
+//
+// A message that cannot be written because the connection dropped is kept and
+// replayed once the session resumes, so Write only fails if the transport is
+// closed or the session is lost.
+func (t *Transport) Write(data string) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		t.mu.Lock()
+		// Backpressure: wait for the relay to acknowledge earlier messages
+		for len(t.pending) >= t.maxPending && t.writable() == nil {
+			t.cond.Wait()
+		}
+		if err := t.writable(); err != nil {
+			t.mu.Unlock()
+			return err
+		}
+		msg := []byte(line)
+		t.sent++
+		t.pending = append(t.pending, msg)
+		c := t.conn
+		t.mu.Unlock()
+
+		// Without t.mu, so acknowledgements are handled while the socket blocks
+		t.writeTo(c, msg)
+	}
+	return nil
+}
+
+// writeTo sends msg on c, if there is a connection. A message that fails is
+// still pending and is replayed once the read loop reconnects.
+func (t *Transport) writeTo(c *conn, msg []byte) {
+	if c == nil {
+		return
+	}
+	if err := c.writeText(msg); err != nil {
+		// The read loop notices the broken connection and reconnects
+		_ = c.netConn.Close()
+	}
+}
+
+// writable reports why messages can no longer be written, if they cannot.
+// Callers must hold t.mu.
+func (t *Transport) writable() error {
+	switch {
+	case t.closed:
+		return &types.ClosedError{Resource: "websocket transport"}
+	case !t.ready:
+		return &types.ConnectionError{Message: "transport not ready for writing"}
+	case t.exited || t.exitErr != nil:
+		return &types.ConnectionError{Message: "relay session ended", Cause: t.exitErr}
+	case t.inputEnded:
+		return &types.ConnectionError{Message: "input already ended"}
+	}
+	return nil
+}
+
+// EndInput tells the relay to close the CLI's stdin.
+func (t *Transport) EndInput() error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	t.mu.Lock()
+	if t.inputEnded {
+		t.mu.Unlock()
+		return nil
+	}
+	if err := t.writable(); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	t.inputEnded = true
+	c := t.conn
+	t.mu.Unlock()
+
+	t.writeTo(c, (&relayEvent{Event: eventEndInput}).encode())
+	return nil
+}
+
+// Close ends the session. The relay stops the CLI when it receives a normal
+// close from the client.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.closing)
+	c := t.conn
+	t.conn = nil
+	t.cond.Broadcast()
+	t.mu.Unlock()
+
+	if c != nil {
+		_ = c.close(closeNormal, "")
+	}
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+	return nil
+}
+
+// readLoop delivers relay messages until the session ends, reconnecting when
+// the connection drops. It closes the wire channel on return.
+func (t *Transport) readLoop() {
+	defer t.wg.Done()
+	defer close(t.wire)
+
+	for {
+		t.mu.Lock()
+		c := t.conn
+		t.mu.Unlock()
+		if c == nil {
+			return
+		}
+
+		_, data, err := c.readMessage()
+		if err != nil {
+			var tooLarge *messageTooLargeError
+			if errors.As(err, &tooLarge) {
+				// The relay counted it as sent, so keep the replay position in step
+				t.received++
+				t.sendError(&types.BufferOverflowError{Limit: tooLarge.Limit, Size: tooLarge.Size})
+				continue
+			}
+			if t.finished(err) || !t.reconnect(c, err) {
+				return
+			}
+			continue
+		}
+
+		if event := decodeRelayEvent(data); event != nil {
+			t.handleEvent(event)
+			continue
+		}
+
+		t.received++
+		var msg map[string]any
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.sendError(&types.JSONDecodeError{Line: string(data), OriginalError: err})
+			continue
+		}
+
+		select {
+		case t.wire <- types.WireMessage{Message: msg, JSON: json.RawMessage(data)}:
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// finished reports whether a read error ends the session instead of
+// triggering a reconnect.
+func (t *Transport) finished(err error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed || t.exited {
+		return true
+	}
+	var closeErr *closeError
+	if errors.As(err, &closeErr) && closeErr.Code == closeNormal {
+		// The relay ended the session without reporting an exit
+		t.fail(&types.ConnectionError{Message: "relay closed the session", Cause: err})
+		return true
+	}
+	return false
+}
+
+func (t *Transport) handleEvent(event *relayEvent) {
+	switch event.Event {
+	case eventAck:
+		t.mu.Lock()
+		first := t.sent - int64(len(t.pending)) + 1
+		if n := event.Received - first + 1; n > 0 {
+			t.pending = t.pending[min(n, int64(len(t.pending))):]
+			t.cond.Broadcast()
+		}
+		t.mu.Unlock()
+	case eventError:
+		t.sendError(&RemoteError{Kind: event.Kind, Message: event.Message})
+	case eventExit:
+		t.mu.Lock()
+		t.exited = true
+		t.exitErr = event.exitError()
+		t.cond.Broadcast()
+		t.mu.Unlock()
+	}
+}
+
+// reconnect redials the relay after broken failed, resuming the session and
+// replaying unacknowledged messages. It returns false if the session is lost.
+func (t *Transport) reconnect(broken *conn, cause error) bool {
+	_ = broken.netConn.Close()
+
+	t.mu.Lock()
+	if t.conn == broken {
+		t.conn = nil
+	}
+	sessionID := t.sessionID
+	t.mu.Unlock()
+
+	if t.maxAttempts <= 0 || sessionID == "" {
+		t.stop(&types.ConnectionError{Message: "relay connection lost", Cause: cause})
+		return false
+	}
+
+	backoff := t.backoff
+	lastErr := cause
+	for attempt := 0; attempt < t.maxAttempts; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-t.ctx.Done():
+			return false
+		}
+		backoff = min(2*backoff, maxReconnectBackoff)
+
+		header := t.header.Clone()
+		header.Set(HeaderSession, sessionID)
+		header.Set(HeaderReceived, strconv.FormatInt(t.received, 10))
+
+		c, respHeader, err := dial(t.ctx, t.url, header, t.tlsConfig, t.maxMessage)
+		if err != nil {
+			lastErr = err
+			var handshakeErr *HandshakeError
+			if errors.As(err, &handshakeErr) && !handshakeErr.retryable() {
+				break
+			}
+			continue
+		}
+		t.configure(c)
+
+		relayReceived, err := strconv.ParseInt(respHeader.Get(HeaderReceived), 10, 64)
+		if err != nil {
+			_ = c.close(closeProtocolError, "missing received count")
+			lastErr = err
+			break
+		}
+		if t.resume(c, relayReceived) {
+			return true
+		}
+		lastErr = errors.New("replay after reconnect failed")
+	}
+
+	t.stop(&types.ConnectionError{Message: "relay connection lost", Cause: lastErr})
+	return false
+}
+
+// resume installs c and replays everything the relay has not received.
+func (t *Transport) resume(c *conn, relayReceived int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		_ = c.close(closeNormal, "")
+		return false
+	}
+
+	first := t.sent - int64(len(t.pending)) + 1
+	if n := relayReceived - first + 1; n > 0 {
+		t.pending = t.pending[min(n, int64(len(t.pending))):]
+	}
+	for _, msg := range t.pending {
+		if err := c.writeText(msg); err != nil {
+			_ = c.netConn.Close()
+			return false
+		}
+	}
+	if t.inputEnded {
+		if err := c.writeText((&relayEvent{Event: eventEndInput}).encode()); err != nil {
+			_ = c.netConn.Close()
+			return false
+		}
+	}
+
+	t.conn = c
+	t.cond.Broadcast()
+	return true
+}
+
+// stop records err as the reason the session ended.
+func (t *Transport) stop(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fail(err)
+}
+
+// fail records err as the exit error. Callers must hold t.mu.
+func (t *Transport) fail(err error) {
+	if t.exitErr == nil && !t.exited {
+		t.exitErr = err
+	}
+	t.cond.Broadcast()
+}
+
+// pingLoop probes the connection so that silent drops are detected by the
+// read deadline.
+func (t *Transport) pingLoop() {
+	defer t.wg.Done()
+
+	if t.pingInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		t.mu.Lock()
+		c := t.conn
+		t.mu.Unlock()
+		if c != nil {
+			if err := c.ping(); err != nil {
+				_ = c.netConn.Close()
+			}
+		}
+	}
+}
+
+// sendError delivers err on the errors channel without blocking.
+func (t *Transport) sendError(err error) {
+	select {
+	case t.errors <- err:
+	default:
+		// Error channel full, continue anyway
+	}
+}
+
+var (
+	_ types.Transport            = (*Transport)(nil)
+	_ types.ErrorTransport       = (*Transport)(nil)
+	_ types.ExitErrorTransport   = (*Transport)(nil)
+	_ types.WireMessageTransport = (*Transport)(nil)
+)
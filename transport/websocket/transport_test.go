@@ -0,0 +1,564 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// fakeTransport stands in for the relay's SubprocessTransport.
+type fakeTransport struct {
+	messages chan map[string]any
+	errors   chan error
+	written  chan string
+	ended    chan struct{}
+
+	mu       sync.Mutex
+	exitErr  error
+	closed   bool
+	endOnce  sync.Once
+	doneOnce sync.Once
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		messages: make(chan map[string]any, 100),
+		errors:   make(chan error, 1),
+		written:  make(chan string, 100),
+		ended:    make(chan struct{}),
+	}
+}
+
+func (f *fakeTransport) Connect(ctx context.Context) error { return nil }
+func (f *fakeTransport) IsReady() bool                     { return true }
+func (f *fakeTransport) Messages() <-chan map[string]any   { return f.messages }
+func (f *fakeTransport) Errors() <-chan error              { return f.errors }
+
+func (f *fakeTransport) Write(data string) error {
+	f.written <- data
+	return nil
+}
+
+func (f *fakeTransport) EndInput() error {
+	f.endOnce.Do(func() { close(f.ended) })
+	return nil
+}
+
+func (f *fakeTransport) ExitError() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.exitErr
+}
+
+// exit simulates the CLI exiting with err.
+func (f *fakeTransport) exit(err error) {
+	f.mu.Lock()
+	f.exitErr = err
+	f.mu.Unlock()
+	f.doneOnce.Do(func() { close(f.messages) })
+}
+
+func (f *fakeTransport) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	f.doneOnce.Do(func() { close(f.messages) })
+	return nil
+}
+
+func (f *fakeTransport) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// startRelay serves a relay whose sessions all use fake.
+func startRelay(t *testing.T, fake *fakeTransport, opts ...RelayOption) (*Relay, string) {
+	t.Helper()
+	opts = append([]RelayOption{
+		WithAuthorizer(func(*http.Request) error { return nil }),
+		WithTransportFactory(func(*types.Options) types.Transport { return fake }),
+	}, opts...)
+	relay, err := NewRelay(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(relay)
+	t.Cleanup(func() {
+		relay.Close()
+		server.Close()
+	})
+	return relay, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func connectTransport(t *testing.T, url string, opts ...Option) *Transport {
+	t.Helper()
+	transport := NewTransport(url, opts...)
+	// Like SubprocessTransport, the transport lives as long as the Connect context
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+func receive(t *testing.T, transport *Transport) map[string]any {
+	t.Helper()
+	select {
+	case msg, ok := <-transport.Messages():
+		if !ok {
+			t.Fatal("messages channel closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return nil
+}
+
+func receiveWritten(t *testing.T, fake *fakeTransport) string {
+	t.Helper()
+	select {
+	case data := <-fake.written:
+		return data
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for relayed write")
+	}
+	return ""
+}
+
+// dropConnection severs the relay's side of the session's connection without
+// a close handshake.
+func dropConnection(t *testing.T, relay *Relay) {
+	t.Helper()
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	for _, s := range relay.sessions {
+		s.mu.Lock()
+		if s.conn != nil {
+			s.conn.netConn.Close()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestTransport_RelaysStreamJSONBothWays(t *testing.T) {
+	fake := newFakeTransport()
+	_, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	if !transport.IsReady() || transport.SessionID() == "" {
+		t.Fatalf("expected ready transport with session ID, got ready=%v id=%q", transport.IsReady(), transport.SessionID())
+	}
+
+	request := `{"type":"control_request","request_id":"req_1","request":{"subtype":"initialize"}}`
+	if err := transport.Write(request + "\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := receiveWritten(t, fake); got != request+"\n" {
+		t.Fatalf("relay wrote %q, want %q", got, request+"\n")
+	}
+
+	fake.messages <- map[string]any{"type": "control_request", "request_id": "cli_1", "request": map[string]any{"subtype": "can_use_tool"}}
+	fake.messages <- map[string]any{"type": "result", "subtype": "success"}
+
+	if msg := receive(t, transport); msg["type"] != "control_request" || msg["request_id"] != "cli_1" {
+		t.Fatalf("unexpected first message: %v", msg)
+	}
+	if msg := receive(t, transport); msg["type"] != "result" {
+		t.Fatalf("unexpected second message: %v", msg)
+	}
+}
+
+// wireFakeTransport is a fakeTransport that delivers the CLI's original JSON.
+type wireFakeTransport struct {
+	*fakeTransport
+	wire chan types.WireMessage
+}
+
+func (f *wireFakeTransport) WireMessages() <-chan types.WireMessage { return f.wire }
+
+func TestTransport_RelaysOriginalJSON(t *testing.T) {
+	fake := &wireFakeTransport{fakeTransport: newFakeTransport(), wire: make(chan types.WireMessage, 1)}
+	relay, err := NewRelay(
+		WithAuthorizer(func(*http.Request) error { return nil }),
+		WithTransportFactory(func(*types.Options) types.Transport { return fake }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(relay)
+	t.Cleanup(func() {
+		relay.Close()
+		server.Close()
+	})
+	transport := connectTransport(t, "ws"+strings.TrimPrefix(server.URL, "http"))
+
+	line := `{"type":"result","total_cost_usd":1.0,"big":12345678901234567890}`
+	fake.wire <- types.WireMessage{Message: map[string]any{"type": "result"}, JSON: json.RawMessage(line)}
+
+	select {
+	case msg := <-transport.WireMessages():
+		if string(msg.JSON) != line || msg.Message["type"] != "result" {
+			t.Fatalf("got %s %v, want %s", msg.JSON, msg.Message, line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestTransport_EndInputReachesCLI(t *testing.T) {
+	fake := newFakeTransport()
+	_, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	if err := transport.EndInput(); err != nil {
+		t.Fatalf("EndInput failed: %v", err)
+	}
+	select {
+	case <-fake.ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not end CLI input")
+	}
+	if err := transport.Write(`{"type":"user"}`); err == nil {
+		t.Fatal("expected Write after EndInput to fail")
+	}
+}
+
+func TestTransport_ExitErrorCarriesProcessError(t *testing.T) {
+	fake := newFakeTransport()
+	_, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	fake.messages <- map[string]any{"type": "assistant"}
+	fake.exit(&types.ProcessError{ExitCode: 3, Stderr: "fatal: out of credits"})
+
+	if msg := receive(t, transport); msg["type"] != "assistant" {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+	select {
+	case _, ok := <-transport.Messages():
+		if ok {
+			t.Fatal("expected messages channel to close after exit")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages channel not closed after exit")
+	}
+
+	var procErr *types.ProcessError
+	if err := transport.ExitError(); !errors.As(err, &procErr) {
+		t.Fatalf("expected *types.ProcessError, got %v", err)
+	}
+	if procErr.ExitCode != 3 || procErr.Stderr != "fatal: out of credits" {
+		t.Fatalf("unexpected process error: %+v", procErr)
+	}
+	if !errors.Is(transport.ExitError(), types.ErrProcess) {
+		t.Fatal("expected exit error to match ErrProcess")
+	}
+}
+
+func TestTransport_CleanExitHasNoExitError(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	fake.exit(nil)
+	for range transport.Messages() {
+	}
+	if err := transport.ExitError(); err != nil {
+		t.Fatalf("expected nil exit error, got %v", err)
+	}
+	waitFor(t, func() bool { return relay.Sessions() == 0 })
+}
+
+func TestTransport_ForwardsRelayErrors(t *testing.T) {
+	fake := newFakeTransport()
+	_, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	fake.errors <- &types.JSONDecodeError{Line: "Debugger listening", OriginalError: errors.New("bad")}
+
+	select {
+	case err := <-transport.Errors():
+		var remote *RemoteError
+		if !errors.As(err, &remote) || !errors.Is(err, types.ErrParse) {
+			t.Fatalf("expected RemoteError matching ErrParse, got %v", err)
+		}
+		if !strings.Contains(remote.Message, "Debugger listening") {
+			t.Fatalf("expected offending line in message, got %q", remote.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for relayed error")
+	}
+}
+
+func TestTransport_ResumesAfterConnectionDrop(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake)
+	transport := connectTransport(t, url, WithReconnect(10, 20*time.Millisecond))
+	sessionID := transport.SessionID()
+
+	fake.messages <- map[string]any{"type": "assistant", "n": float64(1)}
+	if msg := receive(t, transport); msg["n"] != float64(1) {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+
+	dropConnection(t, relay)
+
+	// Output produced and input written while disconnected must not be lost
+	fake.messages <- map[string]any{"type": "assistant", "n": float64(2)}
+	fake.messages <- map[string]any{"type": "assistant", "n": float64(3)}
+	if err := transport.Write(`{"type":"user","n":1}`); err != nil {
+		t.Fatalf("Write during reconnect failed: %v", err)
+	}
+
+	for want := 2; want <= 3; want++ {
+		if msg := receive(t, transport); msg["n"] != float64(want) {
+			t.Fatalf("expected message %d, got %v", want, msg)
+		}
+	}
+	if got := receiveWritten(t, fake); got != `{"type":"user","n":1}`+"\n" {
+		t.Fatalf("unexpected relayed write %q", got)
+	}
+	select {
+	case extra := <-fake.written:
+		t.Fatalf("write delivered twice: %q", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if transport.SessionID() != sessionID {
+		t.Fatalf("session changed across reconnect: %q -> %q", sessionID, transport.SessionID())
+	}
+	if relay.Sessions() != 1 {
+		t.Fatalf("expected one session, got %d", relay.Sessions())
+	}
+}
+
+func TestRelay_RejectsResumeBeyondReplayBuffer(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake, WithReplayBuffer(1))
+	transport := connectTransport(t, url, WithReconnect(0, 0))
+
+	fake.messages <- map[string]any{"type": "assistant", "n": float64(1)}
+	fake.messages <- map[string]any{"type": "assistant", "n": float64(2)}
+	receive(t, transport)
+	receive(t, transport)
+	dropConnection(t, relay)
+
+	// Only the second message is still buffered, so a client that saw neither
+	// cannot resume
+	header := http.Header{}
+	header.Set(HeaderSession, transport.SessionID())
+	header.Set(HeaderReceived, "0")
+	_, _, err := dial(context.Background(), url, header, nil, 0)
+
+	var handshakeErr *HandshakeError
+	if !errors.As(err, &handshakeErr) || handshakeErr.StatusCode != http.StatusGone {
+		t.Fatalf("expected 410 handshake error, got %v", err)
+	}
+	if handshakeErr.retryable() {
+		t.Fatal("410 must not be retried")
+	}
+	waitFor(t, func() bool { return relay.Sessions() == 0 && fake.isClosed() })
+}
+
+func TestRelay_ExpiresDetachedSession(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake, WithResumeTimeout(50*time.Millisecond))
+	transport := connectTransport(t, url, WithReconnect(0, 0))
+
+	dropConnection(t, relay)
+
+	for range transport.Messages() {
+	}
+	if !errors.Is(transport.ExitError(), types.ErrConnection) {
+		t.Fatalf("expected connection error, got %v", transport.ExitError())
+	}
+	waitFor(t, func() bool { return relay.Sessions() == 0 && fake.isClosed() })
+}
+
+func TestRelay_DetachesSilentClient(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake, WithIdleTimeout(50*time.Millisecond), WithResumeTimeout(50*time.Millisecond))
+
+	// A half-open client: the handshake completes, then nothing arrives
+	c, _, err := dial(context.Background(), url, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer c.netConn.Close()
+
+	waitFor(t, func() bool { return relay.Sessions() == 0 && fake.isClosed() })
+}
+
+func TestRelay_ClientCloseStopsCLI(t *testing.T) {
+	fake := newFakeTransport()
+	relay, url := startRelay(t, fake)
+	transport := connectTransport(t, url)
+
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	waitFor(t, func() bool { return relay.Sessions() == 0 && fake.isClosed() })
+
+	if err := transport.Write(`{"type":"user"}`); !errors.Is(err, types.ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestRelay_RejectsUnauthorized(t *testing.T) {
+	fake := newFakeTransport()
+	_, url := startRelay(t, fake, WithAuthorizer(func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			return fmt.Errorf("invalid token")
+		}
+		return nil
+	}))
+
+	err := NewTransport(url).Connect(context.Background())
+	var handshakeErr *HandshakeError
+	if !errors.As(err, &handshakeErr) || handshakeErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 handshake error, got %v", err)
+	}
+	if !errors.Is(err, types.ErrConnection) {
+		t.Fatalf("expected ConnectionError, got %T", err)
+	}
+
+	connectTransport(t, url, WithHeader(http.Header{"Authorization": {"Bearer secret"}}))
+}
+
+func TestNewRelay_RequiresAuthorizer(t *testing.T) {
+	if _, err := NewRelay(); !errors.Is(err, ErrNoAuthorizer) {
+		t.Fatalf("expected ErrNoAuthorizer, got %v", err)
+	}
+}
+
+func TestRelay_StartsNoCLIBeforeHandshakeAndAuthorization(t *testing.T) {
+	var started atomic.Int32
+	relay, err := NewRelay(
+		WithAuthorizer(func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return fmt.Errorf("invalid token")
+			}
+			return nil
+		}),
+		WithTransportFactory(func(*types.Options) types.Transport {
+			started.Add(1)
+			return newFakeTransport()
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(relay)
+	defer server.Close()
+	defer relay.Close()
+
+	// A plain GET, even an authorized one, is not a handshake
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a plain GET, got %d", resp.StatusCode)
+	}
+
+	// An unauthorized handshake is rejected
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	if err := NewTransport(url).Connect(context.Background()); err == nil {
+		t.Error("expected the unauthorized handshake to fail")
+	}
+	if n := started.Load(); n != 0 || relay.Sessions() != 0 {
+		t.Fatalf("expected no CLI to start, got %d starts and %d sessions", n, relay.Sessions())
+	}
+}
+
+func TestTransport_WriteBlocksOnUnacknowledgedMessages(t *testing.T) {
+	fake := newFakeTransport()
+	fake.written = make(chan string) // Unbuffered: the relay stalls until the test reads
+	_, url := startRelay(t, fake)
+	transport := connectTransport(t, url, WithMaxPending(1))
+
+	if err := transport.Write(`{"n":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- transport.Write(`{"n":2}`) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("second Write returned before the first was acknowledged: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	receiveWritten(t, fake)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second Write failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Write still blocked after acknowledgement")
+	}
+	receiveWritten(t, fake)
+}
+
+func TestTransport_AcksHandledWhileWriteBlocks(t *testing.T) {
+	client, server := connPair(t, 0)
+	transport := NewTransport("ws://unused")
+	transport.conn = client
+	transport.ready = true
+
+	// Nothing reads the server side, so the write blocks on the pipe
+	done := make(chan error, 1)
+	go func() { done <- transport.Write(`{"n":1}`) }()
+	time.Sleep(50 * time.Millisecond)
+
+	acked := make(chan struct{})
+	go func() {
+		transport.handleEvent(&relayEvent{Event: eventAck, Received: 1})
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("acknowledgement blocked behind a pending socket write")
+	}
+
+	if _, data, err := server.readMessage(); err != nil || string(data) != `{"n":1}` {
+		t.Fatalf("readMessage = %q, %v", data, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
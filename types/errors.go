@@ -6,19 +6,22 @@ package types
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 )
 
 // Sentinel errors for error checking with errors.Is
 var (
-	ErrCLINotFound = errors.New("claude CLI not found")
-	ErrCLIVersion  = errors.New("CLI version too old")
-	ErrConnection  = errors.New("connection error")
-	ErrProcess     = errors.New("process error")
-	ErrParse       = errors.New("parse error")
-	ErrTimeout     = errors.New("timeout error")
-	ErrClosed      = errors.New("transport closed")
+	ErrCLINotFound    = errors.New("claude CLI not found")
+	ErrCLIVersion     = errors.New("CLI version too old")
+	ErrConnection     = errors.New("connection error")
+	ErrProcess        = errors.New("process error")
+	ErrParse          = errors.New("parse error")
+	ErrTimeout        = errors.New("timeout error")
+	ErrClosed         = errors.New("transport closed")
+	ErrBufferOverflow = errors.New("message exceeds buffer limit")
+	ErrResourceLimit  = errors.New("resource limit error")
 )
 
 // SDKError is the base error type for all SDK errors.
@@ -78,12 +81,20 @@ func (e *ConnectionError) Unwrap() error {
 
 // ProcessError is returned when the CLI process fails.
 type ProcessError struct {
-	ExitCode int
-	Stderr   string
+	ExitCode int       // Exit status, or -1 if the process was terminated by a signal
+	Signal   os.Signal // Signal that terminated the process, if any
+	Stderr   string    // Trailing portion of the process's stderr output
 }
 
 func (e *ProcessError) Error() string {
-	return fmt.Sprintf("process exited with code %d: %s", e.ExitCode, e.Stderr)
+	msg := fmt.Sprintf("process exited with code %d", e.ExitCode)
+	if e.Signal != nil {
+		msg = fmt.Sprintf("process terminated by signal %v", e.Signal)
+	}
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
 }
 
 func (e *ProcessError) Is(target error) bool {
@@ -108,6 +119,56 @@ func (e *JSONDecodeError) Unwrap() error {
 	return e.OriginalError
 }
 
+// BufferOverflowError is returned when a message from the CLI is larger than
+// Options.MaxBufferSize. The message is discarded and reading continues with the next one.
+type BufferOverflowError struct {
+	Limit       int    // Configured MaxBufferSize in bytes
+	Size        int    // Bytes read before the message was discarded
+	MessageType string // Value of the message's "type" field, if it could be determined
+}
+
+func (e *BufferOverflowError) Error() string {
+	if e.MessageType != "" {
+		return fmt.Sprintf("buffer size %d exceeds limit of %d bytes: %q message discarded", e.Size, e.Limit, e.MessageType)
+	}
+	return fmt.Sprintf("buffer size %d exceeds limit of %d bytes: message discarded", e.Size, e.Limit)
+}
+
+func (e *BufferOverflowError) Is(target error) bool {
+	return target == ErrBufferOverflow
+}
+
+// ResourceLimitError is returned when a ResourceLimits setting cannot be
+// applied to the CLI process, or reported when the CLI exits after exceeding one.
+type ResourceLimitError struct {
+	Resource string        // "address_space", "cpu_time", "open_files", "nice", "cgroup" or "memory"
+	Limit    string        // Configured limit
+	Process  *ProcessError // How the CLI exited, if it exceeded the limit
+	Cause    error         // Why the limit could not be applied
+}
+
+func (e *ResourceLimitError) Error() string {
+	resource := "resource limits"
+	if e.Resource != "" {
+		resource = fmt.Sprintf("%s limit %s", e.Resource, e.Limit)
+	}
+	if e.Process != nil {
+		return fmt.Sprintf("CLI exceeded %s: %v", resource, e.Process)
+	}
+	return fmt.Sprintf("cannot apply %s: %v", resource, e.Cause)
+}
+
+func (e *ResourceLimitError) Is(target error) bool {
+	return target == ErrResourceLimit
+}
+
+func (e *ResourceLimitError) Unwrap() error {
+	if e.Process != nil {
+		return e.Process
+	}
+	return e.Cause
+}
+
 // MessageParseError is returned when a message cannot be parsed.
 type MessageParseError struct {
 	Message string
@@ -122,13 +183,18 @@ func (e *MessageParseError) Is(target error) bool {
 	return target == ErrParse
 }
 
-// CLIVersionError is returned when the CLI version is too old.
+// CLIVersionError is returned when the CLI version is too old, or newer than
+// the tested range under a CLIVersionPolicy with RejectUntested.
 type CLIVersionError struct {
 	InstalledVersion string
 	MinimumVersion   string
+	MaximumVersion   string // Set when the version was rejected as untested
 }
 
 func (e *CLIVersionError) Error() string {
+	if e.MaximumVersion != "" {
+		return fmt.Sprintf("CLI version %s is newer than maximum tested version %s", e.InstalledVersion, e.MaximumVersion)
+	}
 	return fmt.Sprintf("CLI version %s is below minimum required version %s", e.InstalledVersion, e.MinimumVersion)
 }
 
@@ -162,3 +228,27 @@ func (e *ClosedError) Error() string {
 func (e *ClosedError) Is(target error) bool {
 	return target == ErrClosed
 }
+
+// CLIVersionWarning is surfaced on the transport's errors channel when the CLI
+// is newer than the version range the SDK is tested against. It is
+// informational: the CLI has been started.
+type CLIVersionWarning struct {
+	InstalledVersion string
+	MaximumVersion   string
+}
+
+func (e *CLIVersionWarning) Error() string {
+	return fmt.Sprintf("CLI version %s is newer than maximum tested version %s", e.InstalledVersion, e.MaximumVersion)
+}
+
+// PermissionFallbackWarning is surfaced once per session when a permission request
+// arrives and no CanUseTool callback is configured. It is informational: the request
+// has already been answered according to the configured fallback policy.
+type PermissionFallbackWarning struct {
+	ToolName string                     // Tool from the first unconfigured request
+	Behavior PermissionFallbackBehavior // Fallback policy that answered the request
+}
+
+func (e *PermissionFallbackWarning) Error() string {
+	return fmt.Sprintf("permission request for %s answered with fallback %q: no CanUseTool callback configured", e.ToolName, e.Behavior)
+}
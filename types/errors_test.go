@@ -5,6 +5,7 @@ package types
 
 import (
 	"errors"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -247,6 +248,15 @@ func TestProcessError(t *testing.T) {
 		}
 	})
 
+	t.Run("Error message with signal and no stderr", func(t *testing.T) {
+		err := &ProcessError{ExitCode: -1, Signal: syscall.SIGKILL}
+
+		expected := "process terminated by signal killed"
+		if err.Error() != expected {
+			t.Errorf("Expected %q, got %q", expected, err.Error())
+		}
+	})
+
 	t.Run("errors.Is returns true for ErrProcess", func(t *testing.T) {
 		err := &ProcessError{
 			ExitCode: 127,
@@ -259,6 +269,38 @@ func TestProcessError(t *testing.T) {
 	})
 }
 
+// TestResourceLimitError tests the ResourceLimitError type.
+func TestResourceLimitError(t *testing.T) {
+	t.Run("Error message when a limit cannot be applied", func(t *testing.T) {
+		err := &ResourceLimitError{Resource: "nice", Limit: "-5", Cause: syscall.EACCES}
+
+		expected := "cannot apply nice limit -5: permission denied"
+		if err.Error() != expected {
+			t.Errorf("Expected %q, got %q", expected, err.Error())
+		}
+		if !errors.Is(err, syscall.EACCES) {
+			t.Error("Expected errors.Is to match the cause")
+		}
+	})
+
+	t.Run("Exceeded limit wraps the process error", func(t *testing.T) {
+		procErr := &ProcessError{ExitCode: -1, Signal: syscall.SIGKILL}
+		err := &ResourceLimitError{Resource: "cpu_time", Limit: "2s", Process: procErr}
+
+		expected := "CLI exceeded cpu_time limit 2s: process terminated by signal killed"
+		if err.Error() != expected {
+			t.Errorf("Expected %q, got %q", expected, err.Error())
+		}
+		if !errors.Is(err, ErrResourceLimit) || !errors.Is(err, ErrProcess) {
+			t.Error("Expected errors.Is to match ErrResourceLimit and ErrProcess")
+		}
+		var target *ProcessError
+		if !errors.As(err, &target) || target != procErr {
+			t.Error("Expected errors.As to find the process error")
+		}
+	})
+}
+
 // TestJSONDecodeError tests the JSONDecodeError type.
 func TestJSONDecodeError(t *testing.T) {
 	t.Run("Error message format", func(t *testing.T) {
@@ -0,0 +1,228 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Size limits for inline media, applied to the base64-encoded data as the API does.
+const (
+	MaxImageSize    = 5 << 20  // 5 MB per image
+	MaxDocumentSize = 32 << 20 // 32 MB per PDF, the API's request size limit
+)
+
+// imageMediaTypes lists the image formats the API accepts.
+var imageMediaTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// fileMediaTypes maps file extensions to the media types UserInput.File accepts.
+var fileMediaTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+	".txt":  "text/plain",
+	".md":   "text/plain",
+}
+
+// UserInput builds the content of a user message from text, images and
+// documents, for sending several parts in one turn:
+//
+//	input := types.NewUserInput().
+//		Text("What changed between these screenshots?").
+//		File("before.png").
+//		File("after.png")
+//
+// Methods record the first invalid part; Content reports it.
+type UserInput struct {
+	blocks []ContentBlock
+	err    error
+}
+
+// NewUserInput returns an empty UserInput.
+func NewUserInput() *UserInput {
+	return &UserInput{}
+}
+
+// Text appends a text part.
+func (u *UserInput) Text(text string) *UserInput {
+	if text == "" {
+		return u.fail(fmt.Errorf("text part is empty"))
+	}
+	return u.add(&TextBlock{TextContent: text})
+}
+
+// Image appends an image given its media type and raw bytes, which are sent base64-encoded.
+func (u *UserInput) Image(mediaType string, data []byte) *UserInput {
+	if err := validateMedia("image", mediaType, data, MaxImageSize); err != nil {
+		return u.fail(err)
+	}
+	return u.add(&ImageBlock{Source: BlockSource{
+		Type:      "base64",
+		MediaType: mediaType,
+		Data:      base64.StdEncoding.EncodeToString(data),
+	}})
+}
+
+// ImageURL appends an image the API fetches from url.
+func (u *UserInput) ImageURL(url string) *UserInput {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return u.fail(fmt.Errorf("image URL %q is not an http(s) URL", url))
+	}
+	return u.add(&ImageBlock{Source: BlockSource{Type: "url", URL: url}})
+}
+
+// ImageFileID appends an image uploaded with the Files API.
+func (u *UserInput) ImageFileID(fileID string) *UserInput {
+	if fileID == "" {
+		return u.fail(fmt.Errorf("image file ID is empty"))
+	}
+	return u.add(&ImageBlock{Source: BlockSource{Type: "file", FileID: fileID}})
+}
+
+// PDF appends a PDF document given its raw bytes. The title is optional.
+func (u *UserInput) PDF(data []byte, title string) *UserInput {
+	if err := validateMedia("document", "application/pdf", data, MaxDocumentSize); err != nil {
+		return u.fail(err)
+	}
+	return u.add(&DocumentBlock{
+		Source: BlockSource{
+			Type:      "base64",
+			MediaType: "application/pdf",
+			Data:      base64.StdEncoding.EncodeToString(data),
+		},
+		Title: title,
+	})
+}
+
+// TextDocument appends a plain-text document, which Claude can cite
+// separately from the prompt. The title is optional.
+func (u *UserInput) TextDocument(text, title string) *UserInput {
+	if text == "" {
+		return u.fail(fmt.Errorf("text document is empty"))
+	}
+	return u.add(&DocumentBlock{
+		Source: BlockSource{Type: "text", MediaType: "text/plain", Data: text},
+		Title:  title,
+	})
+}
+
+// DocumentURL appends a PDF the API fetches from url.
+func (u *UserInput) DocumentURL(url string) *UserInput {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return u.fail(fmt.Errorf("document URL %q is not an http(s) URL", url))
+	}
+	return u.add(&DocumentBlock{Source: BlockSource{Type: "url", URL: url}})
+}
+
+// DocumentFileID appends a document uploaded with the Files API.
+func (u *UserInput) DocumentFileID(fileID string) *UserInput {
+	if fileID == "" {
+		return u.fail(fmt.Errorf("document file ID is empty"))
+	}
+	return u.add(&DocumentBlock{Source: BlockSource{Type: "file", FileID: fileID}})
+}
+
+// File reads the file at path and appends it as an image, a PDF or a text
+// document, chosen by its extension (.png, .jpg, .jpeg, .gif, .webp, .pdf,
+// .txt or .md). Documents are titled with the file's name.
+func (u *UserInput) File(path string) *UserInput {
+	mediaType, ok := fileMediaTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return u.fail(fmt.Errorf("unsupported file type: %s", path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return u.fail(err)
+	}
+
+	switch mediaType {
+	case "application/pdf":
+		return u.PDF(data, filepath.Base(path))
+	case "text/plain":
+		return u.TextDocument(string(data), filepath.Base(path))
+	default:
+		return u.Image(mediaType, data)
+	}
+}
+
+// Blocks returns the parts added so far, or the first invalid part's error.
+// A nil UserInput returns an error.
+func (u *UserInput) Blocks() ([]ContentBlock, error) {
+	if u == nil {
+		return nil, fmt.Errorf("user input is nil")
+	}
+	if u.err != nil {
+		return nil, u.err
+	}
+	if len(u.blocks) == 0 {
+		return nil, fmt.Errorf("user input is empty")
+	}
+	return u.blocks, nil
+}
+
+// Content returns the parts as the content array of a user message, encoded
+// by the blocks' MarshalJSON methods.
+func (u *UserInput) Content() ([]map[string]any, error) {
+	blocks, err := u.Blocks()
+	if err != nil {
+		return nil, err
+	}
+	content := make([]map[string]any, 0, len(blocks))
+	for _, block := range blocks {
+		data, err := json.Marshal(block)
+		if err != nil {
+			return nil, err
+		}
+		var part map[string]any
+		if err := json.Unmarshal(data, &part); err != nil {
+			return nil, err
+		}
+		content = append(content, part)
+	}
+	return content, nil
+}
+
+func (u *UserInput) add(block ContentBlock) *UserInput {
+	u.blocks = append(u.blocks, block)
+	return u
+}
+
+func (u *UserInput) fail(err error) *UserInput {
+	if u.err == nil {
+		u.err = fmt.Errorf("user input part %d: %w", len(u.blocks)+1, err)
+	}
+	return u
+}
+
+// validateMedia checks that data is non-empty, within the size limit, of a
+// supported media type, and actually looks like that type.
+func validateMedia(kind, mediaType string, data []byte, maxSize int) error {
+	if kind == "image" && !imageMediaTypes[mediaType] {
+		return fmt.Errorf("unsupported image media type %q", mediaType)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("%s is empty", kind)
+	}
+	if size := base64.StdEncoding.EncodedLen(len(data)); size > maxSize {
+		return fmt.Errorf("%s is %d bytes encoded, above the %d byte limit", kind, size, maxSize)
+	}
+	if detected := http.DetectContentType(data); detected != mediaType {
+		return fmt.Errorf("%s data is %s, not %s", kind, detected, mediaType)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	pdfData = []byte("%PDF-1.7\n1 0 obj\n")
+)
+
+func TestUserInput_Content(t *testing.T) {
+	content, err := NewUserInput().
+		Text("Compare these").
+		Image("image/png", pngData).
+		ImageURL("https://example.com/chart.png").
+		ImageFileID("file_img").
+		PDF(pdfData, "report.pdf").
+		TextDocument("notes", "").
+		DocumentFileID("file_doc").
+		Content()
+	if err != nil {
+		t.Fatalf("Content failed: %v", err)
+	}
+	if len(content) != 7 {
+		t.Fatalf("expected 7 parts, got %d", len(content))
+	}
+
+	if content[0]["type"] != "text" || content[0]["text"] != "Compare these" {
+		t.Errorf("unexpected text part: %v", content[0])
+	}
+	image := content[1]["source"].(map[string]any)
+	if content[1]["type"] != "image" || image["type"] != "base64" || image["media_type"] != "image/png" ||
+		image["data"] != base64.StdEncoding.EncodeToString(pngData) {
+		t.Errorf("unexpected image part: %v", content[1])
+	}
+	if source := content[2]["source"].(map[string]any); source["type"] != "url" || source["url"] != "https://example.com/chart.png" {
+		t.Errorf("unexpected image URL part: %v", content[2])
+	}
+	if source := content[3]["source"].(map[string]any); source["type"] != "file" || source["file_id"] != "file_img" {
+		t.Errorf("unexpected image file part: %v", content[3])
+	}
+	if content[4]["type"] != "document" || content[4]["title"] != "report.pdf" ||
+		content[4]["source"].(map[string]any)["media_type"] != "application/pdf" {
+		t.Errorf("unexpected PDF part: %v", content[4])
+	}
+	if source := content[5]["source"].(map[string]any); source["type"] != "text" || source["data"] != "notes" {
+		t.Errorf("unexpected text document part: %v", content[5])
+	}
+	if _, ok := content[5]["title"]; ok {
+		t.Errorf("empty title should be omitted: %v", content[5])
+	}
+	if source := content[6]["source"].(map[string]any); source["file_id"] != "file_doc" {
+		t.Errorf("unexpected document file part: %v", content[6])
+	}
+}
+
+func TestUserInput_ContentMatchesMarshalJSON(t *testing.T) {
+	input := NewUserInput().Text("hi").Image("image/png", pngData).PDF(pdfData, "report.pdf")
+	input.add(&DocumentBlock{
+		Source:    BlockSource{Type: "text", MediaType: "text/plain", Data: "notes"},
+		Context:   "meeting notes",
+		Citations: &CitationsConfig{Enabled: true},
+	})
+
+	content, err := input.Content()
+	if err != nil {
+		t.Fatalf("Content failed: %v", err)
+	}
+	blocks, _ := input.Blocks()
+	for i, block := range blocks {
+		data, err := json.Marshal(block)
+		if err != nil {
+			t.Fatalf("MarshalJSON failed: %v", err)
+		}
+		var want map[string]any
+		json.Unmarshal(data, &want)
+		if !reflect.DeepEqual(content[i], want) {
+			t.Errorf("part %d differs from MarshalJSON:\n%v\n%v", i, content[i], want)
+		}
+	}
+	if content[3]["context"] != "meeting notes" || content[3]["citations"] == nil {
+		t.Errorf("document fields dropped: %v", content[3])
+	}
+}
+
+func TestUserInput_File(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"shot.PNG":  pngData,
+		"spec.pdf":  pdfData,
+		"README.md": []byte("# Title"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks, err := NewUserInput().
+		File(filepath.Join(dir, "shot.PNG")).
+		File(filepath.Join(dir, "spec.pdf")).
+		File(filepath.Join(dir, "README.md")).
+		Blocks()
+	if err != nil {
+		t.Fatalf("Blocks failed: %v", err)
+	}
+	if image, ok := blocks[0].(*ImageBlock); !ok || image.Source.MediaType != "image/png" {
+		t.Errorf("expected a PNG image, got %#v", blocks[0])
+	}
+	if doc, ok := blocks[1].(*DocumentBlock); !ok || doc.Source.MediaType != "application/pdf" || doc.Title != "spec.pdf" {
+		t.Errorf("expected a PDF document, got %#v", blocks[1])
+	}
+	if doc, ok := blocks[2].(*DocumentBlock); !ok || doc.Source.Type != "text" || doc.Source.Data != "# Title" {
+		t.Errorf("expected a text document, got %#v", blocks[2])
+	}
+}
+
+func TestUserInput_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input *UserInput
+		want  string
+	}{
+		{"nil input", nil, "user input is nil"},
+		{"empty input", NewUserInput(), "user input is empty"},
+		{"empty text", NewUserInput().Text(""), "text part is empty"},
+		{"unsupported image type", NewUserInput().Image("image/bmp", []byte("BM")), `unsupported image media type "image/bmp"`},
+		{"empty image", NewUserInput().Image("image/png", nil), "image is empty"},
+		{"mismatched image data", NewUserInput().Image("image/jpeg", pngData), "image data is image/png, not image/jpeg"},
+		{"oversized image", NewUserInput().Image("image/png", append(bytes.Clone(pngData), make([]byte, 4<<20)...)), "above the 5242880 byte limit"},
+		{"not a PDF", NewUserInput().PDF([]byte("plain text"), ""), "document data is text/plain; charset=utf-8, not application/pdf"},
+		{"bad URL", NewUserInput().ImageURL("file:///etc/passwd"), "not an http(s) URL"},
+		{"unsupported file", NewUserInput().File("archive.zip"), "unsupported file type"},
+		{"missing file", NewUserInput().File("/nonexistent/shot.png"), "no such file"},
+		{"first error wins", NewUserInput().Text("ok").Image("image/tiff", pngData).Text(""), "part 2: unsupported image media type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.input.Content()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
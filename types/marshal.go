@@ -0,0 +1,757 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Messages and content blocks marshal to the CLI's stream-json format and
+// unmarshal from it through parseMessage and ParseContentBlock, so a message
+// parsed from the CLI comes back unchanged from json.Marshal followed by
+// json.Unmarshal or UnmarshalMessage.
+
+// UnmarshalMessage decodes a message in the CLI's stream-json format, such as
+// one stored with json.Marshal, into its Message type.
+func UnmarshalMessage(data []byte) (Message, error) {
+	raw, err := decodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessage(raw)
+}
+
+// decodeObject decodes a JSON object into a map.
+func decodeObject(data []byte) (map[string]any, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("expected a JSON object, got %s", data)
+	}
+	return raw, nil
+}
+
+// unmarshalMessage parses data with parseMessage into m, which must be the
+// type parseMessage returns for it.
+func unmarshalMessage[M any, P interface {
+	*M
+	Message
+}](data []byte, m P) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	msg, err := parseMessage(raw)
+	if err != nil {
+		return err
+	}
+	parsed, ok := msg.(P)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %s message into %T", describeMessage(raw), m)
+	}
+	*m = *parsed
+	return nil
+}
+
+// describeMessage names a raw message's type, and its subtype if it has one.
+func describeMessage(raw map[string]any) string {
+	if subtype := getString(raw, "subtype"); subtype != "" {
+		return getString(raw, "type") + "/" + subtype
+	}
+	return getString(raw, "type")
+}
+
+// unmarshalBlock parses data with ParseContentBlock into b, which must be the
+// type ParseContentBlock returns for it.
+func unmarshalBlock[B any, P interface {
+	*B
+	ContentBlock
+}](data []byte, b P) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	block, err := ParseContentBlock(raw)
+	if err != nil {
+		return err
+	}
+	parsed, ok := block.(P)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %s block into %T", block.BlockType(), b)
+	}
+	*b = *parsed
+	return nil
+}
+
+// putString sets key to value unless value is empty.
+func putString(obj map[string]any, key, value string) {
+	if value != "" {
+		obj[key] = value
+	}
+}
+
+// putIDs sets the uuid and session_id keys most messages carry.
+func putIDs(obj map[string]any, uuid, sessionID string) {
+	putString(obj, "uuid", uuid)
+	putString(obj, "session_id", sessionID)
+}
+
+// fillFrom copies payload, the message as received, and sets the given
+// fields where it lacks them. Fields with empty values are skipped.
+func fillFrom(payload map[string]any, fields map[string]any) map[string]any {
+	obj := make(map[string]any, len(payload)+len(fields))
+	for key, value := range payload {
+		obj[key] = value
+	}
+	for key, value := range fields {
+		if _, ok := obj[key]; ok || isEmptyField(value) {
+			continue
+		}
+		obj[key] = value
+	}
+	return obj
+}
+
+func isEmptyField(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return v == nil
+	case *float64:
+		return v == nil
+	}
+	return false
+}
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *UserMessage) MarshalJSON() ([]byte, error) {
+	message := map[string]any{"content": m.Content}
+	putString(message, "role", m.Role)
+	obj := map[string]any{"type": "user", "message": message}
+	putIDs(obj, m.UUID, m.SessionID)
+	if m.ParentToolUseID != nil {
+		obj["parent_tool_use_id"] = *m.ParentToolUseID
+	}
+	if m.IsSynthetic {
+		obj["isSynthetic"] = true
+	}
+	if m.IsReplay {
+		obj["isReplay"] = true
+	}
+	if m.ToolUseResult != nil {
+		obj["tool_use_result"] = m.ToolUseResult
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a user message in the CLI's stream-json format.
+func (m *UserMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *AssistantMessage) MarshalJSON() ([]byte, error) {
+	message := map[string]any{"type": "message", "role": "assistant", "content": m.Content}
+	putString(message, "id", m.MessageID)
+	putString(message, "model", m.Model)
+	putString(message, "stop_reason", m.StopReason)
+	if m.Usage != nil {
+		message["usage"] = m.Usage
+	}
+	obj := map[string]any{"type": "assistant", "message": message}
+	putIDs(obj, m.UUID, m.SessionID)
+	if m.ParentToolUseID != nil {
+		obj["parent_tool_use_id"] = *m.ParentToolUseID
+	}
+	if m.Error != nil {
+		obj["error"] = string(*m.Error)
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes an assistant message in the CLI's stream-json format.
+func (m *AssistantMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format. Data,
+// which holds the payload as received, is written as is; the other fields
+// fill in keys it lacks.
+func (m *SystemMessage) MarshalJSON() ([]byte, error) {
+	return m.marshalWith(nil)
+}
+
+// marshalWith encodes the message with fields added to its payload where
+// the payload lacks them.
+func (m *SystemMessage) marshalWith(fields map[string]any) ([]byte, error) {
+	if fields == nil {
+		fields = make(map[string]any, 2)
+	}
+	fields["session_id"] = m.SessionID
+	fields["version"] = m.Version
+
+	// Data is the whole message unless the CLI nested the payload under "data"
+	if m.Data != nil && m.Data["type"] != "system" {
+		obj := map[string]any{"type": "system", "data": fillFrom(m.Data, fields)}
+		putString(obj, "subtype", m.Subtype)
+		putString(obj, "uuid", m.UUID)
+		return json.Marshal(obj)
+	}
+
+	fields["subtype"] = m.Subtype
+	fields["uuid"] = m.UUID
+	obj := fillFrom(m.Data, fields)
+	obj["type"] = "system"
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a system message of any subtype into its common fields.
+func (m *SystemMessage) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	if msgType := getString(raw, "type"); msgType != "system" {
+		return fmt.Errorf("cannot unmarshal %s message into %T", msgType, m)
+	}
+	*m = *parseSystemBase(raw)
+	return nil
+}
+
+// MarshalJSON encodes the message in the CLI's stream-json format. Like
+// SystemMessage, the payload in Data takes precedence over the typed fields.
+func (m *SystemInitMessage) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{
+		"claude_code_version": m.ClaudeCodeVersion,
+		"cwd":                 m.Cwd,
+		"model":               m.Model,
+		"permissionMode":      string(m.PermissionMode),
+		"apiKeySource":        m.APIKeySource,
+		"output_style":        m.OutputStyle,
+		"tools":               m.Tools,
+		"slash_commands":      m.SlashCommands,
+		"agents":              m.Agents,
+		"skills":              m.Skills,
+		"betas":               m.Betas,
+	}
+	if m.MCPServers != nil {
+		fields["mcp_servers"] = m.MCPServers
+	}
+	if m.Plugins != nil {
+		fields["plugins"] = m.Plugins
+	}
+	return m.SystemMessage.marshalWith(fields)
+}
+
+// UnmarshalJSON decodes a system init message in the CLI's stream-json format.
+func (m *SystemInitMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *ResultMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{
+		"type":            "result",
+		"subtype":         m.Subtype,
+		"duration_ms":     m.DurationMS,
+		"duration_api_ms": m.DurationAPI,
+		"is_error":        m.IsError,
+		"num_turns":       m.NumTurns,
+		"session_id":      m.SessionID,
+	}
+	putString(obj, "uuid", m.UUID)
+	if m.StopReason != nil {
+		obj["stop_reason"] = *m.StopReason
+	}
+	if m.TotalCostUSD != nil {
+		obj["total_cost_usd"] = *m.TotalCostUSD
+	}
+	if m.Usage != nil {
+		obj["usage"] = m.Usage
+	} else if m.TokenUsage != nil {
+		obj["usage"] = m.TokenUsage
+	}
+	if m.ModelUsage != nil {
+		obj["modelUsage"] = m.ModelUsage
+	}
+	if m.PermissionDenials != nil {
+		denials := make([]map[string]any, 0, len(m.PermissionDenials))
+		for _, denial := range m.PermissionDenials {
+			entry := map[string]any{"tool_name": denial.ToolName, "tool_use_id": denial.ToolUseID}
+			if denial.ToolInput != nil {
+				entry["tool_input"] = denial.ToolInput
+			}
+			denials = append(denials, entry)
+		}
+		obj["permission_denials"] = denials
+	}
+	if m.Errors != nil {
+		obj["errors"] = m.Errors
+	}
+	if m.Result != nil {
+		obj["result"] = *m.Result
+	}
+	if m.StructuredOutput != nil {
+		obj["structured_output"] = m.StructuredOutput
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a result message in the CLI's stream-json format.
+func (m *ResultMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the event in the CLI's stream-json format.
+func (m *StreamEvent) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "stream_event"}
+	putIDs(obj, m.UUID, m.SessionID)
+	putString(obj, "event_type", m.EventType)
+	if m.Event != nil {
+		obj["event"] = m.Event
+	}
+	if m.Index != nil {
+		obj["index"] = *m.Index
+	}
+	if m.Delta != nil {
+		obj["delta"] = m.Delta
+	}
+	if m.ParentToolUseID != nil {
+		obj["parent_tool_use_id"] = *m.ParentToolUseID
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a stream event in the CLI's stream-json format.
+func (m *StreamEvent) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *AuthStatusMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "auth_status", "isAuthenticating": m.IsAuthenticating}
+	if m.Output != nil {
+		obj["output"] = m.Output
+	}
+	putString(obj, "error", m.Error)
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes an auth status message in the CLI's stream-json format.
+func (m *AuthStatusMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *ToolProgressMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{
+		"type":                 "tool_progress",
+		"tool_use_id":          m.ToolUseID,
+		"tool_name":            m.ToolName,
+		"elapsed_time_seconds": m.ElapsedTimeSeconds,
+	}
+	if m.ParentToolUseID != nil {
+		obj["parent_tool_use_id"] = *m.ParentToolUseID
+	}
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a tool progress message in the CLI's stream-json format.
+func (m *ToolProgressMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *ToolUseSummaryMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "tool_use_summary", "summary": m.Summary}
+	if m.PrecedingToolUseIDs != nil {
+		obj["preceding_tool_use_ids"] = m.PrecedingToolUseIDs
+	}
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a tool use summary in the CLI's stream-json format.
+func (m *ToolUseSummaryMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the event in the CLI's stream-json format. Data, which
+// holds the event as received, takes precedence over the typed fields.
+func (m *RateLimitEvent) MarshalJSON() ([]byte, error) {
+	obj := fillFrom(m.Data, map[string]any{
+		"uuid":                m.UUID,
+		"session_id":          m.SessionID,
+		"resets_at":           m.ResetsAt,
+		"retry_after_seconds": m.RetryAfterSeconds,
+	})
+	obj["type"] = "rate_limit_event"
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a rate limit event in the CLI's stream-json format.
+func (m *RateLimitEvent) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message as received, from Raw.
+func (m *UnknownMessage) MarshalJSON() ([]byte, error) {
+	obj := fillFrom(m.Raw, map[string]any{"uuid": m.UUID, "session_id": m.SessionID})
+	putString(obj, "type", m.Type)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a message of any type, keeping it whole in Raw.
+func (m *UnknownMessage) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	*m = UnknownMessage{
+		Type:      getString(raw, "type"),
+		UUID:      getString(raw, "uuid"),
+		SessionID: getString(raw, "session_id"),
+		Raw:       raw,
+	}
+	return nil
+}
+
+// systemSubtype returns subtype, or fallback if it is empty.
+func systemSubtype(subtype, fallback string) string {
+	if subtype == "" {
+		return fallback
+	}
+	return subtype
+}
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *TaskNotificationMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{
+		"type":    "system",
+		"subtype": systemSubtype(m.Subtype, "task_notification"),
+		"task_id": m.TaskID,
+		"status":  m.Status,
+	}
+	putString(obj, "output_file", m.OutputFile)
+	putString(obj, "summary", m.Summary)
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a task notification in the CLI's stream-json format.
+func (m *TaskNotificationMessage) UnmarshalJSON(data []byte) error {
+	return unmarshalMessage(data, m)
+}
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *FilesPersistedMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "system", "subtype": systemSubtype(m.Subtype, "files_persisted")}
+	if m.Files != nil {
+		obj["files"] = m.Files
+	}
+	if m.Failed != nil {
+		obj["failed"] = m.Failed
+	}
+	putString(obj, "processed_at", m.ProcessedAt)
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a files persisted message in the CLI's stream-json format.
+func (m *FilesPersistedMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// hookMessage holds the fields the hook lifecycle messages share.
+func hookMessage(subtype, hookID, hookName, hookEvent, uuid, sessionID string) map[string]any {
+	obj := map[string]any{
+		"type":       "system",
+		"subtype":    subtype,
+		"hook_id":    hookID,
+		"hook_name":  hookName,
+		"hook_event": hookEvent,
+	}
+	putIDs(obj, uuid, sessionID)
+	return obj
+}
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *HookStartedMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hookMessage(systemSubtype(m.Subtype, "hook_started"), m.HookID, m.HookName, m.HookEvent, m.UUID, m.SessionID))
+}
+
+// UnmarshalJSON decodes a hook started message in the CLI's stream-json format.
+func (m *HookStartedMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *HookProgressMessage) MarshalJSON() ([]byte, error) {
+	obj := hookMessage(systemSubtype(m.Subtype, "hook_progress"), m.HookID, m.HookName, m.HookEvent, m.UUID, m.SessionID)
+	putString(obj, "stdout", m.Stdout)
+	putString(obj, "stderr", m.Stderr)
+	putString(obj, "output", m.Output)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a hook progress message in the CLI's stream-json format.
+func (m *HookProgressMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *HookResponseMessage) MarshalJSON() ([]byte, error) {
+	obj := hookMessage(systemSubtype(m.Subtype, "hook_response"), m.HookID, m.HookName, m.HookEvent, m.UUID, m.SessionID)
+	putString(obj, "output", m.Output)
+	putString(obj, "stdout", m.Stdout)
+	putString(obj, "stderr", m.Stderr)
+	putString(obj, "outcome", m.Outcome)
+	if m.ExitCode != nil {
+		obj["exit_code"] = *m.ExitCode
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a hook response message in the CLI's stream-json format.
+func (m *HookResponseMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *StatusMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "system", "subtype": systemSubtype(m.Subtype, "status")}
+	if m.Status != nil {
+		obj["status"] = m.Status
+	}
+	putString(obj, "permissionMode", string(m.PermissionMode))
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a status message in the CLI's stream-json format.
+func (m *StatusMessage) UnmarshalJSON(data []byte) error { return unmarshalMessage(data, m) }
+
+// MarshalJSON encodes the message in the CLI's stream-json format.
+func (m *CompactBoundaryMessage) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{
+		"type":             "system",
+		"subtype":          systemSubtype(m.Subtype, "compact_boundary"),
+		"compact_metadata": m.CompactMetadata,
+	}
+	putIDs(obj, m.UUID, m.SessionID)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes a compact boundary message in the CLI's stream-json format.
+func (m *CompactBoundaryMessage) UnmarshalJSON(data []byte) error {
+	return unmarshalMessage(data, m)
+}
+
+// marshalBlock encodes block's tagged fields with its type added.
+func marshalBlock(blockType string, fields any) ([]byte, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := json.Marshal(blockType)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "{}" {
+		return []byte(`{"type":` + string(prefix) + `}`), nil
+	}
+	return []byte(`{"type":` + string(prefix) + `,` + string(data[1:])), nil
+}
+
+// MarshalJSON encodes the block in the API's format.
+func (b *TextBlock) MarshalJSON() ([]byte, error) {
+	type plain TextBlock
+	return marshalBlock("text", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a text block.
+func (b *TextBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ThinkingBlock) MarshalJSON() ([]byte, error) {
+	type plain ThinkingBlock
+	return marshalBlock("thinking", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a thinking block.
+func (b *ThinkingBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ToolUseBlock) MarshalJSON() ([]byte, error) {
+	type plain ToolUseBlock
+	return marshalBlock("tool_use", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a tool use block.
+func (b *ToolUseBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format. Content parsed from
+// structured JSON is written back as that JSON. Otherwise it is written as
+// ContentBlocks, unless they are just the text of ResultContent, and as the
+// ResultContent string if there are none.
+func (b *ToolResultBlock) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "tool_result", "tool_use_id": b.ToolUseID}
+	if b.IsError {
+		obj["is_error"] = true
+	}
+	if content := b.marshalContent(); content != nil {
+		obj["content"] = content
+	}
+	return json.Marshal(obj)
+}
+
+func (b *ToolResultBlock) marshalContent() any {
+	if b.structured && json.Valid([]byte(b.ResultContent)) {
+		return json.RawMessage(b.ResultContent)
+	}
+	if len(b.ContentBlocks) == 1 {
+		if text, ok := b.ContentBlocks[0].(*TextBlock); ok && text.TextContent == b.ResultContent {
+			return b.ResultContent
+		}
+	}
+	if b.ContentBlocks != nil {
+		return b.ContentBlocks
+	}
+	if b.ResultContent != "" {
+		return b.ResultContent
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a tool result block.
+func (b *ToolResultBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ImageBlock) MarshalJSON() ([]byte, error) {
+	type plain ImageBlock
+	return marshalBlock("image", (*plain)(b))
+}
+
+// UnmarshalJSON decodes an image block.
+func (b *ImageBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *DocumentBlock) MarshalJSON() ([]byte, error) {
+	type plain DocumentBlock
+	return marshalBlock("document", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a document block.
+func (b *DocumentBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *RedactedThinkingBlock) MarshalJSON() ([]byte, error) {
+	type plain RedactedThinkingBlock
+	return marshalBlock("redacted_thinking", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a redacted thinking block.
+func (b *RedactedThinkingBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ServerToolUseBlock) MarshalJSON() ([]byte, error) {
+	type plain ServerToolUseBlock
+	return marshalBlock("server_tool_use", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a server tool use block.
+func (b *ServerToolUseBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format, with the results or
+// the error code as its content.
+func (b *WebSearchToolResultBlock) MarshalJSON() ([]byte, error) {
+	var content any = b.Results
+	if b.ErrorCode != "" {
+		content = map[string]any{"type": "web_search_tool_result_error", "error_code": b.ErrorCode}
+	}
+	return json.Marshal(map[string]any{
+		"type":        "web_search_tool_result",
+		"tool_use_id": b.ToolUseID,
+		"content":     content,
+	})
+}
+
+// UnmarshalJSON decodes a web search tool result block.
+func (b *WebSearchToolResultBlock) UnmarshalJSON(data []byte) error {
+	return unmarshalBlock(data, b)
+}
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ServerToolResultBlock) MarshalJSON() ([]byte, error) {
+	type plain ServerToolResultBlock
+	return json.Marshal((*plain)(b))
+}
+
+// UnmarshalJSON decodes one of the server tool result blocks.
+func (b *ServerToolResultBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *MCPToolUseBlock) MarshalJSON() ([]byte, error) {
+	type plain MCPToolUseBlock
+	return marshalBlock("mcp_tool_use", (*plain)(b))
+}
+
+// UnmarshalJSON decodes an MCP tool use block.
+func (b *MCPToolUseBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *MCPToolResultBlock) MarshalJSON() ([]byte, error) {
+	obj := map[string]any{"type": "mcp_tool_result", "tool_use_id": b.ToolUseID}
+	if b.ContentBlocks != nil {
+		obj["content"] = b.ContentBlocks
+	}
+	if b.IsError {
+		obj["is_error"] = true
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON decodes an MCP tool result block.
+func (b *MCPToolResultBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *SearchResultBlock) MarshalJSON() ([]byte, error) {
+	type plain SearchResultBlock
+	return marshalBlock("search_result", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a search result block.
+func (b *SearchResultBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block in the API's format.
+func (b *ContainerUploadBlock) MarshalJSON() ([]byte, error) {
+	type plain ContainerUploadBlock
+	return marshalBlock("container_upload", (*plain)(b))
+}
+
+// UnmarshalJSON decodes a container upload block.
+func (b *ContainerUploadBlock) UnmarshalJSON(data []byte) error { return unmarshalBlock(data, b) }
+
+// MarshalJSON encodes the block as received, from Raw.
+func (b *UnknownBlock) MarshalJSON() ([]byte, error) {
+	if len(b.Raw) > 0 {
+		return b.Raw, nil
+	}
+	return json.Marshal(map[string]any{"type": b.Kind})
+}
+
+// UnmarshalJSON decodes a block of any type, keeping it whole in Raw.
+func (b *UnknownBlock) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	kind, _ := raw["type"].(string)
+	if kind == "" {
+		return fmt.Errorf("missing content block type")
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	*b = UnknownBlock{Kind: kind, Raw: encoded}
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wireMessages are messages in the CLI's stream-json format.
+var wireMessages = []string{
+	`{"type":"user","message":{"role":"user","content":"hello"},"session_id":"s1","uuid":"u1","parent_tool_use_id":null}`,
+	`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"ok"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBO"}}]},{"type":"tool_result","tool_use_id":"t2","content":"plain","is_error":true},{"type":"tool_result","tool_use_id":"t3","content":{"rows":2}}]},"isSynthetic":true,"tool_use_result":{"stdout":"ok"}}`,
+	`{"type":"assistant","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"redacted_thinking","data":"enc"},{"type":"text","text":"Hi <b>"},{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/a","limit":10}}],"stop_reason":"tool_use","usage":{"input_tokens":12,"output_tokens":30,"cache_read_input_tokens":100,"service_tier":"standard"}},"parent_tool_use_id":"task_1","session_id":"s1","uuid":"u2"}`,
+	`{"type":"assistant","message":{"model":"claude-sonnet-4-5","content":[{"type":"server_tool_use","id":"srv_1","name":"web_search","input":{"query":"go"}},{"type":"web_search_tool_result","tool_use_id":"srv_1","content":[{"type":"web_search_result","url":"https://go.dev","title":"Go"}]},{"type":"web_search_tool_result","tool_use_id":"srv_2","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}},{"type":"code_execution_tool_result","tool_use_id":"srv_3","content":{"stdout":"1"}},{"type":"mcp_tool_use","id":"m1","name":"add","server_name":"calc","input":{}},{"type":"mcp_tool_result","tool_use_id":"m1","content":[{"type":"text","text":"3"}]},{"type":"search_result","source":"kb","title":"T","content":[{"type":"text","text":"x"}],"citations":{"enabled":true}},{"type":"container_upload","file_id":"f1"},{"type":"document","source":{"type":"text","media_type":"text/plain","data":"doc"},"title":"D"},{"type":"future_block","payload":{"a":[1,2]}}]},"error":"rate_limit"}`,
+	`{"type":"system","subtype":"init","session_id":"s1","uuid":"u3","model":"claude-sonnet-4-5","cwd":"/w","tools":["Read"],"mcp_servers":[{"name":"calc","status":"failed","error":"boom"}],"plugins":[],"permissionMode":"default","extra":{"k":1}}`,
+	`{"type":"system","subtype":"custom","uuid":"u4","data":{"session_id":"s1","version":"2.1.0","detail":true}}`,
+	`{"type":"system","subtype":"task_notification","task_id":"task_1","status":"completed","output_file":"/tmp/o","summary":"done","session_id":"s1"}`,
+	`{"type":"system","subtype":"files_persisted","files":[{"filename":"a","file_id":"f1"}],"failed":[],"processed_at":"now"}`,
+	`{"type":"system","subtype":"hook_started","hook_id":"h1","hook_name":"lint","hook_event":"PostToolUse"}`,
+	`{"type":"system","subtype":"hook_progress","hook_id":"h1","hook_name":"lint","hook_event":"PostToolUse","stdout":"..."}`,
+	`{"type":"system","subtype":"hook_response","hook_id":"h1","hook_name":"lint","hook_event":"PostToolUse","exit_code":0,"outcome":"success"}`,
+	`{"type":"system","subtype":"status","status":null,"permissionMode":"plan"}`,
+	`{"type":"system","subtype":"status","status":"compacting"}`,
+	`{"type":"system","subtype":"compact_boundary","compact_metadata":{"trigger":"auto","pre_tokens":1000}}`,
+	`{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":8,"is_error":false,"num_turns":2,"session_id":"s1","result":"done","total_cost_usd":0.01,"usage":{},"modelUsage":{"claude-sonnet-4-5":{"inputTokens":5,"outputTokens":3,"costUSD":0.01}},"permission_denials":[{"tool_name":"Bash","tool_use_id":"t9","tool_input":{}}],"structured_output":{"ok":true},"stop_reason":"end_turn"}`,
+	`{"type":"result","subtype":"error_max_turns","duration_ms":1,"duration_api_ms":1,"is_error":true,"num_turns":9,"session_id":"s1","errors":["too many turns"],"permission_denials":[]}`,
+	`{"type":"stream_event","uuid":"e1","session_id":"s1","event":{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"a"}},"parent_tool_use_id":null}`,
+	`{"type":"stream_event","event_type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"b"}}`,
+	`{"type":"auth_status","isAuthenticating":true,"output":["Opening browser"],"session_id":"s1"}`,
+	`{"type":"tool_progress","tool_use_id":"t1","tool_name":"Bash","elapsed_time_seconds":1.5,"parent_tool_use_id":"task_1"}`,
+	`{"type":"tool_use_summary","summary":"Read 2 files","preceding_tool_use_ids":["t1","t2"]}`,
+	`{"type":"rate_limit_event","retry_after_seconds":3,"resets_at":"soon","extra":1}`,
+	`{"type":"future_message","uuid":"u9","payload":{"nested":[true]}}`,
+}
+
+func parseWire(t *testing.T, wire string) Message {
+	t.Helper()
+	msg, err := UnmarshalMessage([]byte(wire))
+	if err != nil {
+		t.Fatalf("UnmarshalMessage(%s) failed: %v", wire, err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(wire), &raw); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseMessage(raw)
+	if err != nil {
+		t.Fatalf("parseMessage failed: %v", err)
+	}
+	if !reflect.DeepEqual(msg, parsed) {
+		t.Fatalf("UnmarshalMessage and parseMessage differ:\n%#v\n%#v", msg, parsed)
+	}
+	return msg
+}
+
+func TestMessageJSONRoundTrip(t *testing.T) {
+	for _, wire := range wireMessages {
+		msg := parseWire(t, wire)
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("Marshal(%T) failed: %v", msg, err)
+		}
+		roundTripped := parseWire(t, string(data))
+		if !reflect.DeepEqual(roundTripped, msg) {
+			t.Errorf("%T changed after a round trip:\nwire:  %s\nafter: %s\ngot:  %#v\nwant: %#v", msg, wire, data, roundTripped, msg)
+		}
+
+		// json.Unmarshal into the concrete type gives the same value
+		target := reflect.New(reflect.TypeOf(msg).Elem()).Interface()
+		if err := json.Unmarshal(data, target); err != nil {
+			t.Fatalf("Unmarshal into %T failed: %v", target, err)
+		}
+		if !reflect.DeepEqual(target, msg) {
+			t.Errorf("Unmarshal into %T differs:\ngot:  %#v\nwant: %#v", target, target, msg)
+		}
+	}
+}
+
+func TestMessageJSON_WireFormat(t *testing.T) {
+	parent := "task_1"
+	msg := &AssistantMessage{
+		Model:           "claude-sonnet-4-5",
+		ParentToolUseID: &parent,
+		Content: []ContentBlock{
+			&TextBlock{TextContent: "Reading"},
+			&ToolUseBlock{ID: "t1", Name: "Read", ToolInput: map[string]any{"file_path": "/a"}},
+		},
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"message":{"content":[{"type":"text","text":"Reading"},{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/a"}}],"model":"claude-sonnet-4-5","role":"assistant","type":"message"},"parent_tool_use_id":"task_1","type":"assistant"}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+
+	// Typed fields fill in a hand-built system message
+	initMsg := &SystemInitMessage{SystemMessage: SystemMessage{Subtype: "init", SessionID: "s1"}, Model: "m", Tools: []string{"Read"}}
+	data, err = json.Marshal(initMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := decoded.(*SystemInitMessage)
+	if !ok || got.SessionID != "s1" || got.Model != "m" || !got.HasTool("Read") {
+		t.Errorf("unexpected init message from %s: %#v", data, decoded)
+	}
+}
+
+func TestMessageJSON_UnmarshalErrors(t *testing.T) {
+	var assistant AssistantMessage
+	if err := json.Unmarshal([]byte(`{"type":"user","message":{"content":"hi"}}`), &assistant); err == nil ||
+		!strings.Contains(err.Error(), "cannot unmarshal user message") {
+		t.Errorf("expected a type mismatch error, got %v", err)
+	}
+	var status StatusMessage
+	if err := json.Unmarshal([]byte(`{"type":"system","subtype":"hook_started"}`), &status); err == nil {
+		t.Error("expected a subtype mismatch error")
+	}
+	if _, err := UnmarshalMessage([]byte(`[1]`)); err == nil {
+		t.Error("expected an error for a non-object")
+	}
+	if _, err := UnmarshalMessage([]byte(`null`)); err == nil {
+		t.Error("expected an error for null")
+	}
+
+	// A system message of any subtype decodes into SystemMessage
+	var system SystemMessage
+	if err := json.Unmarshal([]byte(`{"type":"system","subtype":"status","session_id":"s1"}`), &system); err != nil {
+		t.Fatal(err)
+	}
+	if system.Subtype != "status" || system.SessionID != "s1" {
+		t.Errorf("unexpected system message: %+v", system)
+	}
+}
+
+func TestContentBlockJSONRoundTrip(t *testing.T) {
+	blocks := []ContentBlock{
+		&TextBlock{TextContent: "hi"},
+		&ThinkingBlock{ThinkingContent: "hmm", Signature: "sig"},
+		&ToolUseBlock{ID: "t1", Name: "Bash", ToolInput: map[string]any{"command": "ls"}},
+		&ToolResultBlock{ToolUseID: "t1", ResultContent: "done", ContentBlocks: []ContentBlock{&TextBlock{TextContent: "done"}}},
+		&ImageBlock{Source: BlockSource{Type: "url", URL: "https://example.com/a.png"}},
+		&DocumentBlock{Source: BlockSource{Type: "file", FileID: "f1"}, Title: "T", Citations: &CitationsConfig{Enabled: true}},
+		&RedactedThinkingBlock{Data: "enc"},
+		&ServerToolUseBlock{ID: "s1", Name: "web_search", ToolInput: map[string]any{"query": "go"}},
+		&WebSearchToolResultBlock{ToolUseID: "s1", ErrorCode: "unavailable"},
+		&ServerToolResultBlock{Kind: "web_fetch_tool_result", ToolUseID: "s2", Content: map[string]any{"url": "https://go.dev"}},
+		&MCPToolUseBlock{ID: "m1", Name: "add", ServerName: "calc", ToolInput: map[string]any{}},
+		&MCPToolResultBlock{ToolUseID: "m1", ContentBlocks: []ContentBlock{&TextBlock{TextContent: "3"}}, IsError: true},
+		&SearchResultBlock{Source: "kb", Title: "T", ContentBlocks: []ContentBlock{&TextBlock{TextContent: "x"}}},
+		&ContainerUploadBlock{FileID: "f2"},
+		&UnknownBlock{Kind: "future_block", Raw: json.RawMessage(`{"payload":1,"type":"future_block"}`)},
+	}
+	for _, block := range blocks {
+		data, err := json.Marshal(block)
+		if err != nil {
+			t.Fatalf("Marshal(%T) failed: %v", block, err)
+		}
+		target := reflect.New(reflect.TypeOf(block).Elem()).Interface()
+		if err := json.Unmarshal(data, target); err != nil {
+			t.Fatalf("Unmarshal into %T failed: %v", target, err)
+		}
+		if !reflect.DeepEqual(target, block) {
+			t.Errorf("%T changed after a round trip through %s:\ngot:  %#v\nwant: %#v", block, data, target, block)
+		}
+	}
+
+	var text TextBlock
+	if err := json.Unmarshal([]byte(`{"type":"thinking","thinking":"x"}`), &text); err == nil {
+		t.Error("expected a block type mismatch error")
+	}
+}
+
+func TestToolResultBlockJSON_Content(t *testing.T) {
+	tests := []struct {
+		block *ToolResultBlock
+		want  string
+	}{
+		// Strings that happen to be valid JSON stay strings
+		{&ToolResultBlock{ToolUseID: "t1", ResultContent: "123"}, `"123"`},
+		{&ToolResultBlock{ToolUseID: "t1", ResultContent: `[{"type":"text","text":"x"}]`}, `"[{\"type\":\"text\",\"text\":\"x\"}]"`},
+		{&ToolResultBlock{ToolUseID: "t1", ContentBlocks: []ContentBlock{&TextBlock{TextContent: "x"}}}, `[{"type":"text","text":"x"}]`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.block)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var decoded struct {
+			Content json.RawMessage `json:"content"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded.Content) != tt.want {
+			t.Errorf("content of %+v = %s, want %s", tt.block, decoded.Content, tt.want)
+		}
+	}
+
+	// Parsed string content that looks like JSON round-trips as a string
+	var block ToolResultBlock
+	if err := json.Unmarshal([]byte(`{"type":"tool_result","tool_use_id":"t1","content":"123"}`), &block); err != nil {
+		t.Fatal(err)
+	}
+	block.ContentBlocks = nil
+	if data, _ := json.Marshal(&block); !strings.Contains(string(data), `"content":"123"`) {
+		t.Errorf("expected string content, got %s", data)
+	}
+}
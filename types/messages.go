@@ -52,9 +52,18 @@ func (b *ToolUseBlock) Input() map[string]any { return b.ToolInput }
 
 // ToolResultBlock contains the result of a tool execution.
 type ToolResultBlock struct {
-	ToolUseID     string `json:"tool_use_id"`
+	ToolUseID string `json:"tool_use_id"`
+	// ResultContent is the result as a string. Structured results are kept
+	// as their JSON encoding; ContentBlocks holds them parsed.
 	ResultContent string `json:"content,omitempty"`
-	IsError       bool   `json:"is_error,omitempty"`
+	// ContentBlocks holds the result as typed blocks, such as text and images.
+	// A string result is a single TextBlock.
+	ContentBlocks []ContentBlock `json:"-"`
+	IsError       bool           `json:"is_error,omitempty"`
+
+	// structured is set when ResultContent was parsed from non-string
+	// content, so MarshalJSON writes it back as JSON rather than as a string.
+	structured bool
 }
 
 func (b *ToolResultBlock) BlockType() string { return "tool_result" }
@@ -63,17 +72,181 @@ func (b *ToolResultBlock) Type() string      { return "tool_result" }
 // Content returns the result content as string.
 func (b *ToolResultBlock) Content() string { return b.ResultContent }
 
-// ParseContentBlock parses a raw JSON map into a ContentBlock.
-func ParseContentBlock(raw map[string]any) (ContentBlock, error) {
-	blockType, ok := raw["type"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid 'type' field")
+// Text returns the text of the result's text blocks concatenated.
+func (b *ToolResultBlock) Text() string {
+	if b.ContentBlocks == nil {
+		return b.ResultContent
 	}
+	var result string
+	for _, block := range b.ContentBlocks {
+		if textBlock, ok := block.(*TextBlock); ok {
+			result += textBlock.TextContent
+		}
+	}
+	return result
+}
 
-	data, err := json.Marshal(raw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal block: %w", err)
+// BlockSource holds or references the data of an image or document block.
+type BlockSource struct {
+	Type      string `json:"type"`                 // "base64", "url", "file", "text" or "content"
+	MediaType string `json:"media_type,omitempty"` // For example "image/png" or "application/pdf"
+	Data      string `json:"data,omitempty"`       // Base64 data, or the text of a "text" source
+	URL       string `json:"url,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+	// Content holds the blocks of a "content" document source.
+	Content []ContentBlock `json:"content,omitempty"`
+}
+
+// ImageBlock contains an image.
+type ImageBlock struct {
+	Source BlockSource `json:"source"`
+}
+
+func (b *ImageBlock) BlockType() string { return "image" }
+func (b *ImageBlock) Type() string      { return "image" }
+
+// CitationsConfig enables citations for a document.
+type CitationsConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DocumentBlock contains a document such as a PDF or plain text.
+type DocumentBlock struct {
+	Source    BlockSource      `json:"source"`
+	Title     string           `json:"title,omitempty"`
+	Context   string           `json:"context,omitempty"`
+	Citations *CitationsConfig `json:"citations,omitempty"`
+}
+
+func (b *DocumentBlock) BlockType() string { return "document" }
+func (b *DocumentBlock) Type() string      { return "document" }
+
+// RedactedThinkingBlock contains thinking that was encrypted for safety
+// reasons. It must be passed back unchanged in multi-turn conversations.
+type RedactedThinkingBlock struct {
+	Data string `json:"data"`
+}
+
+func (b *RedactedThinkingBlock) BlockType() string { return "redacted_thinking" }
+func (b *RedactedThinkingBlock) Type() string      { return "redacted_thinking" }
+
+// ServerToolUseBlock represents a call to a tool that runs on the API
+// server, such as web_search or code_execution.
+type ServerToolUseBlock struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	ToolInput map[string]any `json:"input"`
+}
+
+func (b *ServerToolUseBlock) BlockType() string { return "server_tool_use" }
+func (b *ServerToolUseBlock) Type() string      { return "server_tool_use" }
+
+// Input returns the tool input.
+func (b *ServerToolUseBlock) Input() map[string]any { return b.ToolInput }
+
+// WebSearchResult is one result of a web search.
+type WebSearchResult struct {
+	URL              string `json:"url"`
+	Title            string `json:"title"`
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+	PageAge          string `json:"page_age,omitempty"`
+}
+
+// WebSearchToolResultBlock contains the results of a web_search server tool
+// call, or the error code if the search failed.
+type WebSearchToolResultBlock struct {
+	ToolUseID string            `json:"tool_use_id"`
+	Results   []WebSearchResult `json:"results,omitempty"`
+	ErrorCode string            `json:"error_code,omitempty"`
+}
+
+func (b *WebSearchToolResultBlock) BlockType() string { return "web_search_tool_result" }
+func (b *WebSearchToolResultBlock) Type() string      { return "web_search_tool_result" }
+
+// ServerToolResultBlock contains the result of another server tool, such as
+// web_fetch_tool_result or code_execution_tool_result. Kind holds the block
+// type and Content the tool-specific result.
+type ServerToolResultBlock struct {
+	Kind      string `json:"type"`
+	ToolUseID string `json:"tool_use_id"`
+	Content   any    `json:"content,omitempty"`
+}
+
+func (b *ServerToolResultBlock) BlockType() string { return b.Kind }
+func (b *ServerToolResultBlock) Type() string      { return b.Kind }
+
+// serverToolResultTypes lists the block types parsed as ServerToolResultBlock.
+var serverToolResultTypes = map[string]bool{
+	"web_fetch_tool_result":                  true,
+	"code_execution_tool_result":             true,
+	"bash_code_execution_tool_result":        true,
+	"text_editor_code_execution_tool_result": true,
+	"tool_search_tool_result":                true,
+}
+
+// MCPToolUseBlock represents a call to a tool on an MCP server connected
+// through the API's MCP connector.
+type MCPToolUseBlock struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	ServerName string         `json:"server_name"`
+	ToolInput  map[string]any `json:"input"`
+}
+
+func (b *MCPToolUseBlock) BlockType() string { return "mcp_tool_use" }
+func (b *MCPToolUseBlock) Type() string      { return "mcp_tool_use" }
+
+// Input returns the tool input.
+func (b *MCPToolUseBlock) Input() map[string]any { return b.ToolInput }
+
+// MCPToolResultBlock contains the result of an MCPToolUseBlock call.
+type MCPToolResultBlock struct {
+	ToolUseID     string         `json:"tool_use_id"`
+	ContentBlocks []ContentBlock `json:"content,omitempty"`
+	IsError       bool           `json:"is_error,omitempty"`
+}
+
+func (b *MCPToolResultBlock) BlockType() string { return "mcp_tool_result" }
+func (b *MCPToolResultBlock) Type() string      { return "mcp_tool_result" }
+
+// SearchResultBlock contains a search result that Claude can cite.
+type SearchResultBlock struct {
+	Source        string           `json:"source"`
+	Title         string           `json:"title"`
+	ContentBlocks []ContentBlock   `json:"content"`
+	Citations     *CitationsConfig `json:"citations,omitempty"`
+}
+
+func (b *SearchResultBlock) BlockType() string { return "search_result" }
+func (b *SearchResultBlock) Type() string      { return "search_result" }
+
+// ContainerUploadBlock references a file uploaded to the code execution container.
+type ContainerUploadBlock struct {
+	FileID string `json:"file_id"`
+}
+
+func (b *ContainerUploadBlock) BlockType() string { return "container_upload" }
+func (b *ContainerUploadBlock) Type() string      { return "container_upload" }
+
+// UnknownBlock preserves a content block of a type the SDK does not know, so
+// that new block types do not break parsing. Raw holds the block's JSON.
+type UnknownBlock struct {
+	Kind string          `json:"type"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+func (b *UnknownBlock) BlockType() string { return b.Kind }
+func (b *UnknownBlock) Type() string      { return b.Kind }
+
+// parseContentBlockJSON parses a content block from its JSON encoding.
+func parseContentBlockJSON(data []byte) (ContentBlock, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.Type == "" {
+		return nil, fmt.Errorf("missing or invalid 'type' field")
 	}
+	blockType := header.Type
 
 	switch blockType {
 	case "text":
@@ -90,23 +263,142 @@ func ParseContentBlock(raw map[string]any) (ContentBlock, error) {
 		}
 		return &ThinkingBlock{ThinkingContent: block.Thinking, Signature: block.Signature}, nil
 
-	case "tool_use":
+	case "redacted_thinking":
+		var block redactedThinkingBlockJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse redacted_thinking block: %w", err)
+		}
+		return &RedactedThinkingBlock{Data: block.Data}, nil
+
+	case "tool_use", "server_tool_use", "mcp_tool_use":
 		var block toolUseBlockJSON
 		if err := json.Unmarshal(data, &block); err != nil {
-			return nil, fmt.Errorf("failed to parse tool_use block: %w", err)
+			return nil, fmt.Errorf("failed to parse %s block: %w", blockType, err)
+		}
+		switch blockType {
+		case "server_tool_use":
+			return &ServerToolUseBlock{ID: block.ID, Name: block.Name, ToolInput: block.Input}, nil
+		case "mcp_tool_use":
+			return &MCPToolUseBlock{ID: block.ID, Name: block.Name, ServerName: block.ServerName, ToolInput: block.Input}, nil
 		}
 		return &ToolUseBlock{ID: block.ID, Name: block.Name, ToolInput: block.Input}, nil
 
-	case "tool_result":
+	case "tool_result", "mcp_tool_result":
 		var block toolResultBlockJSON
 		if err := json.Unmarshal(data, &block); err != nil {
-			return nil, fmt.Errorf("failed to parse tool_result block: %w", err)
+			return nil, fmt.Errorf("failed to parse %s block: %w", blockType, err)
+		}
+		text, blocks, err := parseBlockContent(block.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s content: %w", blockType, err)
 		}
-		return &ToolResultBlock{ToolUseID: block.ToolUseID, ResultContent: block.Content, IsError: block.IsError}, nil
+		if blockType == "mcp_tool_result" {
+			return &MCPToolResultBlock{ToolUseID: block.ToolUseID, ContentBlocks: blocks, IsError: block.IsError}, nil
+		}
+		structured := len(block.Content) > 0 && block.Content[0] == '['
+		return &ToolResultBlock{ToolUseID: block.ToolUseID, ResultContent: text, ContentBlocks: blocks, IsError: block.IsError, structured: structured}, nil
 
-	default:
-		return nil, fmt.Errorf("unknown block type: %s", blockType)
+	case "image":
+		var block mediaBlockJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse image block: %w", err)
+		}
+		source, err := block.Source.parse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse image source: %w", err)
+		}
+		return &ImageBlock{Source: source}, nil
+
+	case "document":
+		var block mediaBlockJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse document block: %w", err)
+		}
+		source, err := block.Source.parse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document source: %w", err)
+		}
+		return &DocumentBlock{Source: source, Title: block.Title, Context: block.Context, Citations: block.Citations}, nil
+
+	case "web_search_tool_result":
+		var block serverToolResultJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse web_search_tool_result block: %w", err)
+		}
+		result := &WebSearchToolResultBlock{ToolUseID: block.ToolUseID}
+		var failure struct {
+			ErrorCode string `json:"error_code"`
+		}
+		if err := json.Unmarshal(block.Content, &result.Results); err != nil {
+			if err := json.Unmarshal(block.Content, &failure); err != nil {
+				return nil, fmt.Errorf("failed to parse web_search_tool_result content: %w", err)
+			}
+			result.ErrorCode = failure.ErrorCode
+		}
+		return result, nil
+
+	case "search_result":
+		var block searchResultBlockJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse search_result block: %w", err)
+		}
+		_, blocks, err := parseBlockContent(block.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse search_result content: %w", err)
+		}
+		return &SearchResultBlock{Source: block.Source, Title: block.Title, ContentBlocks: blocks, Citations: block.Citations}, nil
+
+	case "container_upload":
+		var block containerUploadBlockJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse container_upload block: %w", err)
+		}
+		return &ContainerUploadBlock{FileID: block.FileID}, nil
+	}
+
+	if serverToolResultTypes[blockType] {
+		var block serverToolResultJSON
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("failed to parse %s block: %w", blockType, err)
+		}
+		result := &ServerToolResultBlock{Kind: blockType, ToolUseID: block.ToolUseID}
+		if len(block.Content) > 0 {
+			if err := json.Unmarshal(block.Content, &result.Content); err != nil {
+				return nil, fmt.Errorf("failed to parse %s content: %w", blockType, err)
+			}
+		}
+		return result, nil
 	}
+
+	return &UnknownBlock{Kind: blockType, Raw: append(json.RawMessage(nil), data...)}, nil
+}
+
+// parseBlockContent parses content that is either a string or an array of
+// blocks. It returns the content as a string, with arrays kept as JSON, and
+// as blocks, with a string as a single TextBlock.
+func parseBlockContent(content json.RawMessage) (string, []ContentBlock, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return "", nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text, []ContentBlock{&TextBlock{TextContent: text}}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(content, &items); err != nil {
+		return "", nil, err
+	}
+	blocks := make([]ContentBlock, 0, len(items))
+	for _, item := range items {
+		block, err := parseContentBlockJSON(item)
+		if err != nil {
+			return "", nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return string(content), blocks, nil
 }
 
 // JSON unmarshaling helper types
@@ -119,16 +411,60 @@ type thinkingBlockJSON struct {
 	Signature string `json:"signature"`
 }
 
+type redactedThinkingBlockJSON struct {
+	Data string `json:"data"`
+}
+
+type containerUploadBlockJSON struct {
+	FileID string `json:"file_id"`
+}
+
 type toolUseBlockJSON struct {
-	ID    string         `json:"id"`
-	Name  string         `json:"name"`
-	Input map[string]any `json:"input"`
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	ServerName string         `json:"server_name"`
+	Input      map[string]any `json:"input"`
 }
 
 type toolResultBlockJSON struct {
-	ToolUseID string `json:"tool_use_id"`
-	Content   string `json:"content"`
-	IsError   bool   `json:"is_error"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+type blockSourceJSON struct {
+	Type      string          `json:"type"`
+	MediaType string          `json:"media_type"`
+	Data      string          `json:"data"`
+	URL       string          `json:"url"`
+	FileID    string          `json:"file_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+func (s blockSourceJSON) parse() (BlockSource, error) {
+	source := BlockSource{Type: s.Type, MediaType: s.MediaType, Data: s.Data, URL: s.URL, FileID: s.FileID}
+	_, blocks, err := parseBlockContent(s.Content)
+	source.Content = blocks
+	return source, err
+}
+
+type mediaBlockJSON struct {
+	Source    blockSourceJSON  `json:"source"`
+	Title     string           `json:"title"`
+	Context   string           `json:"context"`
+	Citations *CitationsConfig `json:"citations"`
+}
+
+type serverToolResultJSON struct {
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+type searchResultBlockJSON struct {
+	Source    string           `json:"source"`
+	Title     string           `json:"title"`
+	Content   json.RawMessage  `json:"content"`
+	Citations *CitationsConfig `json:"citations"`
 }
 
 // Message represents a message in the conversation.
@@ -136,6 +472,35 @@ type Message interface {
 	MessageType() string
 }
 
+// RawJSONMessage is implemented by the SDK's message types, which can carry
+// the JSON they were parsed from. See Options.RetainRawJSON.
+type RawJSONMessage interface {
+	Message
+	RawJSON() json.RawMessage
+	SetRawJSON(data json.RawMessage)
+}
+
+// rawJSON is embedded in message types to hold their original JSON.
+type rawJSON struct {
+	raw json.RawMessage
+}
+
+// RawJSON returns the JSON the message was parsed from, or nil if it was not
+// retained.
+func (r *rawJSON) RawJSON() json.RawMessage { return r.raw }
+
+// SetRawJSON sets the JSON returned by RawJSON.
+func (r *rawJSON) SetRawJSON(data json.RawMessage) { r.raw = data }
+
+// MessageRawJSON returns the JSON msg was parsed from, or nil if it was not
+// retained or msg does not carry it.
+func MessageRawJSON(msg Message) json.RawMessage {
+	if m, ok := msg.(RawJSONMessage); ok {
+		return m.RawJSON()
+	}
+	return nil
+}
+
 // UserMessage represents a user's message.
 type UserMessage struct {
 	Content         []ContentBlock `json:"content"`
@@ -146,6 +511,8 @@ type UserMessage struct {
 	IsSynthetic     bool           `json:"isSynthetic,omitempty"`
 	IsReplay        bool           `json:"isReplay,omitempty"`
 	ToolUseResult   map[string]any `json:"tool_use_result,omitempty"`
+
+	rawJSON
 }
 
 func (m *UserMessage) MessageType() string { return "user" }
@@ -175,13 +542,21 @@ const (
 
 // AssistantMessage represents Claude's response.
 type AssistantMessage struct {
-	Content         []ContentBlock         `json:"content"`
-	Model           string                 `json:"model"`
-	StopReason      string                 `json:"stop_reason,omitempty"`
+	Content    []ContentBlock `json:"content"`
+	Model      string         `json:"model"`
+	StopReason string         `json:"stop_reason,omitempty"`
+	// MessageID is the API message ID, shared by the messages the CLI sends
+	// for each content block of one response.
+	MessageID string `json:"message_id,omitempty"`
+	// Usage is the token usage of the whole API response, repeated on each
+	// of its messages.
+	Usage           *Usage                 `json:"usage,omitempty"`
 	UUID            string                 `json:"uuid,omitempty"`
 	SessionID       string                 `json:"session_id,omitempty"`
 	ParentToolUseID *string                `json:"parent_tool_use_id,omitempty"`
 	Error           *AssistantMessageError `json:"error,omitempty"`
+
+	rawJSON
 }
 
 func (m *AssistantMessage) MessageType() string { return "assistant" }
@@ -240,27 +615,97 @@ type SystemMessage struct {
 	SessionID string         `json:"session_id,omitempty"`
 	Version   string         `json:"version,omitempty"`
 	Data      map[string]any `json:"data,omitempty"`
+
+	rawJSON
 }
 
 func (m *SystemMessage) MessageType() string { return "system" }
 
+// SystemInitMessage is the system message with subtype "init" that the CLI
+// sends when it starts processing a query. It describes the session's
+// configuration. The embedded SystemMessage keeps the raw payload in Data.
+type SystemInitMessage struct {
+	SystemMessage
+	ClaudeCodeVersion string                `json:"claude_code_version,omitempty"`
+	Cwd               string                `json:"cwd,omitempty"`
+	Model             string                `json:"model,omitempty"`
+	PermissionMode    PermissionMode        `json:"permissionMode,omitempty"`
+	APIKeySource      string                `json:"apiKeySource,omitempty"`
+	OutputStyle       string                `json:"output_style,omitempty"`
+	Tools             []string              `json:"tools,omitempty"`
+	MCPServers        []SystemInitMCPServer `json:"mcp_servers,omitempty"`
+	SlashCommands     []string              `json:"slash_commands,omitempty"`
+	Agents            []string              `json:"agents,omitempty"`
+	Skills            []string              `json:"skills,omitempty"`
+	Betas             []string              `json:"betas,omitempty"`
+	Plugins           []SystemInitPlugin    `json:"plugins,omitempty"`
+}
+
+// SystemInitMCPServer is an MCP server's connection status at init.
+type SystemInitMCPServer struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "connected", "failed", "needs-auth" or "pending"
+}
+
+// SystemInitPlugin is a plugin loaded for the session.
+type SystemInitPlugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// HasTool reports whether the named tool is available in the session.
+func (m *SystemInitMessage) HasTool(name string) bool {
+	for _, tool := range m.Tools {
+		if tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MCPServer returns the status of the named MCP server.
+func (m *SystemInitMessage) MCPServer(name string) (SystemInitMCPServer, bool) {
+	for _, server := range m.MCPServers {
+		if server.Name == name {
+			return server, true
+		}
+	}
+	return SystemInitMCPServer{}, false
+}
+
+// UnavailableMCPServers returns the MCP servers whose status is not "connected".
+func (m *SystemInitMessage) UnavailableMCPServers() []SystemInitMCPServer {
+	var unavailable []SystemInitMCPServer
+	for _, server := range m.MCPServers {
+		if server.Status != "connected" {
+			unavailable = append(unavailable, server)
+		}
+	}
+	return unavailable
+}
+
 // ResultMessage represents the final result of a query.
 type ResultMessage struct {
-	Subtype           string                `json:"subtype"`
-	UUID              string                `json:"uuid,omitempty"`
-	DurationMS        int                   `json:"duration_ms"`
-	DurationAPI       int                   `json:"duration_api_ms"`
-	IsError           bool                  `json:"is_error"`
-	NumTurns          int                   `json:"num_turns"`
-	SessionID         string                `json:"session_id"`
-	StopReason        *string               `json:"stop_reason,omitempty"`
-	TotalCostUSD      *float64              `json:"total_cost_usd,omitempty"`
-	Usage             map[string]any        `json:"usage,omitempty"`
+	Subtype      string         `json:"subtype"`
+	UUID         string         `json:"uuid,omitempty"`
+	DurationMS   int            `json:"duration_ms"`
+	DurationAPI  int            `json:"duration_api_ms"`
+	IsError      bool           `json:"is_error"`
+	NumTurns     int            `json:"num_turns"`
+	SessionID    string         `json:"session_id"`
+	StopReason   *string        `json:"stop_reason,omitempty"`
+	TotalCostUSD *float64       `json:"total_cost_usd,omitempty"`
+	Usage        map[string]any `json:"usage,omitempty"`
+	// TokenUsage is Usage typed. The parser fills both; Usage keeps fields
+	// the Usage struct does not model.
+	TokenUsage        *Usage                `json:"-"`
 	ModelUsage        map[string]ModelUsage `json:"modelUsage,omitempty"`
 	PermissionDenials []PermissionDenial    `json:"permission_denials,omitempty"`
 	Errors            []string              `json:"errors,omitempty"`
 	Result            *string               `json:"result,omitempty"`
 	StructuredOutput  any                   `json:"structured_output,omitempty"`
+
+	rawJSON
 }
 
 // ModelUsage tracks per-model token and cost usage in result payloads.
@@ -306,6 +751,8 @@ type StreamEvent struct {
 	Index           *int           `json:"index,omitempty"`
 	Delta           map[string]any `json:"delta,omitempty"`
 	ParentToolUseID *string        `json:"parent_tool_use_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *StreamEvent) MessageType() string { return "stream_event" }
@@ -317,6 +764,8 @@ type AuthStatusMessage struct {
 	Error            string   `json:"error,omitempty"`
 	UUID             string   `json:"uuid,omitempty"`
 	SessionID        string   `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *AuthStatusMessage) MessageType() string { return "auth_status" }
@@ -329,6 +778,8 @@ type ToolProgressMessage struct {
 	ElapsedTimeSeconds float64 `json:"elapsed_time_seconds,omitempty"`
 	UUID               string  `json:"uuid,omitempty"`
 	SessionID          string  `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *ToolProgressMessage) MessageType() string { return "tool_progress" }
@@ -339,6 +790,8 @@ type ToolUseSummaryMessage struct {
 	PrecedingToolUseIDs []string `json:"preceding_tool_use_ids,omitempty"`
 	UUID                string   `json:"uuid,omitempty"`
 	SessionID           string   `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *ToolUseSummaryMessage) MessageType() string { return "tool_use_summary" }
@@ -350,10 +803,26 @@ type RateLimitEvent struct {
 	RetryAfterSeconds *float64       `json:"retry_after_seconds,omitempty"`
 	ResetsAt          string         `json:"resets_at,omitempty"`
 	Data              map[string]any `json:"data,omitempty"`
+
+	rawJSON
 }
 
 func (m *RateLimitEvent) MessageType() string { return "rate_limit_event" }
 
+// UnknownMessage carries a message of a type the SDK does not know, such as
+// one added by a newer CLI, so that it reaches the message stream instead of
+// failing to parse. Raw holds the message as received.
+type UnknownMessage struct {
+	Type      string         `json:"type"`
+	UUID      string         `json:"uuid,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Raw       map[string]any `json:"-"`
+
+	rawJSON
+}
+
+func (m *UnknownMessage) MessageType() string { return m.Type }
+
 // TaskNotificationMessage reports background task completion/failure.
 type TaskNotificationMessage struct {
 	Subtype    string `json:"subtype"`
@@ -363,6 +832,8 @@ type TaskNotificationMessage struct {
 	Summary    string `json:"summary,omitempty"`
 	UUID       string `json:"uuid,omitempty"`
 	SessionID  string `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *TaskNotificationMessage) MessageType() string { return "system" }
@@ -387,6 +858,8 @@ type FilesPersistedMessage struct {
 	ProcessedAt string                  `json:"processed_at,omitempty"`
 	UUID        string                  `json:"uuid,omitempty"`
 	SessionID   string                  `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *FilesPersistedMessage) MessageType() string { return "system" }
@@ -399,6 +872,8 @@ type HookStartedMessage struct {
 	HookEvent string `json:"hook_event"`
 	UUID      string `json:"uuid,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *HookStartedMessage) MessageType() string { return "system" }
@@ -414,6 +889,8 @@ type HookProgressMessage struct {
 	Output    string `json:"output,omitempty"`
 	UUID      string `json:"uuid,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *HookProgressMessage) MessageType() string { return "system" }
@@ -431,6 +908,8 @@ type HookResponseMessage struct {
 	Outcome   string `json:"outcome,omitempty"`
 	UUID      string `json:"uuid,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *HookResponseMessage) MessageType() string { return "system" }
@@ -442,6 +921,8 @@ type StatusMessage struct {
 	PermissionMode PermissionMode `json:"permissionMode,omitempty"`
 	UUID           string         `json:"uuid,omitempty"`
 	SessionID      string         `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *StatusMessage) MessageType() string { return "system" }
@@ -458,6 +939,8 @@ type CompactBoundaryMessage struct {
 	CompactMetadata CompactMetadata `json:"compact_metadata"`
 	UUID            string          `json:"uuid,omitempty"`
 	SessionID       string          `json:"session_id,omitempty"`
+
+	rawJSON
 }
 
 func (m *CompactBoundaryMessage) MessageType() string { return "system" }
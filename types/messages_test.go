@@ -5,6 +5,7 @@ package types
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -167,7 +168,8 @@ func TestParseContentBlock(t *testing.T) {
 			input: map[string]any{
 				"type": "unknown_type",
 			},
-			expectError: true,
+			expectError: false,
+			expectType:  "unknown_type",
 		},
 	}
 
@@ -193,6 +195,141 @@ func TestParseContentBlock(t *testing.T) {
 	}
 }
 
+// TestParseContentBlock_ExtendedTypes tests the block types beyond text and tools.
+func TestParseContentBlock_ExtendedTypes(t *testing.T) {
+	t.Run("document with content source", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type":      "document",
+			"title":     "Spec",
+			"citations": map[string]any{"enabled": true},
+			"source": map[string]any{
+				"type":    "content",
+				"content": []any{map[string]any{"type": "text", "text": "section 1"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, ok := block.(*DocumentBlock)
+		if !ok {
+			t.Fatalf("expected *DocumentBlock, got %T", block)
+		}
+		if doc.Title != "Spec" || doc.Citations == nil || !doc.Citations.Enabled || doc.Source.Type != "content" {
+			t.Errorf("unexpected document: %+v", doc)
+		}
+		if len(doc.Source.Content) != 1 || doc.Source.Content[0].(*TextBlock).TextContent != "section 1" {
+			t.Errorf("unexpected document content: %v", doc.Source.Content)
+		}
+	})
+
+	t.Run("pdf document", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type":   "document",
+			"source": map[string]any{"type": "base64", "media_type": "application/pdf", "data": "JVBERi0="},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := BlockSource{Type: "base64", MediaType: "application/pdf", Data: "JVBERi0="}
+		if got := block.(*DocumentBlock).Source; got.Type != want.Type || got.MediaType != want.MediaType || got.Data != want.Data {
+			t.Errorf("unexpected source: %+v", got)
+		}
+	})
+
+	t.Run("tool_result with sub-blocks", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type":        "tool_result",
+			"tool_use_id": "tool1",
+			"content": []any{
+				map[string]any{"type": "text", "text": "see image"},
+				map[string]any{"type": "image", "source": map[string]any{"type": "url", "url": "https://example.com/a.png"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		result := block.(*ToolResultBlock)
+		if len(result.ContentBlocks) != 2 || result.Text() != "see image" {
+			t.Fatalf("unexpected tool result: %+v", result)
+		}
+		if image := result.ContentBlocks[1].(*ImageBlock); image.Source.URL != "https://example.com/a.png" {
+			t.Errorf("unexpected image source: %+v", image.Source)
+		}
+	})
+
+	t.Run("mcp tool use and result", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type": "mcp_tool_use", "id": "mcptoolu_1", "name": "echo", "server_name": "tools",
+			"input": map[string]any{"text": "hi"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if use := block.(*MCPToolUseBlock); use.ServerName != "tools" || use.Input()["text"] != "hi" {
+			t.Errorf("unexpected mcp_tool_use: %+v", use)
+		}
+
+		block, err = ParseContentBlock(map[string]any{
+			"type": "mcp_tool_result", "tool_use_id": "mcptoolu_1", "is_error": true,
+			"content": []any{map[string]any{"type": "text", "text": "failed"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := block.(*MCPToolResultBlock); !result.IsError || len(result.ContentBlocks) != 1 {
+			t.Errorf("unexpected mcp_tool_result: %+v", result)
+		}
+	})
+
+	t.Run("web search error", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1",
+			"content": map[string]any{"type": "web_search_tool_result_error", "error_code": "max_uses_exceeded"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := block.(*WebSearchToolResultBlock); result.ErrorCode != "max_uses_exceeded" || result.Results != nil {
+			t.Errorf("unexpected web search result: %+v", result)
+		}
+	})
+
+	t.Run("search result and container upload", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{
+			"type": "search_result", "source": "https://kb/1", "title": "KB",
+			"content": []any{map[string]any{"type": "text", "text": "answer"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := block.(*SearchResultBlock); result.Source != "https://kb/1" || len(result.ContentBlocks) != 1 {
+			t.Errorf("unexpected search_result: %+v", result)
+		}
+
+		block, err = ParseContentBlock(map[string]any{"type": "container_upload", "file_id": "file_1"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if upload := block.(*ContainerUploadBlock); upload.FileID != "file_1" {
+			t.Errorf("unexpected container_upload: %+v", upload)
+		}
+	})
+
+	t.Run("unknown block keeps raw JSON", func(t *testing.T) {
+		block, err := ParseContentBlock(map[string]any{"type": "hologram", "frames": float64(3)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		unknown, ok := block.(*UnknownBlock)
+		if !ok {
+			t.Fatalf("expected *UnknownBlock, got %T", block)
+		}
+		if unknown.BlockType() != "hologram" || string(unknown.Raw) != `{"frames":3,"type":"hologram"}` {
+			t.Errorf("unexpected unknown block: %s %s", unknown.BlockType(), unknown.Raw)
+		}
+	})
+}
+
 // TestUserMessage tests UserMessage methods.
 func TestUserMessage(t *testing.T) {
 	t.Run("MessageType returns user", func(t *testing.T) {
@@ -495,3 +632,20 @@ func TestPermissionResult(t *testing.T) {
 		}
 	})
 }
+
+func TestMessageRawJSON(t *testing.T) {
+	raw := json.RawMessage(`{"type":"system","subtype":"init"}`)
+	messages := []Message{&AssistantMessage{}, &ResultMessage{}, &SystemInitMessage{}, &UnknownMessage{Type: "future"}}
+	for _, msg := range messages {
+		if MessageRawJSON(msg) != nil {
+			t.Errorf("%T: expected no raw JSON before it is set", msg)
+		}
+		msg.(RawJSONMessage).SetRawJSON(raw)
+		if got := MessageRawJSON(msg); string(got) != string(raw) {
+			t.Errorf("%T: got raw JSON %s, want %s", msg, got, raw)
+		}
+		if data, err := json.Marshal(msg); err != nil || strings.Contains(string(data), "raw") {
+			t.Errorf("%T: raw JSON should not be marshalled, got %s, %v", msg, data, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package types
 import (
 	"fmt"
 	"sync"
+	"time"
 )
 
 // PermissionMode controls how tool permissions are handled.
@@ -20,6 +21,28 @@ const (
 	PermissionDontAsk  PermissionMode = "dontAsk"
 )
 
+// PermissionFallbackBehavior selects how permission requests are answered
+// when no CanUseTool callback is configured.
+type PermissionFallbackBehavior string
+
+const (
+	// PermissionFallbackAllow allows every unconfigured permission request (legacy default).
+	PermissionFallbackAllow PermissionFallbackBehavior = "allow"
+	// PermissionFallbackDeny denies every unconfigured permission request.
+	PermissionFallbackDeny PermissionFallbackBehavior = "deny"
+)
+
+// DefaultPermissionFallbackMessage is sent with fallback denials when no message is configured.
+const DefaultPermissionFallbackMessage = "tool use denied: no permission callback configured"
+
+// PermissionFallback is the policy applied to permission requests when CanUseTool is unset.
+// Any Behavior other than PermissionFallbackAllow denies.
+type PermissionFallback struct {
+	Behavior PermissionFallbackBehavior `json:"behavior"`
+	// Message is returned to the CLI with denials. Empty uses DefaultPermissionFallbackMessage.
+	Message string `json:"message,omitempty"`
+}
+
 // SettingSource specifies where settings come from.
 type SettingSource string
 
@@ -100,6 +123,52 @@ type SandboxSettings struct {
 	Ripgrep                   *SandboxRipgrepConfig    `json:"ripgrep,omitempty"`
 }
 
+// ResourceLimits caps the resources of the CLI process. Zero fields leave the
+// corresponding limit as inherited. Limits are supported on Linux only.
+type ResourceLimits struct {
+	// AddressSpace limits virtual memory in bytes (RLIMIT_AS).
+	AddressSpace uint64 `json:"address_space,omitempty"`
+	// CPUTime limits CPU time, rounded up to whole seconds (RLIMIT_CPU). The CLI
+	// receives SIGXCPU at the limit and SIGKILL one second later.
+	CPUTime time.Duration `json:"cpu_time,omitempty"`
+	// OpenFiles limits open file descriptors (RLIMIT_NOFILE).
+	OpenFiles uint64 `json:"open_files,omitempty"`
+	// Nice sets the scheduling priority of the CLI's process group, from -20
+	// (highest) to 19 (lowest). Negative values require CAP_SYS_NICE.
+	Nice int `json:"nice,omitempty"`
+	// Cgroup places the CLI in an existing cgroup v2 directory, such as
+	// "/sys/fs/cgroup/agents/session-1", whose controllers (memory.max,
+	// pids.max, cpu.max) then apply to it and everything it starts.
+	Cgroup string `json:"cgroup,omitempty"`
+}
+
+// CLIVersionPolicy bounds the CLI versions Connect accepts. Versions are
+// compared numerically component by component; a bound with fewer components
+// covers every release under it, so a MaximumTested of "2.1" accepts 2.1.40.
+type CLIVersionPolicy struct {
+	// Minimum rejects older versions with *CLIVersionError. Empty means MinimumCLIVersion.
+	Minimum string `json:"minimum,omitempty"`
+	// MaximumTested is the newest version known to work. Newer versions are
+	// reported with *CLIVersionWarning. Empty means MaximumTestedCLIVersion.
+	MaximumTested string `json:"maximum_tested,omitempty"`
+	// RejectUntested rejects versions newer than MaximumTested with
+	// *CLIVersionError instead of warning.
+	RejectUntested bool `json:"reject_untested,omitempty"`
+}
+
+// CLIInfo describes a resolved CLI installation.
+type CLIInfo struct {
+	// Path is the CLI entry point.
+	Path string `json:"path"`
+	// Version is the version the CLI reports, or empty if it could not be determined.
+	Version string `json:"version,omitempty"`
+	// RuntimeExecutable runs script entry points, such as "node" or "bun".
+	// It is empty for native binaries.
+	RuntimeExecutable string `json:"runtime_executable,omitempty"`
+	// Warning is set when Version is newer than the policy's MaximumTested.
+	Warning *CLIVersionWarning `json:"-"`
+}
+
 // SystemPromptPreset allows using preset system prompts.
 type SystemPromptPreset struct {
 	Type   string  `json:"type"`   // "preset"
@@ -188,6 +257,11 @@ const Version = "0.1.0"
 // MinimumCLIVersion is the minimum supported CLI version.
 const MinimumCLIVersion = "2.0.0"
 
+// MaximumTestedCLIVersion is the newest CLI major version the SDK is tested
+// against, so only a major-version jump is reported with a *CLIVersionWarning.
+// A CLIVersionPolicy with a narrower MaximumTested opts into stricter checks.
+const MaximumTestedCLIVersion = "2"
+
 // mcpToolsByName caches tool lookups by name
 var (
 	mcpToolsByName   = make(map[*MCPServer]map[string]*MCPTool)
@@ -318,8 +392,16 @@ type Options struct {
 	MaxBudgetUSD float64 `json:"max_budget_usd,omitempty"`
 	// MaxThinkingTokens limits extended thinking tokens.
 	MaxThinkingTokens int `json:"max_thinking_tokens,omitempty"`
-	// MaxBufferSize sets the maximum buffer size for stdout (default: 1MB).
+	// MaxBufferSize sets the maximum size of a single message from the CLI (default: 1MB).
+	// Larger messages are skipped and reported as *BufferOverflowError.
 	MaxBufferSize int `json:"max_buffer_size,omitempty"`
+	// ResourceLimits caps the CLI process's resources.
+	ResourceLimits *ResourceLimits `json:"resource_limits,omitempty"`
+	// CLIVersionPolicy overrides the accepted CLI version range.
+	CLIVersionPolicy *CLIVersionPolicy `json:"cli_version_policy,omitempty"`
+	// ShutdownTimeout is how long Close waits for the CLI to exit after closing
+	// its input, and again after SIGTERM before SIGKILL (default: 5s).
+	ShutdownTimeout time.Duration `json:"shutdown_timeout,omitempty"`
 
 	// Cwd sets the working directory for the CLI subprocess.
 	Cwd string `json:"cwd,omitempty"`
@@ -348,6 +430,26 @@ type Options struct {
 	Hooks map[HookEvent][]HookMatcher `json:"-"`
 	// CanUseTool is a callback to control tool usage dynamically.
 	CanUseTool CanUseToolCallback `json:"-"`
+	// PermissionFallback answers permission requests when CanUseTool is unset.
+	// Nil allows all requests (legacy behavior).
+	PermissionFallback *PermissionFallback `json:"-"`
+
+	// StrictMessageParsing reports messages of unknown types as
+	// *MessageParseError on the errors channel instead of delivering them as
+	// *UnknownMessage. Meant for tests that should fail on protocol changes.
+	StrictMessageParsing bool `json:"strict_message_parsing,omitempty"`
+
+	// RetainRawJSON keeps the JSON of each message, available from its
+	// RawJSON method, for logging, replaying or forwarding CLI payloads. It is
+	// the CLI's original JSON only if the transport implements
+	// WireMessageTransport; otherwise it is the decoded message re-encoded,
+	// with keys sorted and numbers reformatted.
+	RetainRawJSON bool `json:"retain_raw_json,omitempty"`
+
+	// SubagentEvents makes the Client report the lifecycle and messages of
+	// Task subagents on its SubagentEvents channel, which must then be
+	// drained alongside Messages.
+	SubagentEvents bool `json:"subagent_events,omitempty"`
 
 	// IncludePartialMessages enables streaming of partial message updates.
 	IncludePartialMessages bool `json:"include_partial_messages,omitempty"`
@@ -473,6 +575,43 @@ func WithPermissionPromptToolName(toolName string) Option {
 	}
 }
 
+// WithPermissionFallback sets the policy for permission requests when no CanUseTool callback is configured.
+func WithPermissionFallback(behavior PermissionFallbackBehavior, message string) Option {
+	return func(o *Options) {
+		o.PermissionFallback = &PermissionFallback{Behavior: behavior, Message: message}
+	}
+}
+
+// WithStrictMessageParsing reports messages of unknown types as errors
+// instead of delivering them as *UnknownMessage.
+func WithStrictMessageParsing(strict bool) Option {
+	return func(o *Options) {
+		o.StrictMessageParsing = strict
+	}
+}
+
+// WithRetainRawJSON keeps the JSON of each message, available from
+// MessageRawJSON or the message's RawJSON method.
+func WithRetainRawJSON() Option {
+	return func(o *Options) {
+		o.RetainRawJSON = true
+	}
+}
+
+// WithSubagentEvents reports the lifecycle and messages of Task subagents
+// on Client.SubagentEvents. The channel must be drained alongside
+// Client.Messages, or message delivery stalls once it is full.
+func WithSubagentEvents() Option {
+	return func(o *Options) {
+		o.SubagentEvents = true
+	}
+}
+
+// WithStrictPermissions denies permission requests when no CanUseTool callback is configured.
+func WithStrictPermissions() Option {
+	return WithPermissionFallback(PermissionFallbackDeny, "")
+}
+
 // WithEnv sets environment variables.
 func WithEnv(env map[string]string) Option {
 	return func(o *Options) {
@@ -741,13 +880,34 @@ func WithStderrCallback(callback func(string)) Option {
 	}
 }
 
-// WithMaxBufferSize sets the max buffer size for stdout.
+// WithMaxBufferSize sets the maximum size of a single message from the CLI.
 func WithMaxBufferSize(size int) Option {
 	return func(o *Options) {
 		o.MaxBufferSize = size
 	}
 }
 
+// WithShutdownTimeout sets how long Close waits at each step of stopping the CLI.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = timeout
+	}
+}
+
+// WithResourceLimits caps the CLI process's resources.
+func WithResourceLimits(limits ResourceLimits) Option {
+	return func(o *Options) {
+		o.ResourceLimits = &limits
+	}
+}
+
+// WithCLIVersionPolicy sets the CLI version range Connect accepts.
+func WithCLIVersionPolicy(policy CLIVersionPolicy) Option {
+	return func(o *Options) {
+		o.CLIVersionPolicy = &policy
+	}
+}
+
 // WithMCPServers sets external MCP server configurations.
 func WithMCPServers(servers map[string]MCPServerConfig) Option {
 	return func(o *Options) {
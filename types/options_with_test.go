@@ -5,6 +5,7 @@ package types
 
 import (
 	"testing"
+	"time"
 )
 
 func TestWithModel(t *testing.T) {
@@ -396,6 +397,22 @@ func TestWithMaxBufferSize(t *testing.T) {
 	}
 }
 
+func TestWithShutdownTimeout(t *testing.T) {
+	opts := DefaultOptions()
+	WithShutdownTimeout(2 * time.Second)(opts)
+	if opts.ShutdownTimeout != 2*time.Second {
+		t.Errorf("expected 2s, got %v", opts.ShutdownTimeout)
+	}
+}
+
+func TestWithCLIVersionPolicy(t *testing.T) {
+	opts := DefaultOptions()
+	WithCLIVersionPolicy(CLIVersionPolicy{MaximumTested: "2.2", RejectUntested: true})(opts)
+	if opts.CLIVersionPolicy == nil || opts.CLIVersionPolicy.MaximumTested != "2.2" || !opts.CLIVersionPolicy.RejectUntested {
+		t.Errorf("unexpected policy: %+v", opts.CLIVersionPolicy)
+	}
+}
+
 func TestWithMCPServers(t *testing.T) {
 	opts := DefaultOptions()
 	servers := map[string]MCPServerConfig{
@@ -440,3 +457,57 @@ func TestMCPServerBuilder(t *testing.T) {
 		t.Errorf("expected tool name 'greet', got %q", server.Tools[0].Name)
 	}
 }
+
+func TestWithPermissionFallback(t *testing.T) {
+	opts := DefaultOptions()
+	if opts.PermissionFallback != nil {
+		t.Fatalf("expected nil fallback by default, got %+v", opts.PermissionFallback)
+	}
+	WithPermissionFallback(PermissionFallbackDeny, "no callback")(opts)
+	if opts.PermissionFallback == nil ||
+		opts.PermissionFallback.Behavior != PermissionFallbackDeny ||
+		opts.PermissionFallback.Message != "no callback" {
+		t.Fatalf("unexpected fallback: %+v", opts.PermissionFallback)
+	}
+}
+
+func TestWithStrictPermissions(t *testing.T) {
+	opts := DefaultOptions()
+	WithStrictPermissions()(opts)
+	if opts.PermissionFallback == nil || opts.PermissionFallback.Behavior != PermissionFallbackDeny {
+		t.Fatalf("expected deny fallback, got %+v", opts.PermissionFallback)
+	}
+}
+
+func TestWithStrictMessageParsing(t *testing.T) {
+	opts := DefaultOptions()
+	if opts.StrictMessageParsing {
+		t.Fatal("expected lenient message parsing by default")
+	}
+	WithStrictMessageParsing(true)(opts)
+	if !opts.StrictMessageParsing {
+		t.Fatal("expected strict message parsing")
+	}
+}
+
+func TestWithRetainRawJSON(t *testing.T) {
+	opts := DefaultOptions()
+	if opts.RetainRawJSON {
+		t.Fatal("expected raw JSON to be dropped by default")
+	}
+	WithRetainRawJSON()(opts)
+	if !opts.RetainRawJSON {
+		t.Fatal("expected raw JSON to be retained")
+	}
+}
+
+func TestWithSubagentEvents(t *testing.T) {
+	opts := DefaultOptions()
+	if opts.SubagentEvents {
+		t.Fatal("expected subagent events to be off by default")
+	}
+	WithSubagentEvents()(opts)
+	if !opts.SubagentEvents {
+		t.Fatal("expected subagent events to be enabled")
+	}
+}
@@ -0,0 +1,516 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StreamEventData is the typed payload of a StreamEvent, one of the Anthropic
+// streaming events: *MessageStartEvent, *ContentBlockStartEvent,
+// *ContentBlockDeltaEvent, *ContentBlockStopEvent, *MessageDeltaEvent,
+// *MessageStopEvent, *PingEvent, *StreamErrorEvent or *UnknownStreamEvent.
+type StreamEventData interface {
+	StreamEventType() string
+}
+
+// MessageStartEvent begins a new assistant message.
+type MessageStartEvent struct {
+	ID         string
+	Model      string
+	Role       string
+	StopReason string
+	Content    []ContentBlock
+	Usage      *Usage
+}
+
+func (e *MessageStartEvent) StreamEventType() string { return "message_start" }
+
+// ContentBlockStartEvent begins the content block at Index. Its Block holds
+// the block's initial state, such as a tool use's ID and name.
+type ContentBlockStartEvent struct {
+	Index int
+	Block ContentBlock
+}
+
+func (e *ContentBlockStartEvent) StreamEventType() string { return "content_block_start" }
+
+// Delta types carried by ContentBlockDeltaEvent.
+const (
+	DeltaText      = "text_delta"
+	DeltaInputJSON = "input_json_delta"
+	DeltaThinking  = "thinking_delta"
+	DeltaSignature = "signature_delta"
+	DeltaCitations = "citations_delta"
+)
+
+// ContentBlockDelta is an increment to a content block. Which field is set
+// depends on Type: Text for text_delta, PartialJSON for input_json_delta,
+// Thinking for thinking_delta, Signature for signature_delta and Citation
+// for citations_delta.
+type ContentBlockDelta struct {
+	Type        string
+	Text        string
+	PartialJSON string
+	Thinking    string
+	Signature   string
+	Citation    map[string]any
+}
+
+// ContentBlockDeltaEvent extends the content block at Index.
+type ContentBlockDeltaEvent struct {
+	Index int
+	Delta ContentBlockDelta
+}
+
+func (e *ContentBlockDeltaEvent) StreamEventType() string { return "content_block_delta" }
+
+// ContentBlockStopEvent ends the content block at Index.
+type ContentBlockStopEvent struct {
+	Index int
+}
+
+func (e *ContentBlockStopEvent) StreamEventType() string { return "content_block_stop" }
+
+// MessageDeltaEvent reports top-level changes to the message, such as its
+// stop reason, along with cumulative usage.
+type MessageDeltaEvent struct {
+	StopReason   string
+	StopSequence string
+	Usage        *Usage
+}
+
+func (e *MessageDeltaEvent) StreamEventType() string { return "message_delta" }
+
+// MessageStopEvent ends the message.
+type MessageStopEvent struct{}
+
+func (e *MessageStopEvent) StreamEventType() string { return "message_stop" }
+
+// PingEvent keeps the stream alive.
+type PingEvent struct{}
+
+func (e *PingEvent) StreamEventType() string { return "ping" }
+
+// StreamErrorEvent reports an error from the API mid-stream, such as overloaded_error.
+type StreamErrorEvent struct {
+	ErrorType string
+	Message   string
+}
+
+func (e *StreamErrorEvent) StreamEventType() string { return "error" }
+
+// UnknownStreamEvent carries a stream event of a type the SDK does not know.
+type UnknownStreamEvent struct {
+	Kind string
+	Raw  map[string]any
+}
+
+func (e *UnknownStreamEvent) StreamEventType() string { return e.Kind }
+
+// Data returns the event's typed payload. Events without a nested event
+// object are built from the top-level EventType, Index and Delta fields.
+func (m *StreamEvent) Data() (StreamEventData, error) {
+	event := m.Event
+	if event == nil {
+		event = map[string]any{"type": m.EventType}
+		if m.Index != nil {
+			event["index"] = float64(*m.Index)
+		}
+		if m.Delta != nil {
+			event["delta"] = m.Delta
+		}
+	}
+	return ParseStreamEventData(event)
+}
+
+// ParseStreamEventData parses a raw Anthropic streaming event.
+func ParseStreamEventData(event map[string]any) (StreamEventData, error) {
+	eventType, _ := event["type"].(string)
+	index, _ := event["index"].(float64)
+
+	switch eventType {
+	case "message_start":
+		message, _ := event["message"].(map[string]any)
+		start := &MessageStartEvent{
+			ID:         getString(message, "id"),
+			Model:      getString(message, "model"),
+			Role:       getString(message, "role"),
+			StopReason: getString(message, "stop_reason"),
+		}
+		if usage, ok := message["usage"].(map[string]any); ok {
+			parsed := ParseUsage(usage)
+			start.Usage = &parsed
+		}
+		if content, ok := message["content"].([]any); ok {
+			for _, item := range content {
+				raw, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				block, err := ParseContentBlock(raw)
+				if err != nil {
+					return nil, fmt.Errorf("message_start content: %w", err)
+				}
+				start.Content = append(start.Content, block)
+			}
+		}
+		return start, nil
+
+	case "content_block_start":
+		raw, ok := event["content_block"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("content_block_start: missing content_block")
+		}
+		block, err := ParseContentBlock(raw)
+		if err != nil {
+			return nil, fmt.Errorf("content_block_start: %w", err)
+		}
+		return &ContentBlockStartEvent{Index: int(index), Block: block}, nil
+
+	case "content_block_delta":
+		raw, ok := event["delta"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("content_block_delta: missing delta")
+		}
+		delta := ContentBlockDelta{
+			Type:        getString(raw, "type"),
+			Text:        getString(raw, "text"),
+			PartialJSON: getString(raw, "partial_json"),
+			Thinking:    getString(raw, "thinking"),
+			Signature:   getString(raw, "signature"),
+		}
+		delta.Citation, _ = raw["citation"].(map[string]any)
+		return &ContentBlockDeltaEvent{Index: int(index), Delta: delta}, nil
+
+	case "content_block_stop":
+		return &ContentBlockStopEvent{Index: int(index)}, nil
+
+	case "message_delta":
+		delta, _ := event["delta"].(map[string]any)
+		messageDelta := &MessageDeltaEvent{
+			StopReason:   getString(delta, "stop_reason"),
+			StopSequence: getString(delta, "stop_sequence"),
+		}
+		if usage, ok := event["usage"].(map[string]any); ok {
+			parsed := ParseUsage(usage)
+			messageDelta.Usage = &parsed
+		}
+		return messageDelta, nil
+
+	case "message_stop":
+		return &MessageStopEvent{}, nil
+
+	case "ping":
+		return &PingEvent{}, nil
+
+	case "error":
+		apiErr, _ := event["error"].(map[string]any)
+		return &StreamErrorEvent{
+			ErrorType: getString(apiErr, "type"),
+			Message:   getString(apiErr, "message"),
+		}, nil
+
+	case "":
+		return nil, fmt.Errorf("stream event has no type")
+
+	default:
+		return &UnknownStreamEvent{Kind: eventType, Raw: event}, nil
+	}
+}
+
+// MessageAccumulator reconstructs assistant messages from stream events, so
+// partial output can be rendered as it arrives:
+//
+//	acc := types.NewMessageAccumulator()
+//	for msg := range client.Messages() {
+//		if event, ok := msg.(*types.StreamEvent); ok {
+//			if snapshot, err := acc.Add(event); err == nil && snapshot != nil {
+//				render(snapshot)
+//			}
+//		}
+//	}
+//
+// Messages streamed by subagents are tracked separately by their parent tool
+// use ID. A MessageAccumulator is not safe for concurrent use.
+type MessageAccumulator struct {
+	messages map[string]*partialMessage
+}
+
+// partialMessage is an assistant message being streamed.
+type partialMessage struct {
+	message AssistantMessage
+	// toolJSON holds the input JSON received so far for tool use blocks, by index.
+	toolJSON map[int]string
+}
+
+// NewMessageAccumulator returns an empty MessageAccumulator.
+func NewMessageAccumulator() *MessageAccumulator {
+	return &MessageAccumulator{messages: make(map[string]*partialMessage)}
+}
+
+// Add applies event and returns a snapshot of the message it belongs to.
+// Snapshots are copies, so they stay valid as later events arrive. Events
+// that carry no message content, such as ping, return a nil snapshot.
+//
+// Tool use input is parsed as its JSON streams in: a snapshot holds the
+// input as of the last delta that could be completed into valid JSON, and
+// content_block_stop fails if the final input is invalid.
+func (a *MessageAccumulator) Add(event *StreamEvent) (*AssistantMessage, error) {
+	data, err := event.Data()
+	if err != nil {
+		return nil, err
+	}
+	key := ""
+	if event.ParentToolUseID != nil {
+		key = *event.ParentToolUseID
+	}
+
+	if start, ok := data.(*MessageStartEvent); ok {
+		partial := &partialMessage{
+			message: AssistantMessage{
+				Content:         append([]ContentBlock(nil), start.Content...),
+				Model:           start.Model,
+				StopReason:      start.StopReason,
+				MessageID:       start.ID,
+				Usage:           start.Usage,
+				UUID:            event.UUID,
+				SessionID:       event.SessionID,
+				ParentToolUseID: event.ParentToolUseID,
+			},
+			toolJSON: make(map[int]string),
+		}
+		a.messages[key] = partial
+		return partial.snapshot(), nil
+	}
+
+	partial, ok := a.messages[key]
+	if !ok {
+		switch data.(type) {
+		case *PingEvent, *StreamErrorEvent, *UnknownStreamEvent:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s event before message_start", data.StreamEventType())
+	}
+
+	switch e := data.(type) {
+	case *ContentBlockStartEvent:
+		if e.Index < 0 || e.Index > len(partial.message.Content) {
+			return nil, fmt.Errorf("content_block_start: unexpected index %d", e.Index)
+		}
+		if e.Index == len(partial.message.Content) {
+			partial.message.Content = append(partial.message.Content, nil)
+		}
+		partial.message.Content[e.Index] = copyBlock(e.Block)
+
+	case *ContentBlockDeltaEvent:
+		if err := partial.applyDelta(e); err != nil {
+			return nil, err
+		}
+
+	case *ContentBlockStopEvent:
+		if err := partial.finishBlock(e.Index); err != nil {
+			return nil, err
+		}
+
+	case *MessageDeltaEvent:
+		if e.StopReason != "" {
+			partial.message.StopReason = e.StopReason
+		}
+		if e.Usage != nil {
+			partial.message.Usage = mergeDeltaUsage(partial.message.Usage, *e.Usage)
+		}
+
+	case *MessageStopEvent:
+		delete(a.messages, key)
+
+	default:
+		return nil, nil
+	}
+	return partial.snapshot(), nil
+}
+
+// Snapshot returns the message in progress for parentToolUseID, or for the
+// main conversation if it is empty, or nil if no message is in progress.
+func (a *MessageAccumulator) Snapshot(parentToolUseID string) *AssistantMessage {
+	partial, ok := a.messages[parentToolUseID]
+	if !ok {
+		return nil
+	}
+	return partial.snapshot()
+}
+
+// Reset discards all messages in progress.
+func (a *MessageAccumulator) Reset() {
+	a.messages = make(map[string]*partialMessage)
+}
+
+func (p *partialMessage) block(index int, eventType string) (ContentBlock, error) {
+	if index < 0 || index >= len(p.message.Content) || p.message.Content[index] == nil {
+		return nil, fmt.Errorf("%s: no content block at index %d", eventType, index)
+	}
+	return p.message.Content[index], nil
+}
+
+func (p *partialMessage) applyDelta(e *ContentBlockDeltaEvent) error {
+	block, err := p.block(e.Index, "content_block_delta")
+	if err != nil {
+		return err
+	}
+
+	switch b := block.(type) {
+	case *TextBlock:
+		if e.Delta.Type == DeltaText {
+			b.TextContent += e.Delta.Text
+		}
+	case *ThinkingBlock:
+		switch e.Delta.Type {
+		case DeltaThinking:
+			b.ThinkingContent += e.Delta.Thinking
+		case DeltaSignature:
+			b.Signature += e.Delta.Signature
+		}
+	case *ToolUseBlock:
+		if e.Delta.Type == DeltaInputJSON {
+			p.toolJSON[e.Index] += e.Delta.PartialJSON
+			if input, ok := parsePartialJSON(p.toolJSON[e.Index]); ok {
+				b.ToolInput = input
+			}
+		}
+	}
+	return nil
+}
+
+func (p *partialMessage) finishBlock(index int) error {
+	block, err := p.block(index, "content_block_stop")
+	if err != nil {
+		return err
+	}
+	tool, ok := block.(*ToolUseBlock)
+	if !ok {
+		return nil
+	}
+	partialJSON, streamed := p.toolJSON[index]
+	if !streamed || partialJSON == "" {
+		return nil
+	}
+	var input map[string]any
+	if err := json.Unmarshal([]byte(partialJSON), &input); err != nil {
+		return fmt.Errorf("tool use %s: invalid input JSON: %w", tool.ID, err)
+	}
+	tool.ToolInput = input
+	delete(p.toolJSON, index)
+	return nil
+}
+
+func (p *partialMessage) snapshot() *AssistantMessage {
+	message := p.message
+	message.Content = make([]ContentBlock, 0, len(p.message.Content))
+	for _, block := range p.message.Content {
+		if block != nil {
+			message.Content = append(message.Content, copyBlock(block))
+		}
+	}
+	return &message
+}
+
+// copyBlock copies the blocks the accumulator extends, so snapshots do not
+// change when later deltas arrive. Other blocks are not modified and are shared.
+func copyBlock(block ContentBlock) ContentBlock {
+	switch b := block.(type) {
+	case *TextBlock:
+		c := *b
+		return &c
+	case *ThinkingBlock:
+		c := *b
+		return &c
+	case *ToolUseBlock:
+		c := *b
+		return &c
+	default:
+		return block
+	}
+}
+
+// mergeDeltaUsage applies the usage of a message_delta event, whose counts
+// are cumulative for the message. Counts it leaves at zero keep the values
+// from message_start.
+func mergeDeltaUsage(current *Usage, delta Usage) *Usage {
+	if current == nil {
+		return &delta
+	}
+	merged := *current
+	for _, field := range []struct{ dst, src *int }{
+		{&merged.InputTokens, &delta.InputTokens},
+		{&merged.OutputTokens, &delta.OutputTokens},
+		{&merged.CacheCreationInputTokens, &delta.CacheCreationInputTokens},
+		{&merged.CacheReadInputTokens, &delta.CacheReadInputTokens},
+		{&merged.ServerToolUse.WebSearchRequests, &delta.ServerToolUse.WebSearchRequests},
+		{&merged.ServerToolUse.WebFetchRequests, &delta.ServerToolUse.WebFetchRequests},
+	} {
+		if *field.src != 0 {
+			*field.dst = *field.src
+		}
+	}
+	return &merged
+}
+
+// parsePartialJSON parses an incomplete JSON object by closing its open
+// strings, arrays and objects. It reports false if the prefix cannot be
+// completed, such as one ending in a key without a value.
+func parsePartialJSON(partial string) (map[string]any, bool) {
+	var closers []byte
+	inString, escaped := false, false
+	for i := 0; i < len(partial); i++ {
+		c := partial[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		}
+	}
+
+	completed := partial
+	if inString {
+		if escaped {
+			completed = completed[:len(completed)-1]
+		}
+		completed += `"`
+	}
+	for len(completed) > 0 {
+		last := completed[len(completed)-1]
+		if last != ',' && last != ' ' && last != '\n' && last != '\t' && last != '\r' {
+			break
+		}
+		completed = completed[:len(completed)-1]
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		completed += string(closers[i])
+	}
+
+	var input map[string]any
+	if err := json.Unmarshal([]byte(completed), &input); err != nil || input == nil {
+		return nil, false
+	}
+	return input, true
+}
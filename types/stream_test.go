@@ -0,0 +1,262 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func streamEvent(event map[string]any) *StreamEvent {
+	return &StreamEvent{UUID: "evt", SessionID: "sess", Event: event}
+}
+
+func blockDelta(index int, delta map[string]any) *StreamEvent {
+	return streamEvent(map[string]any{"type": "content_block_delta", "index": float64(index), "delta": delta})
+}
+
+func TestParseStreamEventData(t *testing.T) {
+	tests := []struct {
+		name  string
+		event map[string]any
+		want  StreamEventData
+	}{
+		{
+			name: "message_start",
+			event: map[string]any{"type": "message_start", "message": map[string]any{
+				"id": "msg_1", "model": "claude-sonnet-4-5", "role": "assistant", "content": []any{},
+				"usage": map[string]any{"input_tokens": float64(10)},
+			}},
+			want: &MessageStartEvent{ID: "msg_1", Model: "claude-sonnet-4-5", Role: "assistant", Usage: &Usage{InputTokens: 10}},
+		},
+		{
+			name: "content_block_start",
+			event: map[string]any{"type": "content_block_start", "index": float64(1), "content_block": map[string]any{
+				"type": "tool_use", "id": "tu_1", "name": "Read", "input": map[string]any{},
+			}},
+			want: &ContentBlockStartEvent{Index: 1, Block: &ToolUseBlock{ID: "tu_1", Name: "Read", ToolInput: map[string]any{}}},
+		},
+		{
+			name:  "text delta",
+			event: map[string]any{"type": "content_block_delta", "index": float64(0), "delta": map[string]any{"type": "text_delta", "text": "Hi"}},
+			want:  &ContentBlockDeltaEvent{Index: 0, Delta: ContentBlockDelta{Type: DeltaText, Text: "Hi"}},
+		},
+		{
+			name:  "input json delta",
+			event: map[string]any{"type": "content_block_delta", "index": float64(2), "delta": map[string]any{"type": "input_json_delta", "partial_json": `{"a":`}},
+			want:  &ContentBlockDeltaEvent{Index: 2, Delta: ContentBlockDelta{Type: DeltaInputJSON, PartialJSON: `{"a":`}},
+		},
+		{
+			name:  "content_block_stop",
+			event: map[string]any{"type": "content_block_stop", "index": float64(3)},
+			want:  &ContentBlockStopEvent{Index: 3},
+		},
+		{
+			name: "message_delta",
+			event: map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"},
+				"usage": map[string]any{"output_tokens": float64(5)}},
+			want: &MessageDeltaEvent{StopReason: "tool_use", Usage: &Usage{OutputTokens: 5}},
+		},
+		{
+			name:  "message_stop",
+			event: map[string]any{"type": "message_stop"},
+			want:  &MessageStopEvent{},
+		},
+		{
+			name:  "error",
+			event: map[string]any{"type": "error", "error": map[string]any{"type": "overloaded_error", "message": "Overloaded"}},
+			want:  &StreamErrorEvent{ErrorType: "overloaded_error", Message: "Overloaded"},
+		},
+		{
+			name:  "unknown",
+			event: map[string]any{"type": "future_event", "x": float64(1)},
+			want:  &UnknownStreamEvent{Kind: "future_event", Raw: map[string]any{"type": "future_event", "x": float64(1)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStreamEventData(tt.event)
+			if err != nil {
+				t.Fatalf("ParseStreamEventData failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseStreamEventData(map[string]any{}); err == nil {
+		t.Error("expected an error for an event without a type")
+	}
+}
+
+func TestStreamEventData_TopLevelFields(t *testing.T) {
+	index := 2
+	event := &StreamEvent{EventType: "content_block_delta", Index: &index, Delta: map[string]any{"type": "text_delta", "text": "abc"}}
+	data, err := event.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	want := &ContentBlockDeltaEvent{Index: 2, Delta: ContentBlockDelta{Type: DeltaText, Text: "abc"}}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("got %#v, want %#v", data, want)
+	}
+}
+
+func TestMessageAccumulator(t *testing.T) {
+	acc := NewMessageAccumulator()
+	events := []*StreamEvent{
+		streamEvent(map[string]any{"type": "message_start", "message": map[string]any{
+			"id": "msg_1", "model": "claude-sonnet-4-5", "content": []any{},
+			"usage": map[string]any{"input_tokens": float64(10), "output_tokens": float64(1)},
+		}}),
+		streamEvent(map[string]any{"type": "content_block_start", "index": float64(0), "content_block": map[string]any{"type": "thinking", "thinking": ""}}),
+		blockDelta(0, map[string]any{"type": "thinking_delta", "thinking": "Let me "}),
+		blockDelta(0, map[string]any{"type": "thinking_delta", "thinking": "look."}),
+		blockDelta(0, map[string]any{"type": "signature_delta", "signature": "sig"}),
+		streamEvent(map[string]any{"type": "content_block_stop", "index": float64(0)}),
+		streamEvent(map[string]any{"type": "content_block_start", "index": float64(1), "content_block": map[string]any{"type": "text", "text": ""}}),
+		blockDelta(1, map[string]any{"type": "text_delta", "text": "Reading "}),
+		blockDelta(1, map[string]any{"type": "text_delta", "text": "the file."}),
+		streamEvent(map[string]any{"type": "content_block_stop", "index": float64(1)}),
+		streamEvent(map[string]any{"type": "content_block_start", "index": float64(2), "content_block": map[string]any{
+			"type": "tool_use", "id": "tu_1", "name": "Read", "input": map[string]any{},
+		}}),
+	}
+	var snapshot *AssistantMessage
+	for i, event := range events {
+		var err error
+		if snapshot, err = acc.Add(event); err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+	}
+	if snapshot.Model != "claude-sonnet-4-5" || snapshot.Text() != "Reading the file." || len(snapshot.Content) != 3 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	thinking := snapshot.Content[0].(*ThinkingBlock)
+	if thinking.ThinkingContent != "Let me look." || thinking.Signature != "sig" {
+		t.Errorf("unexpected thinking block: %+v", thinking)
+	}
+
+	// Tool input is parsed as it streams in
+	before := snapshot
+	snapshot, err := acc.Add(blockDelta(2, map[string]any{"type": "input_json_delta", "partial_json": `{"file_path": "/tmp/a.g`}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := snapshot.Content[2].(*ToolUseBlock)
+	if tool.ToolInput["file_path"] != "/tmp/a.g" {
+		t.Errorf("unexpected partial input: %+v", tool.ToolInput)
+	}
+	if len(before.Content[2].(*ToolUseBlock).ToolInput) != 0 {
+		t.Error("earlier snapshot changed")
+	}
+
+	// An incomplete key keeps the last parsable input
+	snapshot, _ = acc.Add(blockDelta(2, map[string]any{"type": "input_json_delta", "partial_json": `o", "lim`}))
+	if tool := snapshot.Content[2].(*ToolUseBlock); tool.ToolInput["file_path"] != "/tmp/a.g" {
+		t.Errorf("unexpected partial input: %+v", tool.ToolInput)
+	}
+	acc.Add(blockDelta(2, map[string]any{"type": "input_json_delta", "partial_json": `it": 10}`}))
+	snapshot, err = acc.Add(streamEvent(map[string]any{"type": "content_block_stop", "index": float64(2)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"file_path": "/tmp/a.go", "limit": float64(10)}
+	if tool := snapshot.Content[2].(*ToolUseBlock); !reflect.DeepEqual(tool.ToolInput, want) {
+		t.Errorf("got input %+v, want %+v", tool.ToolInput, want)
+	}
+
+	snapshot, _ = acc.Add(streamEvent(map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"},
+		"usage": map[string]any{"output_tokens": float64(42)}}))
+	if snapshot.StopReason != "tool_use" {
+		t.Errorf("expected stop reason tool_use, got %q", snapshot.StopReason)
+	}
+	if want := (&Usage{InputTokens: 10, OutputTokens: 42}); snapshot.MessageID != "msg_1" || !reflect.DeepEqual(snapshot.Usage, want) {
+		t.Errorf("got message %q usage %+v, want msg_1 %+v", snapshot.MessageID, snapshot.Usage, want)
+	}
+	if _, err := acc.Add(streamEvent(map[string]any{"type": "message_stop"})); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Snapshot("") != nil {
+		t.Error("expected no message in progress after message_stop")
+	}
+}
+
+func TestMessageAccumulator_Errors(t *testing.T) {
+	acc := NewMessageAccumulator()
+	if _, err := acc.Add(blockDelta(0, map[string]any{"type": "text_delta", "text": "x"})); err == nil {
+		t.Error("expected an error for a delta before message_start")
+	}
+	if snapshot, err := acc.Add(streamEvent(map[string]any{"type": "ping"})); err != nil || snapshot != nil {
+		t.Errorf("expected ping to be ignored, got %v, %v", snapshot, err)
+	}
+
+	acc.Add(streamEvent(map[string]any{"type": "message_start", "message": map[string]any{}}))
+	if _, err := acc.Add(blockDelta(4, map[string]any{"type": "text_delta", "text": "x"})); err == nil {
+		t.Error("expected an error for a delta to a missing block")
+	}
+	acc.Add(streamEvent(map[string]any{"type": "content_block_start", "index": float64(0), "content_block": map[string]any{
+		"type": "tool_use", "id": "tu_1", "name": "Bash", "input": map[string]any{},
+	}}))
+	acc.Add(blockDelta(0, map[string]any{"type": "input_json_delta", "partial_json": `{"command": }`}))
+	if _, err := acc.Add(streamEvent(map[string]any{"type": "content_block_stop", "index": float64(0)})); err == nil {
+		t.Error("expected an error for invalid tool input")
+	}
+}
+
+func TestMessageAccumulator_Subagents(t *testing.T) {
+	acc := NewMessageAccumulator()
+	parent := "tu_task"
+	start := map[string]any{"type": "message_start", "message": map[string]any{}}
+	textStart := map[string]any{"type": "content_block_start", "index": float64(0), "content_block": map[string]any{"type": "text", "text": ""}}
+
+	for _, parentID := range []*string{nil, &parent} {
+		for _, event := range []map[string]any{start, textStart} {
+			e := streamEvent(event)
+			e.ParentToolUseID = parentID
+			if _, err := acc.Add(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	sub := blockDelta(0, map[string]any{"type": "text_delta", "text": "from subagent"})
+	sub.ParentToolUseID = &parent
+	snapshot, err := acc.Add(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Text() != "from subagent" || snapshot.ParentToolUseID == nil || *snapshot.ParentToolUseID != parent {
+		t.Errorf("unexpected subagent snapshot: %+v", snapshot)
+	}
+	if main := acc.Snapshot(""); main == nil || main.Text() != "" {
+		t.Errorf("unexpected main snapshot: %+v", main)
+	}
+
+	acc.Reset()
+	if acc.Snapshot(parent) != nil {
+		t.Error("expected Reset to discard messages in progress")
+	}
+}
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		partial string
+		want    map[string]any
+	}{
+		{`{`, map[string]any{}},
+		{`{"a": "b`, map[string]any{"a": "b"}},
+		{`{"a": "b\`, map[string]any{"a": "b"}},
+		{`{"a": [1, 2,`, map[string]any{"a": []any{float64(1), float64(2)}}},
+		{`{"a": {"b": "}"`, map[string]any{"a": map[string]any{"b": "}"}}},
+		{`{"a":`, nil},
+		{`{"a": tr`, nil},
+	}
+	for _, tt := range tests {
+		got, ok := parsePartialJSON(tt.partial)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePartialJSON(%q) = %v, %v; want %v", tt.partial, got, ok, tt.want)
+		}
+	}
+}
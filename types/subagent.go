@@ -0,0 +1,49 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+// SubagentEventType identifies what happened to a subagent.
+type SubagentEventType string
+
+const (
+	// SubagentEventStart is sent when a Task tool call starts a subagent.
+	SubagentEventStart SubagentEventType = "start"
+	// SubagentEventMessage is sent for each message the subagent sends.
+	SubagentEventMessage SubagentEventType = "message"
+	// SubagentEventStop is sent when the SubagentStop hook fires.
+	SubagentEventStop SubagentEventType = "stop"
+	// SubagentEventResult is sent when the Task tool call's result arrives.
+	SubagentEventResult SubagentEventType = "result"
+)
+
+// Subagent describes a subagent run by a Task tool call.
+type Subagent struct {
+	// ParentToolUseID is the ID of the Task tool call, which the subagent's
+	// messages carry as their ParentToolUseID.
+	ParentToolUseID string `json:"parent_tool_use_id"`
+	AgentType       string `json:"agent_type,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Prompt          string `json:"prompt,omitempty"`
+	// AgentID is set once the SubagentStart or SubagentStop hook fires.
+	AgentID string `json:"agent_id,omitempty"`
+	// TranscriptPath is the subagent's transcript file, from SubagentStop.
+	TranscriptPath string `json:"transcript_path,omitempty"`
+	// Stopped is set when the SubagentStop hook fires.
+	Stopped bool `json:"stopped,omitempty"`
+	// Result is the Task tool call's result, or nil while the subagent runs.
+	Result *ToolResultBlock `json:"result,omitempty"`
+}
+
+// Done reports whether the Task tool call's result has arrived.
+func (s *Subagent) Done() bool { return s.Result != nil }
+
+// SubagentEvent reports a change in a subagent's lifecycle or a message it
+// sent. Events for one subagent arrive in order.
+type SubagentEvent struct {
+	Type SubagentEventType `json:"type"`
+	// Subagent is a copy of the subagent's state as of the event.
+	Subagent Subagent `json:"subagent"`
+	// Message is the subagent's message for SubagentEventMessage events.
+	Message Message `json:"message,omitempty"`
+}
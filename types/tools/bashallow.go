@@ -0,0 +1,145 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// ErrCommandNotAllowed is matched by CommandNotAllowedError via errors.Is.
+var ErrCommandNotAllowed = errors.New("command not allowed")
+
+// CommandNotAllowedError is returned when a Bash command is rejected by a BashAllowlist.
+type CommandNotAllowedError struct {
+	Command string // Full command line
+	Reason  string
+}
+
+func (e *CommandNotAllowedError) Error() string {
+	return fmt.Sprintf("Bash command not allowed: %s", e.Reason)
+}
+
+func (e *CommandNotAllowedError) Is(target error) bool {
+	return target == ErrCommandNotAllowed
+}
+
+// BashAllowlist permits Bash tool calls only when every command they run matches
+// an allowed prefix. Unlike substring matching on the command string, it parses
+// the command line, so "git status" can be allowed without also allowing
+// "git status; rm -rf ." or "git status $(curl ...)".
+type BashAllowlist struct {
+	prefixes [][]string
+
+	// AllowFileWrites permits output redirections to files (> out, >> log).
+	AllowFileWrites bool
+
+	// AllowSubstitution permits command and process substitution. The commands
+	// inside a substitution must still match the allowlist.
+	AllowSubstitution bool
+
+	// AllowAssignments permits variable assignments such as "GIT_PAGER=cat git log".
+	// They are denied by default because variables like PATH change what runs.
+	AllowAssignments bool
+}
+
+// NewBashAllowlist creates an allowlist from command prefixes such as "git status"
+// or "ls". A command matches a prefix when its leading words equal the prefix's
+// words exactly and none of them involve expansion.
+func NewBashAllowlist(prefixes ...string) *BashAllowlist {
+	l := &BashAllowlist{}
+	for _, prefix := range prefixes {
+		if words := strings.Fields(prefix); len(words) > 0 {
+			l.prefixes = append(l.prefixes, words)
+		}
+	}
+	return l
+}
+
+// Check parses command and returns a *CommandNotAllowedError if any part of it
+// is not allowed, or nil otherwise. Commands that cannot be parsed are denied.
+func (l *BashAllowlist) Check(command string) error {
+	analysis, err := ParseBash(command)
+	if err != nil {
+		return &CommandNotAllowedError{Command: command, Reason: fmt.Sprintf("cannot analyze command: %v", err)}
+	}
+	if !l.AllowSubstitution && analysis.HasSubstitution() {
+		return &CommandNotAllowedError{Command: command, Reason: fmt.Sprintf("substitution %s is not allowed", analysis.Substitutions[0])}
+	}
+	for _, cmd := range analysis.Commands {
+		if !l.AllowFileWrites {
+			for _, r := range cmd.Redirects {
+				if r.WritesFile() {
+					return &CommandNotAllowedError{Command: command, Reason: fmt.Sprintf("redirection to %q is not allowed", r.Target)}
+				}
+			}
+		}
+		if !l.AllowAssignments && len(cmd.Assignments) > 0 {
+			return &CommandNotAllowedError{Command: command, Reason: fmt.Sprintf("variable assignment %q is not allowed", cmd.Assignments[0])}
+		}
+		if cmd.Name != "" && !l.allows(cmd) {
+			return &CommandNotAllowedError{Command: command, Reason: fmt.Sprintf("%q is not in the allowlist", cmd.Raw)}
+		}
+	}
+	return nil
+}
+
+func (l *BashAllowlist) allows(cmd Command) bool {
+	for _, prefix := range l.prefixes {
+		if len(cmd.Words) < len(prefix) {
+			continue
+		}
+		matched := true
+		for i, word := range prefix {
+			if cmd.Words[i].Expanded || cmd.Words[i].Value != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CanUseTool wraps a permission callback so that disallowed Bash commands are
+// denied before next is consulted. Other tools are passed to next unchanged.
+// A nil next allows every request that passes the allowlist.
+func (l *BashAllowlist) CanUseTool(next types.CanUseToolCallback) types.CanUseToolCallback {
+	return func(toolName string, input map[string]any, ctx *types.ToolPermissionContext) (types.PermissionResult, error) {
+		if err := l.checkTool(toolName, input); err != nil {
+			return &types.PermissionResultDeny{Behavior: "deny", Message: err.Error()}, nil
+		}
+		if next == nil {
+			return &types.PermissionResultAllow{Behavior: "allow"}, nil
+		}
+		return next(toolName, input, ctx)
+	}
+}
+
+// PreToolUseHook returns a PreToolUse hook callback that denies disallowed Bash
+// commands and leaves every other decision to the CLI.
+func (l *BashAllowlist) PreToolUseHook() types.HookCallback {
+	return types.ToGenericCallback(func(input *types.PreToolUseHookInput, toolUseID *string, ctx *types.HookContext) (*types.HookOutput, error) {
+		if err := l.checkTool(input.ToolName, input.ToolInput); err != nil {
+			return types.NewPreToolUseOutput("deny", err.Error(), nil), nil
+		}
+		return &types.HookOutput{}, nil
+	})
+}
+
+func (l *BashAllowlist) checkTool(toolName string, input map[string]any) error {
+	if toolName != NameBash {
+		return nil
+	}
+	var bash BashInput
+	if err := Decode(input, &bash); err != nil {
+		return &CommandNotAllowedError{Reason: err.Error()}
+	}
+	return l.Check(bash.Command)
+}
@@ -0,0 +1,824 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BashAnalysis describes what a Bash tool command line executes.
+type BashAnalysis struct {
+	// Commands lists every simple command in execution order, including commands
+	// inside subshells, groups and command/process substitutions. Entries with an
+	// empty Name are bare assignments or redirections (e.g. "FOO=1" or "> file").
+	Commands []Command
+
+	// Substitutions holds the raw text of every $(...), `...`, <(...) and >(...).
+	Substitutions []string
+
+	// Subshell is true if the command line contains a ( ... ) subshell.
+	Subshell bool
+
+	// Background is true if any command is run asynchronously with "&".
+	Background bool
+}
+
+// Command is a single simple command.
+type Command struct {
+	Name        string     // Executable as written, with quoting removed
+	Args        []string   // Arguments with quoting removed; expansions are left verbatim
+	Words       []Word     // Name followed by Args, with expansion details
+	Assignments []string   // Leading NAME=value assignments
+	Redirects   []Redirect // Redirections in the order written
+	Raw         string     // Source text of the command
+
+	// Operator is the control operator that follows the command: "", ";", "&",
+	// "&&", "||", "|" or "|&". A newline is reported as ";".
+	Operator string
+
+	// Nested is true for commands inside a subshell, group or substitution.
+	Nested bool
+}
+
+// Word is a single shell word.
+type Word struct {
+	Value    string // Text with quoting removed
+	Raw      string // Source text
+	Expanded bool   // Contains parameter, arithmetic or command expansion, so the runtime value is unknown
+
+	quoted bool
+}
+
+// Redirect is a single I/O redirection.
+type Redirect struct {
+	FD     string // Explicit file descriptor, e.g. "2" in "2>err"; empty for the default
+	Op     string // One of < > >> >| <> <& >& &> &>> << <<- <<<
+	Target string // File, descriptor or here-document delimiter, with quoting removed
+}
+
+// WritesFile reports whether the redirection writes to a file. Duplicating a
+// descriptor (2>&1) and writing to /dev/null, /dev/stdout or /dev/stderr do not count.
+func (r Redirect) WritesFile() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "&>", "&>>", "<>":
+	case ">&":
+		if isFDTarget(r.Target) {
+			return false
+		}
+	default:
+		return false
+	}
+	switch r.Target {
+	case "/dev/null", "/dev/stdout", "/dev/stderr":
+		return false
+	}
+	return true
+}
+
+// Executables returns the distinct command names in execution order.
+func (a *BashAnalysis) Executables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, cmd := range a.Commands {
+		if cmd.Name != "" && !seen[cmd.Name] {
+			seen[cmd.Name] = true
+			names = append(names, cmd.Name)
+		}
+	}
+	return names
+}
+
+// Redirects returns every redirection in the command line.
+func (a *BashAnalysis) Redirects() []Redirect {
+	var redirects []Redirect
+	for _, cmd := range a.Commands {
+		redirects = append(redirects, cmd.Redirects...)
+	}
+	return redirects
+}
+
+// FileWrites returns the redirections that write to files.
+func (a *BashAnalysis) FileWrites() []Redirect {
+	var writes []Redirect
+	for _, r := range a.Redirects() {
+		if r.WritesFile() {
+			writes = append(writes, r)
+		}
+	}
+	return writes
+}
+
+// HasSubstitution reports whether the command line contains command or process substitution.
+func (a *BashAnalysis) HasSubstitution() bool {
+	return len(a.Substitutions) > 0
+}
+
+// BashSyntaxError is returned by ParseBash for command lines it cannot parse.
+type BashSyntaxError struct {
+	Offset  int // Byte offset in the command line
+	Message string
+}
+
+func (e *BashSyntaxError) Error() string {
+	return fmt.Sprintf("bash syntax error at offset %d: %s", e.Offset, e.Message)
+}
+
+// ParseBash parses a Bash command line such as the "command" input of the Bash tool.
+//
+// It splits lists and pipelines (; && || | &), descends into subshells, groups,
+// command substitution, process substitution and unquoted here-documents, and
+// records redirections and expansions. It does not evaluate anything. Constructs
+// that cannot be analyzed reliably (case statements, function definitions,
+// coprocesses) are rejected with a *BashSyntaxError, so callers making permission
+// decisions should deny on error.
+func ParseBash(command string) (*BashAnalysis, error) {
+	p := &bashParser{src: command, analysis: &BashAnalysis{}}
+	if err := p.parseList(0); err != nil {
+		return nil, err
+	}
+	return p.analysis, nil
+}
+
+type bashParser struct {
+	src      string
+	pos      int
+	nested   bool
+	analysis *BashAnalysis
+	heredocs []pendingHeredoc
+}
+
+type pendingHeredoc struct {
+	delim     string
+	stripTabs bool
+	expand    bool
+}
+
+// redirectOps is ordered so that longer operators match first.
+var redirectOps = []string{"&>>", "&>", "<<<", "<<-", "<<", "<>", "<&", "<", ">>", ">|", ">&", ">"}
+
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[^]]*\])?\+?=`)
+
+func (p *bashParser) errorf(format string, args ...any) error {
+	return &BashSyntaxError{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *bashParser) peek(offset int) byte {
+	if i := p.pos + offset; i < len(p.src) {
+		return p.src[i]
+	}
+	return 0
+}
+
+func (p *bashParser) atEnd() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *bashParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// skipBlanks skips spaces, tabs and line continuations.
+func (p *bashParser) skipBlanks() {
+	for !p.atEnd() {
+		switch {
+		case p.src[p.pos] == ' ' || p.src[p.pos] == '\t':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (p *bashParser) skipComment() {
+	for !p.atEnd() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipNewlines skips blanks, comments and newlines, reading any pending here-documents.
+func (p *bashParser) skipNewlines() {
+	for {
+		p.skipBlanks()
+		switch p.peek(0) {
+		case '#':
+			p.skipComment()
+		case '\n':
+			p.pos++
+			p.readHeredocs()
+		default:
+			return
+		}
+	}
+}
+
+// parseList parses commands until term (or end of input when term is 0).
+func (p *bashParser) parseList(term byte) error {
+	for {
+		p.skipNewlines()
+		if p.atEnd() {
+			if term != 0 {
+				return p.errorf("missing %q", term)
+			}
+			return nil
+		}
+
+		switch c := p.src[p.pos]; {
+		case term != 0 && c == term:
+			p.pos++
+			return nil
+		case c == ')' || c == ';' || c == '&' || c == '|':
+			return p.errorf("unexpected %q", c)
+		}
+
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return err
+		}
+		op, err := p.parseOperator()
+		if err != nil {
+			return err
+		}
+		cmd.Operator = op
+		if cmd.Name != "" || len(cmd.Assignments) > 0 || len(cmd.Redirects) > 0 {
+			p.analysis.Commands = append(p.analysis.Commands, *cmd)
+		}
+
+		switch op {
+		case "&":
+			p.analysis.Background = true
+		case "&&", "||", "|", "|&":
+			p.skipNewlines()
+			if p.atEnd() || (term != 0 && p.src[p.pos] == term) {
+				return p.errorf("missing command after %q", op)
+			}
+		}
+	}
+}
+
+// parseOperator consumes the control operator following a command.
+func (p *bashParser) parseOperator() (string, error) {
+	p.skipBlanks()
+	if p.peek(0) == '#' {
+		p.skipComment()
+	}
+	if p.atEnd() {
+		return "", nil
+	}
+	for _, op := range []string{"&&", "||", "|&"} {
+		if p.hasPrefix(op) {
+			p.pos += len(op)
+			return op, nil
+		}
+	}
+	switch c := p.src[p.pos]; c {
+	case ';':
+		if p.peek(1) == ';' {
+			return "", p.errorf("unsupported shell syntax: case statement")
+		}
+		p.pos++
+		return ";", nil
+	case '|', '&':
+		p.pos++
+		return string(c), nil
+	case '\n':
+		p.pos++
+		p.readHeredocs()
+		return ";", nil
+	case ')':
+		return "", nil
+	default:
+		return "", p.errorf("unexpected %q", c)
+	}
+}
+
+// parseCommand parses a simple command, subshell or arithmetic command.
+func (p *bashParser) parseCommand() (*Command, error) {
+	p.skipBlanks()
+	start := p.pos
+	cmd := &Command{Nested: p.nested}
+	finish := func() (*Command, error) {
+		cmd.Raw = strings.TrimSpace(p.src[start:p.pos])
+		return cmd, nil
+	}
+
+	if p.peek(0) == '(' {
+		if p.peek(1) == '(' {
+			p.pos += 2
+			if err := p.skipArithmetic(); err != nil {
+				return nil, err
+			}
+		} else {
+			p.pos++
+			p.analysis.Subshell = true
+			if err := p.subParse(')'); err != nil {
+				return nil, err
+			}
+		}
+		// Only redirections may follow a compound command.
+		for {
+			p.skipBlanks()
+			r, ok, err := p.parseRedirect()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return finish()
+			}
+			cmd.Redirects = append(cmd.Redirects, r)
+		}
+	}
+
+	// skipping discards the words of "for NAME in ..." and "select NAME in ..."
+	// while still recording any substitutions they contain.
+	skipping := false
+	for {
+		p.skipBlanks()
+		if p.atEnd() {
+			break
+		}
+		c := p.src[p.pos]
+		if c == '#' {
+			p.skipComment()
+			break
+		}
+		if skipping && p.hasPrefix("((") {
+			p.pos += 2
+			if err := p.skipArithmetic(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		r, ok, err := p.parseRedirect()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cmd.Redirects = append(cmd.Redirects, r)
+			continue
+		}
+
+		if c == '(' {
+			if cmd.Name != "" {
+				return nil, p.errorf("unsupported shell syntax: function definition")
+			}
+			return nil, p.errorf("unexpected %q", c)
+		}
+		if isBashMeta(c) && !isProcessSubstitution(p.src[p.pos:]) {
+			break
+		}
+
+		w, err := p.parseWord()
+		if err != nil {
+			return nil, err
+		}
+		if skipping {
+			continue
+		}
+		if cmd.Name == "" && len(cmd.Words) == 0 {
+			if !w.Expanded && assignmentPattern.MatchString(w.Raw) {
+				cmd.Assignments = append(cmd.Assignments, w.Value)
+				continue
+			}
+			if !w.quoted && !w.Expanded && len(cmd.Assignments) == 0 {
+				switch w.Value {
+				case "!", "{", "}", "if", "then", "elif", "else", "fi", "while", "until", "do", "done", "time":
+					continue
+				case "for", "select":
+					skipping = true
+					continue
+				case "case", "esac":
+					return nil, p.errorf("unsupported shell syntax: case statement")
+				case "function":
+					return nil, p.errorf("unsupported shell syntax: function definition")
+				case "coproc":
+					return nil, p.errorf("unsupported shell syntax: coprocess")
+				}
+			}
+			cmd.Name = w.Value
+		} else {
+			cmd.Args = append(cmd.Args, w.Value)
+		}
+		cmd.Words = append(cmd.Words, w)
+	}
+	return finish()
+}
+
+// subParse parses a nested list terminated by term.
+func (p *bashParser) subParse(term byte) error {
+	nested := p.nested
+	p.nested = true
+	err := p.parseList(term)
+	p.nested = nested
+	return err
+}
+
+// parseRedirect parses a redirection at the current position, if there is one.
+func (p *bashParser) parseRedirect() (Redirect, bool, error) {
+	i := p.pos
+	for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+		i++
+	}
+	fd := p.src[p.pos:i]
+	rest := p.src[i:]
+
+	var op string
+	for _, candidate := range redirectOps {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" || (fd != "" && op[0] == '&') || (fd == "" && isProcessSubstitution(rest)) {
+		return Redirect{}, false, nil
+	}
+
+	p.pos = i + len(op)
+	p.skipBlanks()
+	if p.atEnd() || (isBashMeta(p.src[p.pos]) && !isProcessSubstitution(p.src[p.pos:])) {
+		return Redirect{}, false, p.errorf("missing target for %q", op)
+	}
+	w, err := p.parseWord()
+	if err != nil {
+		return Redirect{}, false, err
+	}
+	if op == "<<" || op == "<<-" {
+		p.heredocs = append(p.heredocs, pendingHeredoc{delim: w.Value, stripTabs: op == "<<-", expand: !w.quoted})
+	}
+	return Redirect{FD: fd, Op: op, Target: w.Value}, true, nil
+}
+
+// readHeredocs consumes the bodies of pending here-documents after a newline.
+func (p *bashParser) readHeredocs() {
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, h := range pending {
+		bodyStart := p.pos
+		bodyEnd := len(p.src)
+		for !p.atEnd() {
+			lineEnd := strings.IndexByte(p.src[p.pos:], '\n')
+			next := len(p.src)
+			line := p.src[p.pos:]
+			if lineEnd >= 0 {
+				line = p.src[p.pos : p.pos+lineEnd]
+				next = p.pos + lineEnd + 1
+			}
+			if h.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delim {
+				bodyEnd = p.pos
+				p.pos = next
+				break
+			}
+			p.pos = next
+		}
+		if h.expand {
+			// Unquoted delimiters make the body subject to expansion, including
+			// command substitution, as if it were double-quoted.
+			body := &bashParser{src: p.src[bodyStart:bodyEnd], nested: true, analysis: p.analysis}
+			var discard strings.Builder
+			_, _ = body.parseDoubleQuoted(&discard, 0)
+		}
+	}
+}
+
+// parseWord parses one word, removing quotes and recording substitutions.
+func (p *bashParser) parseWord() (Word, error) {
+	start := p.pos
+	var b strings.Builder
+	var w Word
+
+	if isProcessSubstitution(p.src[p.pos:]) {
+		p.pos += 2
+		if err := p.parseSubstitution(start); err != nil {
+			return w, err
+		}
+		b.WriteString(p.src[start:p.pos])
+		w.Expanded = true
+	}
+
+	for !p.atEnd() {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || isBashMeta(c) {
+			break
+		}
+		switch c {
+		case '\\':
+			switch {
+			case p.peek(1) == '\n':
+				p.pos += 2
+			case p.pos+1 < len(p.src):
+				b.WriteByte(p.src[p.pos+1])
+				p.pos += 2
+				w.quoted = true
+			default:
+				p.pos++
+			}
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return w, p.errorf("unterminated single quote")
+			}
+			b.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+			w.quoted = true
+		case '"':
+			p.pos++
+			expanded, err := p.parseDoubleQuoted(&b, '"')
+			if err != nil {
+				return w, err
+			}
+			w.Expanded = w.Expanded || expanded
+			w.quoted = true
+		case '$':
+			if p.peek(1) == '\'' {
+				if err := p.parseANSIQuoted(&b); err != nil {
+					return w, err
+				}
+				w.quoted = true
+				continue
+			}
+			expanded, err := p.parseDollar(&b)
+			if err != nil {
+				return w, err
+			}
+			w.Expanded = w.Expanded || expanded
+		case '`':
+			if err := p.parseBacktick(&b); err != nil {
+				return w, err
+			}
+			w.Expanded = true
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	w.Value = b.String()
+	w.Raw = p.src[start:p.pos]
+	return w, nil
+}
+
+// parseDoubleQuoted parses the inside of a double-quoted string up to term, or to
+// the end of input when term is 0 (used for here-document bodies).
+func (p *bashParser) parseDoubleQuoted(b *strings.Builder, term byte) (bool, error) {
+	expanded := false
+	for !p.atEnd() {
+		c := p.src[p.pos]
+		switch {
+		case term != 0 && c == term:
+			p.pos++
+			return expanded, nil
+		case c == '\\':
+			switch next := p.peek(1); next {
+			case '\n':
+				p.pos += 2
+			case '$', '`', '"', '\\':
+				b.WriteByte(next)
+				p.pos += 2
+			default:
+				b.WriteByte(c)
+				p.pos++
+			}
+		case c == '$':
+			e, err := p.parseDollar(b)
+			if err != nil {
+				return expanded, err
+			}
+			expanded = expanded || e
+		case c == '`':
+			if err := p.parseBacktick(b); err != nil {
+				return expanded, err
+			}
+			expanded = true
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	if term != 0 {
+		return expanded, p.errorf("unterminated double quote")
+	}
+	return expanded, nil
+}
+
+// parseDollar parses a $ expansion. It returns false if the $ is literal.
+func (p *bashParser) parseDollar(b *strings.Builder) (bool, error) {
+	start := p.pos
+	switch {
+	case p.hasPrefix("$(("):
+		p.pos += 3
+		if err := p.skipArithmetic(); err != nil {
+			return false, err
+		}
+	case p.hasPrefix("$("):
+		p.pos += 2
+		if err := p.parseSubstitution(start); err != nil {
+			return false, err
+		}
+	case p.hasPrefix("${"):
+		p.pos += 2
+		if err := p.skipParameter(); err != nil {
+			return false, err
+		}
+	case isNameStart(p.peek(1)):
+		p.pos++
+		for !p.atEnd() && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+	case strings.IndexByte("0123456789@*#?$!-", p.peek(1)) >= 0 && p.peek(1) != 0:
+		p.pos += 2
+	default:
+		b.WriteByte('$')
+		p.pos++
+		return false, nil
+	}
+	b.WriteString(p.src[start:p.pos])
+	return true, nil
+}
+
+// parseANSIQuoted parses $'...' and writes the decoded text.
+func (p *bashParser) parseANSIQuoted(b *strings.Builder) error {
+	p.pos += 2
+	for !p.atEnd() {
+		c := p.src[p.pos]
+		switch c {
+		case '\'':
+			p.pos++
+			return nil
+		case '\\':
+			next := p.peek(1)
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case 0:
+				return p.errorf("unterminated $'...' string")
+			default:
+				b.WriteByte(next)
+			}
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return p.errorf("unterminated $'...' string")
+}
+
+// parseSubstitution parses the body of $( or a process substitution and records it.
+func (p *bashParser) parseSubstitution(start int) error {
+	if err := p.subParse(')'); err != nil {
+		return err
+	}
+	p.analysis.Substitutions = append(p.analysis.Substitutions, p.src[start:p.pos])
+	return nil
+}
+
+// parseBacktick parses a `...` substitution and records it.
+func (p *bashParser) parseBacktick(b *strings.Builder) error {
+	start := p.pos
+	p.pos++
+	var inner strings.Builder
+	for {
+		if p.atEnd() {
+			return p.errorf("unterminated backquote")
+		}
+		c := p.src[p.pos]
+		if c == '`' {
+			p.pos++
+			break
+		}
+		if c == '\\' && strings.IndexByte("`$\\", p.peek(1)) >= 0 && p.peek(1) != 0 {
+			inner.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		inner.WriteByte(c)
+		p.pos++
+	}
+
+	sub := &bashParser{src: inner.String(), nested: true, analysis: p.analysis}
+	if err := sub.parseList(0); err != nil {
+		return p.errorf("in command substitution %s: %v", p.src[start:p.pos], err)
+	}
+	p.analysis.Substitutions = append(p.analysis.Substitutions, p.src[start:p.pos])
+	b.WriteString(p.src[start:p.pos])
+	return nil
+}
+
+// skipArithmetic skips the body of (( ... )) or $(( ... )) after the opening parens.
+func (p *bashParser) skipArithmetic() error {
+	depth := 0
+	var discard strings.Builder
+	for !p.atEnd() {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+			p.pos++
+		case ')':
+			if depth == 0 {
+				if p.peek(1) != ')' {
+					return p.errorf("malformed arithmetic expression")
+				}
+				p.pos += 2
+				return nil
+			}
+			depth--
+			p.pos++
+		case '$':
+			if _, err := p.parseDollar(&discard); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.parseBacktick(&discard); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	return p.errorf("unterminated arithmetic expression")
+}
+
+// skipParameter skips the body of ${ ... } after the opening brace.
+func (p *bashParser) skipParameter() error {
+	var discard strings.Builder
+	for !p.atEnd() {
+		switch c := p.src[p.pos]; c {
+		case '}':
+			p.pos++
+			return nil
+		case '\\':
+			p.pos += 2
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return p.errorf("unterminated single quote")
+			}
+			p.pos += end + 2
+		case '"':
+			p.pos++
+			if _, err := p.parseDoubleQuoted(&discard, '"'); err != nil {
+				return err
+			}
+		case '$':
+			if _, err := p.parseDollar(&discard); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.parseBacktick(&discard); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	return p.errorf("unterminated ${")
+}
+
+func isBashMeta(c byte) bool {
+	switch c {
+	case ';', '&', '|', '<', '>', '(', ')', '\n':
+		return true
+	}
+	return false
+}
+
+func isProcessSubstitution(s string) bool {
+	return strings.HasPrefix(s, "<(") || strings.HasPrefix(s, ">(")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func isFDTarget(s string) bool {
+	if s == "-" {
+		return true
+	}
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func mustParseBash(t *testing.T, command string) *BashAnalysis {
+	t.Helper()
+	analysis, err := ParseBash(command)
+	if err != nil {
+		t.Fatalf("ParseBash(%q) failed: %v", command, err)
+	}
+	return analysis
+}
+
+func TestParseBash_Executables(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"git status", []string{"git"}},
+		{"git status; rm -rf /", []string{"git", "rm"}},
+		{"make && ./run || echo failed", []string{"make", "./run", "echo"}},
+		{"cat a | grep b |& tee c", []string{"cat", "grep", "tee"}},
+		{"sleep 1 & wait", []string{"sleep", "wait"}},
+		{"ls\npwd\n", []string{"ls", "pwd"}},
+		{"(cd sub && make)", []string{"cd", "make"}},
+		{"{ ls; pwd; }", []string{"ls", "pwd"}},
+		{"echo $(whoami)", []string{"whoami", "echo"}},
+		{"echo `id`", []string{"id", "echo"}},
+		{`echo "today is $(date +%F)"`, []string{"date", "echo"}},
+		{"diff <(ls a) >(wc -l)", []string{"ls", "wc", "diff"}},
+		{"echo $(echo $(uname))", []string{"uname", "echo"}},
+		{"if test -f x; then cat x; else touch x; fi", []string{"test", "cat", "touch"}},
+		{"while read l; do echo $l; done < in", []string{"read", "echo"}},
+		{"for f in *.go; do gofmt -l $f; done", []string{"gofmt"}},
+		{"for f in $(ls); do rm $f; done", []string{"ls", "rm"}},
+		{"for ((i=0; i<3; i++)); do echo $i; done", []string{"echo"}},
+		{"! grep -q x file", []string{"grep"}},
+		{"time go test ./...", []string{"go"}},
+		{"ls # ; rm -rf /", []string{"ls"}},
+		{"'r''m' -rf x", []string{"rm"}},
+		{`r\m -rf x`, []string{"rm"}},
+		{"git \\\n  status", []string{"git"}},
+		{"echo ${HOME:-$(id -u)}", []string{"id", "echo"}},
+		{"echo $((1 + $(nproc)))", []string{"nproc", "echo"}},
+		{"(( x++ )) && ls", []string{"ls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := mustParseBash(t, tt.command).Executables()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Executables() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBash_CommandDetails(t *testing.T) {
+	analysis := mustParseBash(t, `GIT_PAGER=cat git log --format="%h %s" -n 3 2>/dev/null && echo done`)
+	if len(analysis.Commands) != 2 {
+		t.Fatalf("expected 2 commands, got %+v", analysis.Commands)
+	}
+
+	git := analysis.Commands[0]
+	if git.Name != "git" {
+		t.Fatalf("Name = %q", git.Name)
+	}
+	if want := []string{"log", "--format=%h %s", "-n", "3"}; !reflect.DeepEqual(git.Args, want) {
+		t.Fatalf("Args = %q, want %q", git.Args, want)
+	}
+	if want := []string{"GIT_PAGER=cat"}; !reflect.DeepEqual(git.Assignments, want) {
+		t.Fatalf("Assignments = %q, want %q", git.Assignments, want)
+	}
+	if want := []Redirect{{FD: "2", Op: ">", Target: "/dev/null"}}; !reflect.DeepEqual(git.Redirects, want) {
+		t.Fatalf("Redirects = %+v, want %+v", git.Redirects, want)
+	}
+	if git.Operator != "&&" || git.Nested {
+		t.Fatalf("Operator = %q, Nested = %v", git.Operator, git.Nested)
+	}
+	if git.Raw != `GIT_PAGER=cat git log --format="%h %s" -n 3 2>/dev/null` {
+		t.Fatalf("Raw = %q", git.Raw)
+	}
+	if analysis.Commands[1].Operator != "" {
+		t.Fatalf("last Operator = %q", analysis.Commands[1].Operator)
+	}
+}
+
+func TestParseBash_Words(t *testing.T) {
+	analysis := mustParseBash(t, `$CMD "$HOME/x" 'lit $HOME' \$HOME $'a\tb' "a\"b"`)
+	words := analysis.Commands[0].Words
+	want := []struct {
+		value    string
+		expanded bool
+	}{
+		{"$CMD", true},
+		{"$HOME/x", true},
+		{"lit $HOME", false},
+		{"$HOME", false},
+		{"a\tb", false},
+		{`a"b`, false},
+	}
+	if len(words) != len(want) {
+		t.Fatalf("got %d words: %+v", len(words), words)
+	}
+	for i, w := range want {
+		if words[i].Value != w.value || words[i].Expanded != w.expanded {
+			t.Errorf("word %d = {%q, %v}, want {%q, %v}", i, words[i].Value, words[i].Expanded, w.value, w.expanded)
+		}
+	}
+}
+
+func TestParseBash_Nested(t *testing.T) {
+	analysis := mustParseBash(t, "ls; (cd /tmp; pwd); echo $(date)")
+	nested := map[string]bool{}
+	for _, cmd := range analysis.Commands {
+		nested[cmd.Name] = cmd.Nested
+	}
+	want := map[string]bool{"ls": false, "cd": true, "pwd": true, "date": true, "echo": false}
+	if !reflect.DeepEqual(nested, want) {
+		t.Fatalf("nested = %v, want %v", nested, want)
+	}
+	if !analysis.Subshell {
+		t.Fatal("expected Subshell")
+	}
+	if want := []string{"$(date)"}; !reflect.DeepEqual(analysis.Substitutions, want) {
+		t.Fatalf("Substitutions = %q, want %q", analysis.Substitutions, want)
+	}
+}
+
+func TestParseBash_Flags(t *testing.T) {
+	if a := mustParseBash(t, "git status"); a.HasSubstitution() || a.Subshell || a.Background {
+		t.Fatalf("unexpected flags: %+v", a)
+	}
+	if a := mustParseBash(t, "server &"); !a.Background {
+		t.Fatal("expected Background")
+	}
+	if a := mustParseBash(t, "echo `x`"); !a.HasSubstitution() {
+		t.Fatal("expected substitution")
+	}
+	if a := mustParseBash(t, "echo '$(x)' \"\\$(y)\""); a.HasSubstitution() {
+		t.Fatalf("quoted substitution should be literal: %q", a.Substitutions)
+	}
+}
+
+func TestParseBash_Redirects(t *testing.T) {
+	tests := []struct {
+		command string
+		want    Redirect
+		writes  bool
+	}{
+		{"echo x > out", Redirect{Op: ">", Target: "out"}, true},
+		{"echo x >>log", Redirect{Op: ">>", Target: "log"}, true},
+		{"echo x >| out", Redirect{Op: ">|", Target: "out"}, true},
+		{"make &> build.log", Redirect{Op: "&>", Target: "build.log"}, true},
+		{"make &>> build.log", Redirect{Op: "&>>", Target: "build.log"}, true},
+		{"make >& build.log", Redirect{Op: ">&", Target: "build.log"}, true},
+		{"make 2>&1", Redirect{FD: "2", Op: ">&", Target: "1"}, false},
+		{"make 2> /dev/null", Redirect{FD: "2", Op: ">", Target: "/dev/null"}, false},
+		{"echo x >/dev/stderr", Redirect{Op: ">", Target: "/dev/stderr"}, false},
+		{"exec 3<> sock", Redirect{FD: "3", Op: "<>", Target: "sock"}, true},
+		{"sort < in", Redirect{Op: "<", Target: "in"}, false},
+		{"wc -c <<< 'text'", Redirect{Op: "<<<", Target: "text"}, false},
+		{`echo x > "my file"`, Redirect{Op: ">", Target: "my file"}, true},
+		{"echo a2>x", Redirect{Op: ">", Target: "x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			redirects := mustParseBash(t, tt.command).Redirects()
+			if len(redirects) != 1 || redirects[0] != tt.want {
+				t.Fatalf("Redirects() = %+v, want [%+v]", redirects, tt.want)
+			}
+			if got := redirects[0].WritesFile(); got != tt.writes {
+				t.Fatalf("WritesFile() = %v, want %v", got, tt.writes)
+			}
+		})
+	}
+
+	if writes := mustParseBash(t, "> truncated").FileWrites(); len(writes) != 1 {
+		t.Fatalf("bare redirection not reported: %+v", writes)
+	}
+	if writes := mustParseBash(t, "(ls; pwd) > listing").FileWrites(); len(writes) != 1 || writes[0].Target != "listing" {
+		t.Fatalf("subshell redirection not reported: %+v", writes)
+	}
+}
+
+func TestParseBash_Heredocs(t *testing.T) {
+	analysis := mustParseBash(t, "cat <<EOF > out\nhello $(whoami)\n; rm -rf /\nEOF\necho after")
+	if got, want := analysis.Executables(), []string{"whoami", "cat", "echo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Executables() = %q, want %q", got, want)
+	}
+
+	analysis = mustParseBash(t, "cat <<'EOF'\n$(whoami)\nEOF")
+	if analysis.HasSubstitution() {
+		t.Fatalf("quoted heredoc should not expand: %q", analysis.Substitutions)
+	}
+
+	analysis = mustParseBash(t, "cat <<-END\n\tbody\n\tEND\nls")
+	if got, want := analysis.Executables(), []string{"cat", "ls"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Executables() = %q, want %q", got, want)
+	}
+}
+
+func TestParseBash_Errors(t *testing.T) {
+	commands := []string{
+		"echo 'unterminated",
+		`echo "unterminated`,
+		"echo $(unterminated",
+		"echo `unterminated",
+		"(ls",
+		"ls)",
+		"; ls",
+		"ls &&",
+		"ls | ",
+		"ls >",
+		"ls ;; pwd",
+		"case $x in a) rm x;; esac",
+		"f() { rm -rf /; }; f",
+		"function f { ls; }",
+		"coproc cat",
+		"echo ${unterminated",
+		"echo $((1 + 2)",
+	}
+	for _, command := range commands {
+		t.Run(command, func(t *testing.T) {
+			_, err := ParseBash(command)
+			var syntaxErr *BashSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected BashSyntaxError, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// newInput returns a zero-valued typed input for a built-in tool, or nil if unknown.
+func newInput(toolName string) Input {
+	switch toolName {
+	case NameBash:
+		return &BashInput{}
+	case NameRead:
+		return &ReadInput{}
+	case NameWrite:
+		return &WriteInput{}
+	case NameEdit:
+		return &EditInput{}
+	case NameGlob:
+		return &GlobInput{}
+	case NameGrep:
+		return &GrepInput{}
+	case NameWebFetch:
+		return &WebFetchInput{}
+	case NameTask:
+		return &TaskInput{}
+	case NameTodoWrite:
+		return &TodoWriteInput{}
+	case NameNotebookEdit:
+		return &NotebookEditInput{}
+	default:
+		return nil
+	}
+}
+
+// newResult returns a zero-valued typed result for a built-in tool, or nil if unknown.
+func newResult(toolName string) Result {
+	switch toolName {
+	case NameBash:
+		return &BashResult{}
+	case NameRead:
+		return &ReadResult{}
+	case NameWrite:
+		return &WriteResult{}
+	case NameEdit:
+		return &EditResult{}
+	case NameGlob:
+		return &GlobResult{}
+	case NameGrep:
+		return &GrepResult{}
+	case NameWebFetch:
+		return &WebFetchResult{}
+	case NameTask:
+		return &TaskResult{}
+	case NameTodoWrite:
+		return &TodoWriteResult{}
+	default:
+		return nil
+	}
+}
+
+// DecodeInput decodes a raw tool input into the typed input for toolName.
+// Tools without a typed model decode to *UnknownInput.
+func DecodeInput(toolName string, input map[string]any) (Input, error) {
+	typed := newInput(toolName)
+	if typed == nil {
+		return &UnknownInput{Name: toolName, Raw: input}, nil
+	}
+	if err := Decode(input, typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// DecodeResult decodes a raw tool response into the typed result for toolName.
+// Tools without a typed model, and responses that are not JSON objects, decode
+// to *UnknownResult.
+func DecodeResult(toolName string, response any) (Result, error) {
+	raw, ok := response.(map[string]any)
+	if !ok {
+		return &UnknownResult{Name: toolName, Raw: response}, nil
+	}
+	typed := newResult(toolName)
+	if typed == nil {
+		return &UnknownResult{Name: toolName, Raw: response}, nil
+	}
+	if err := Decode(raw, typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// DecodeToolUse decodes the input of a ToolUseBlock.
+func DecodeToolUse(block *types.ToolUseBlock) (Input, error) {
+	return DecodeInput(block.Name, block.ToolInput)
+}
+
+// DecodePostToolUse decodes both the input and the response of a PostToolUse hook input.
+func DecodePostToolUse(input *types.PostToolUseHookInput) (Input, Result, error) {
+	typedInput, err := DecodeInput(input.ToolName, input.ToolInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	typedResult, err := DecodeResult(input.ToolName, input.ToolResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+	return typedInput, typedResult, nil
+}
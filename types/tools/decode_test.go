@@ -0,0 +1,204 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func TestDecodeInput_KnownTools(t *testing.T) {
+	tests := []struct {
+		toolName string
+		input    map[string]any
+		check    func(t *testing.T, in Input)
+	}{
+		{NameBash, map[string]any{"command": "ls"}, func(t *testing.T, in Input) {
+			if b, ok := in.(*BashInput); !ok || b.Command != "ls" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameRead, map[string]any{"file_path": "/a"}, func(t *testing.T, in Input) {
+			if r, ok := in.(*ReadInput); !ok || r.FilePath != "/a" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameWrite, map[string]any{"file_path": "/b", "content": "x"}, func(t *testing.T, in Input) {
+			if w, ok := in.(*WriteInput); !ok || w.Content != "x" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameEdit, map[string]any{"file_path": "/c", "old_string": "a", "new_string": "b", "replace_all": true}, func(t *testing.T, in Input) {
+			if e, ok := in.(*EditInput); !ok || !e.ReplaceAll || e.NewString != "b" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameNotebookEdit, map[string]any{"notebook_path": "/d.ipynb", "new_source": "x", "edit_mode": "insert"}, func(t *testing.T, in Input) {
+			if n, ok := in.(*NotebookEditInput); !ok || n.Path() != "/d.ipynb" || n.EditMode != "insert" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameGrep, map[string]any{"pattern": "x", "output_mode": "count"}, func(t *testing.T, in Input) {
+			if g, ok := in.(*GrepInput); !ok || g.OutputMode != "count" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameTask, map[string]any{"description": "d", "prompt": "p", "subagent_type": "explorer"}, func(t *testing.T, in Input) {
+			if task, ok := in.(*TaskInput); !ok || task.SubagentType != "explorer" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+		{NameTodoWrite, map[string]any{"todos": []any{
+			map[string]any{"content": "write tests", "status": "in_progress", "activeForm": "Writing tests"},
+		}}, func(t *testing.T, in Input) {
+			todo, ok := in.(*TodoWriteInput)
+			if !ok || len(todo.Todos) != 1 || todo.Todos[0].Status != "in_progress" {
+				t.Fatalf("unexpected input: %#v", in)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.toolName, func(t *testing.T) {
+			in, err := DecodeInput(tt.toolName, tt.input)
+			if err != nil {
+				t.Fatalf("DecodeInput failed: %v", err)
+			}
+			if in.ToolName() != tt.toolName {
+				t.Fatalf("ToolName() = %q, want %q", in.ToolName(), tt.toolName)
+			}
+			tt.check(t, in)
+		})
+	}
+}
+
+func TestDecodeInput_UnknownTool(t *testing.T) {
+	raw := map[string]any{"sql": "select 1"}
+	in, err := DecodeInput("mcp__db__query", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, ok := in.(*UnknownInput)
+	if !ok {
+		t.Fatalf("expected *UnknownInput, got %T", in)
+	}
+	if unknown.ToolName() != "mcp__db__query" || unknown.Raw["sql"] != "select 1" {
+		t.Fatalf("unexpected unknown input: %+v", unknown)
+	}
+}
+
+func TestDecodeResult_KnownTools(t *testing.T) {
+	bash, err := DecodeResult(NameBash, map[string]any{"stdout": "ok\n", "stderr": "", "interrupted": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := bash.(*BashResult); !ok || r.Stdout != "ok\n" {
+		t.Fatalf("unexpected bash result: %#v", bash)
+	}
+
+	read, err := DecodeResult(NameRead, map[string]any{
+		"type": "text",
+		"file": map[string]any{"filePath": "/a.go", "content": "package a", "numLines": float64(1), "startLine": float64(1), "totalLines": float64(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := read.(*ReadResult); !ok || r.File.FilePath != "/a.go" || r.File.TotalLines != 1 {
+		t.Fatalf("unexpected read result: %#v", read)
+	}
+
+	edit, err := DecodeResult(NameEdit, map[string]any{
+		"filePath":  "/a.go",
+		"oldString": "a",
+		"newString": "b",
+		"structuredPatch": []any{map[string]any{
+			"oldStart": float64(1), "oldLines": float64(1), "newStart": float64(1), "newLines": float64(1),
+			"lines": []any{"-a", "+b"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := edit.(*EditResult); !ok || len(r.StructuredPatch) != 1 || r.StructuredPatch[0].Lines[1] != "+b" {
+		t.Fatalf("unexpected edit result: %#v", edit)
+	}
+
+	task, err := DecodeResult(NameTask, map[string]any{
+		"content":     []any{map[string]any{"type": "text", "text": "found "}, map[string]any{"type": "text", "text": "it"}},
+		"totalTokens": float64(1200),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := task.(*TaskResult); !ok || r.Text() != "found it" || r.TotalTokens != 1200 {
+		t.Fatalf("unexpected task result: %#v", task)
+	}
+
+	todos, err := DecodeResult(NameTodoWrite, map[string]any{
+		"oldTodos": []any{},
+		"newTodos": []any{map[string]any{"content": "ship", "status": "completed"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := todos.(*TodoWriteResult); !ok || len(r.NewTodos) != 1 || r.NewTodos[0].Status != "completed" {
+		t.Fatalf("unexpected todo result: %#v", todos)
+	}
+}
+
+func TestDecodeResult_Fallbacks(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolName string
+		response any
+	}{
+		{"unknown tool", "mcp__db__query", map[string]any{"rows": []any{}}},
+		{"string response", NameBash, "Error: command timed out"},
+		{"nil response", NameRead, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeResult(tt.toolName, tt.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unknown, ok := result.(*UnknownResult)
+			if !ok {
+				t.Fatalf("expected *UnknownResult, got %T", result)
+			}
+			if unknown.ToolName() != tt.toolName {
+				t.Fatalf("ToolName() = %q, want %q", unknown.ToolName(), tt.toolName)
+			}
+		})
+	}
+}
+
+func TestDecodeToolUse(t *testing.T) {
+	in, err := DecodeToolUse(&types.ToolUseBlock{ID: "toolu_1", Name: NameGlob, ToolInput: map[string]any{"pattern": "**/*.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := in.(*GlobInput); !ok || g.Pattern != "**/*.go" {
+		t.Fatalf("unexpected input: %#v", in)
+	}
+}
+
+func TestDecodePostToolUse(t *testing.T) {
+	in, result, err := DecodePostToolUse(&types.PostToolUseHookInput{
+		ToolName:     NameWrite,
+		ToolInput:    map[string]any{"file_path": "/a", "content": "x"},
+		ToolResponse: map[string]any{"type": "create", "filePath": "/a", "content": "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := in.(*WriteInput); !ok {
+		t.Fatalf("expected *WriteInput, got %T", in)
+	}
+	if w, ok := result.(*WriteResult); !ok || w.Type != "create" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
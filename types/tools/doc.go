@@ -0,0 +1,40 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package tools provides typed models for Claude Code's built-in tools.
+//
+// Tool inputs arrive as untyped maps in permission callbacks (CanUseToolCallback)
+// and PreToolUse hooks. This package decodes those maps into structs, lets callers
+// modify them, and encodes them back without dropping fields the SDK does not model:
+//
+//	result, err := tools.RewritePermission(input, func(b *tools.BashInput) error {
+//	    b.Command += " --dry-run"
+//	    return nil
+//	})
+//
+// DecodeInput and DecodeResult map a tool name to its typed input and result
+// (ToolUseBlock.ToolInput, PostToolUseHookInput.ToolResponse), falling back to
+// UnknownInput/UnknownResult for MCP tools and tools the SDK does not model:
+//
+//	switch in := input.(type) {
+//	case *tools.BashInput:
+//	    log.Printf("bash: %s", in.Command)
+//	case *tools.UnknownInput:
+//	    log.Printf("%s: %v", in.Name, in.Raw)
+//	}
+//
+// PathGuard confines file tools to the working directory and AddDirs, resolving
+// ".." and symlinks before comparing. Install it as a permission callback or hook:
+//
+//	guard, err := tools.NewPathGuardFromOptions(opts)
+//	client := sdk.NewClient(sdk.WithCanUseTool(guard.CanUseTool(nil)))
+//
+// ParseBash splits a Bash tool command into the simple commands it runs, and
+// BashAllowlist builds a permission check on top of it that allows "git status"
+// without allowing "git status; rm -rf .":
+//
+//	allow := tools.NewBashAllowlist("git status", "git diff", "ls")
+//	client := sdk.NewClient(sdk.WithCanUseTool(allow.CanUseTool(guard.CanUseTool(nil))))
+//
+// The package depends only on the types package.
+package tools
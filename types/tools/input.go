@@ -0,0 +1,160 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+// Built-in tool names as reported in tool_name / ToolUseBlock.Name.
+const (
+	NameBash      = "Bash"
+	NameRead      = "Read"
+	NameWrite     = "Write"
+	NameEdit      = "Edit"
+	NameGlob      = "Glob"
+	NameGrep      = "Grep"
+	NameWebFetch  = "WebFetch"
+	NameTask      = "Task"
+	NameTodoWrite = "TodoWrite"
+
+	NameNotebookEdit = "NotebookEdit"
+)
+
+// Input is implemented by all typed built-in tool inputs.
+type Input interface {
+	ToolName() string
+}
+
+// FileInput is implemented by inputs that target a single filesystem path.
+type FileInput interface {
+	Input
+	Path() string
+	SetPath(path string)
+}
+
+// BashInput is the input for the Bash tool.
+type BashInput struct {
+	Command                   string `json:"command"`
+	Timeout                   *int   `json:"timeout,omitempty"`
+	Description               string `json:"description,omitempty"`
+	RunInBackground           bool   `json:"run_in_background,omitempty"`
+	DangerouslyDisableSandbox bool   `json:"dangerouslyDisableSandbox,omitempty"`
+}
+
+func (i *BashInput) ToolName() string { return NameBash }
+
+// ReadInput is the input for the Read tool.
+type ReadInput struct {
+	FilePath string `json:"file_path"`
+	Offset   *int   `json:"offset,omitempty"`
+	Limit    *int   `json:"limit,omitempty"`
+}
+
+func (i *ReadInput) ToolName() string    { return NameRead }
+func (i *ReadInput) Path() string        { return i.FilePath }
+func (i *ReadInput) SetPath(path string) { i.FilePath = path }
+
+// WriteInput is the input for the Write tool.
+type WriteInput struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+func (i *WriteInput) ToolName() string    { return NameWrite }
+func (i *WriteInput) Path() string        { return i.FilePath }
+func (i *WriteInput) SetPath(path string) { i.FilePath = path }
+
+// EditInput is the input for the Edit tool.
+type EditInput struct {
+	FilePath   string `json:"file_path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+func (i *EditInput) ToolName() string    { return NameEdit }
+func (i *EditInput) Path() string        { return i.FilePath }
+func (i *EditInput) SetPath(path string) { i.FilePath = path }
+
+// NotebookEditInput is the input for the NotebookEdit tool.
+type NotebookEditInput struct {
+	NotebookPath string `json:"notebook_path"`
+	NewSource    string `json:"new_source"`
+	CellID       string `json:"cell_id,omitempty"`
+	CellType     string `json:"cell_type,omitempty"` // "code" or "markdown"
+	EditMode     string `json:"edit_mode,omitempty"` // "replace", "insert" or "delete"
+}
+
+func (i *NotebookEditInput) ToolName() string    { return NameNotebookEdit }
+func (i *NotebookEditInput) Path() string        { return i.NotebookPath }
+func (i *NotebookEditInput) SetPath(path string) { i.NotebookPath = path }
+
+// GlobInput is the input for the Glob tool. Path is optional and defaults to the CLI cwd.
+type GlobInput struct {
+	Pattern string `json:"pattern"`
+	Dir     string `json:"path,omitempty"`
+}
+
+func (i *GlobInput) ToolName() string    { return NameGlob }
+func (i *GlobInput) Path() string        { return i.Dir }
+func (i *GlobInput) SetPath(path string) { i.Dir = path }
+
+// GrepInput is the input for the Grep tool. Path is optional and defaults to the CLI cwd.
+type GrepInput struct {
+	Pattern         string `json:"pattern"`
+	Dir             string `json:"path,omitempty"`
+	Glob            string `json:"glob,omitempty"`
+	FileType        string `json:"type,omitempty"`
+	OutputMode      string `json:"output_mode,omitempty"`
+	CaseInsensitive bool   `json:"-i,omitempty"`
+	LineNumbers     *bool  `json:"-n,omitempty"`
+	After           *int   `json:"-A,omitempty"`
+	Before          *int   `json:"-B,omitempty"`
+	Context         *int   `json:"-C,omitempty"`
+	Multiline       bool   `json:"multiline,omitempty"`
+	HeadLimit       *int   `json:"head_limit,omitempty"`
+	Offset          *int   `json:"offset,omitempty"`
+}
+
+func (i *GrepInput) ToolName() string    { return NameGrep }
+func (i *GrepInput) Path() string        { return i.Dir }
+func (i *GrepInput) SetPath(path string) { i.Dir = path }
+
+// WebFetchInput is the input for the WebFetch tool.
+type WebFetchInput struct {
+	URL    string `json:"url"`
+	Prompt string `json:"prompt"`
+}
+
+func (i *WebFetchInput) ToolName() string { return NameWebFetch }
+
+// TaskInput is the input for the Task (subagent) tool.
+type TaskInput struct {
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	SubagentType string `json:"subagent_type"`
+	Model        string `json:"model,omitempty"`
+}
+
+func (i *TaskInput) ToolName() string { return NameTask }
+
+// Todo is a single entry in a TodoWrite list.
+type Todo struct {
+	Content    string `json:"content"`
+	Status     string `json:"status"` // "pending", "in_progress", "completed"
+	ActiveForm string `json:"activeForm,omitempty"`
+}
+
+// TodoWriteInput is the input for the TodoWrite tool.
+type TodoWriteInput struct {
+	Todos []Todo `json:"todos"`
+}
+
+func (i *TodoWriteInput) ToolName() string { return NameTodoWrite }
+
+// UnknownInput holds the input for tools without a typed model (MCP tools,
+// newer built-ins). Raw is the original input map.
+type UnknownInput struct {
+	Name string
+	Raw  map[string]any
+}
+
+func (i *UnknownInput) ToolName() string { return i.Name }
@@ -0,0 +1,288 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// maxSymlinkHops bounds symlink resolution, matching the Linux ELOOP limit.
+const maxSymlinkHops = 40
+
+// ErrPathOutsideRoots is matched by PathViolationError via errors.Is.
+var ErrPathOutsideRoots = errors.New("path outside allowed directories")
+
+// PathViolationError is returned when a file tool targets a path outside the allowed roots.
+type PathViolationError struct {
+	ToolName string
+	Path     string   // Path as requested by the tool call
+	Resolved string   // Absolute path after resolving "..", "." and symlinks
+	Roots    []string // Resolved allowed roots
+	Reason   string   // Set when the path could not be resolved
+}
+
+func (e *PathViolationError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s denied for path %q: %s", e.ToolName, e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%s denied for path %q (resolves to %s): outside allowed directories %s",
+		e.ToolName, e.Path, e.Resolved, strings.Join(e.Roots, ", "))
+}
+
+func (e *PathViolationError) Is(target error) bool {
+	return target == ErrPathOutsideRoots
+}
+
+// PathGuard restricts file tools (Read, Write, Edit, NotebookEdit, Glob, Grep) to a set of
+// allowed root directories. Paths are resolved the way the kernel would resolve
+// them: relative paths are joined to the base directory and ".." and symlinks are
+// followed component by component, so a symlink inside a root that points outside
+// it is rejected. Paths that do not exist yet (new files) are resolved through
+// their deepest existing ancestor. Paths starting with "~" are rejected, since the
+// CLI expands them against a home directory the guard does not know.
+//
+// Glob patterns are checked up to their first wildcard, relative to the Glob
+// path, and may not contain ".." after a wildcard.
+//
+// Tools without a path (Bash, WebFetch, MCP tools) are not checked.
+type PathGuard struct {
+	base  string
+	roots []string
+}
+
+// NewPathGuard creates a guard allowing access beneath base and any additional roots.
+// Relative tool paths are resolved against base. An empty base uses the process
+// working directory.
+func NewPathGuard(base string, roots ...string) (*PathGuard, error) {
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		base = wd
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base directory %q: %w", base, err)
+	}
+	resolvedBase, err := resolvePath(absBase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base directory %q: %w", base, err)
+	}
+
+	guard := &PathGuard{base: resolvedBase, roots: []string{resolvedBase}}
+	for _, root := range roots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(resolvedBase, root)
+		}
+		resolved, err := resolvePath(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root directory %q: %w", root, err)
+		}
+		guard.roots = append(guard.roots, resolved)
+	}
+	return guard, nil
+}
+
+// NewPathGuardFromOptions creates a guard for the options' Cwd and AddDirs.
+func NewPathGuardFromOptions(opts *types.Options) (*PathGuard, error) {
+	if opts == nil {
+		return NewPathGuard("")
+	}
+	return NewPathGuard(opts.Cwd, opts.AddDirs...)
+}
+
+// Roots returns the resolved allowed roots. The first entry is the base directory.
+func (g *PathGuard) Roots() []string {
+	return append([]string(nil), g.roots...)
+}
+
+// Contains reports whether path resolves to a location beneath an allowed root.
+// It returns the resolved path.
+func (g *PathGuard) Contains(path string) (string, bool, error) {
+	if strings.ContainsRune(path, '\x00') {
+		return "", false, fmt.Errorf("path contains null byte")
+	}
+	if strings.HasPrefix(path, "~") {
+		return "", false, fmt.Errorf("home directory paths are not supported")
+	}
+	if !filepath.IsAbs(path) {
+		// Not filepath.Join: it would collapse "link/.." before the link is resolved.
+		path = g.base + string(filepath.Separator) + path
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", false, err
+	}
+	for _, root := range g.roots {
+		if isWithin(root, resolved) {
+			return resolved, true, nil
+		}
+	}
+	return resolved, false, nil
+}
+
+// Check validates a tool call. It returns a *PathViolationError if the tool
+// targets a path outside the allowed roots, or nil otherwise.
+func (g *PathGuard) Check(toolName string, input map[string]any) error {
+	fileInput, ok := newInput(toolName).(FileInput)
+	if !ok {
+		return nil
+	}
+	if err := Decode(input, fileInput); err != nil {
+		return &PathViolationError{ToolName: toolName, Reason: err.Error()}
+	}
+
+	paths := []string{fileInput.Path()}
+	if glob, ok := fileInput.(*GlobInput); ok && glob.Pattern != "" {
+		if globEscapes(glob.Pattern) {
+			return &PathViolationError{ToolName: toolName, Path: glob.Pattern, Roots: g.Roots(), Reason: `".." after a wildcard`}
+		}
+		pattern := glob.Pattern
+		if !filepath.IsAbs(pattern) && !strings.HasPrefix(pattern, "~") && glob.Dir != "" {
+			// Relative patterns are matched under the Glob path
+			pattern = glob.Dir + string(filepath.Separator) + pattern
+		}
+		paths = append(paths, globStaticPrefix(pattern))
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			// Optional paths default to the CLI working directory.
+			continue
+		}
+		resolved, contained, err := g.Contains(path)
+		if err != nil {
+			return &PathViolationError{ToolName: toolName, Path: path, Roots: g.Roots(), Reason: err.Error()}
+		}
+		if !contained {
+			return &PathViolationError{ToolName: toolName, Path: path, Resolved: resolved, Roots: g.Roots()}
+		}
+	}
+	return nil
+}
+
+// CanUseTool wraps a permission callback so that out-of-root file access is denied
+// before next is consulted. A nil next allows every request that passes the guard.
+func (g *PathGuard) CanUseTool(next types.CanUseToolCallback) types.CanUseToolCallback {
+	return func(toolName string, input map[string]any, ctx *types.ToolPermissionContext) (types.PermissionResult, error) {
+		if err := g.Check(toolName, input); err != nil {
+			return &types.PermissionResultDeny{Behavior: "deny", Message: err.Error()}, nil
+		}
+		if next == nil {
+			return &types.PermissionResultAllow{Behavior: "allow"}, nil
+		}
+		return next(toolName, input, ctx)
+	}
+}
+
+// PreToolUseHook returns a PreToolUse hook callback that denies out-of-root file
+// access and leaves every other decision to the CLI.
+func (g *PathGuard) PreToolUseHook() types.HookCallback {
+	return types.ToGenericCallback(func(input *types.PreToolUseHookInput, toolUseID *string, ctx *types.HookContext) (*types.HookOutput, error) {
+		if err := g.Check(input.ToolName, input.ToolInput); err != nil {
+			return types.NewPreToolUseOutput("deny", err.Error(), nil), nil
+		}
+		return &types.HookOutput{}, nil
+	})
+}
+
+// resolvePath resolves "." and ".." and follows symlinks component by component.
+// Components that do not exist are appended lexically.
+func resolvePath(path string) (string, error) {
+	return resolveWithHops(path, 0)
+}
+
+func resolveWithHops(path string, hops int) (string, error) {
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+
+	// The path is deliberately not cleaned first: filepath.Clean would collapse
+	// "link/.." lexically, while the kernel applies ".." to the link's target.
+	components := splitComponents(path[len(volume):])
+	for i := 0; i < len(components); i++ {
+		component := components[i]
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		candidate := filepath.Join(resolved, component)
+		info, err := os.Lstat(candidate)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+				resolved = candidate
+				continue
+			}
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", fmt.Errorf("too many levels of symbolic links resolving %s", path)
+		}
+		target, err := os.Readlink(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = resolved + string(filepath.Separator) + target
+		}
+		remaining := target
+		if rest := components[i+1:]; len(rest) > 0 {
+			remaining += string(filepath.Separator) + strings.Join(rest, string(filepath.Separator))
+		}
+		return resolveWithHops(remaining, hops)
+	}
+	return resolved, nil
+}
+
+// splitComponents splits a path on the separator, keeping ".." entries.
+func splitComponents(path string) []string {
+	return strings.Split(filepath.ToSlash(path), "/")
+}
+
+// isWithin reports whether path equals root or is beneath it.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// globEscapes reports whether a ".." component follows the first wildcard of a
+// glob pattern, where the static prefix check cannot see it.
+func globEscapes(pattern string) bool {
+	idx := strings.IndexAny(pattern, "*?[{")
+	if idx < 0 {
+		return false
+	}
+	return slices.Contains(splitComponents(pattern[idx:]), "..")
+}
+
+// globStaticPrefix returns the directory portion of a glob pattern that precedes
+// the first wildcard.
+func globStaticPrefix(pattern string) string {
+	idx := strings.IndexAny(pattern, "*?[{")
+	if idx < 0 {
+		return pattern
+	}
+	return filepath.Dir(pattern[:idx+1])
+}
@@ -0,0 +1,307 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// pathGuardFixture lays out:
+//
+//	<tmp>/work                      base root
+//	<tmp>/work/src/main.go
+//	<tmp>/work/link-out -> ../outside
+//	<tmp>/work/link-in  -> src
+//	<tmp>/work/loop-a   -> loop-b, loop-b -> loop-a
+//	<tmp>/work-other                sibling sharing the root's prefix
+//	<tmp>/outside/secret
+//	<tmp>/outside/link-back -> ../work/src
+//	<tmp>/extra                     additional root
+func pathGuardFixture(t *testing.T) (tmp string, guard *PathGuard) {
+	t.Helper()
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"work/src", "work-other", "outside", "extra"} {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"work/src/main.go", "outside/secret", "work-other/file"} {
+		if err := os.WriteFile(filepath.Join(tmp, file), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"work/link-out":     "../outside",
+		"work/link-in":      "src",
+		"work/loop-a":       "loop-b",
+		"work/loop-b":       "loop-a",
+		"outside/link-back": "../work/src",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(tmp, link)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	guard, err = NewPathGuard(filepath.Join(tmp, "work"), filepath.Join(tmp, "extra"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmp, guard
+}
+
+func TestPathGuard_Check(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+	work := filepath.Join(tmp, "work")
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed bool
+	}{
+		{"relative file", "src/main.go", true},
+		{"dot prefix", "./src/main.go", true},
+		{"absolute file", filepath.Join(work, "src/main.go"), true},
+		{"root itself", work, true},
+		{"root trailing slash", work + "/", true},
+		{"double slashes", work + "//src//main.go", true},
+		{"dotdot staying inside", "src/../src/main.go", true},
+		{"new file", "src/new.go", true},
+		{"new nested dirs", "a/b/c/new.go", true},
+		{"additional root", filepath.Join(tmp, "extra/notes.md"), true},
+		{"symlink within root", "link-in/main.go", true},
+		{"outside symlink into root", filepath.Join(tmp, "outside/link-back/main.go"), true},
+		{"relative escape", "../outside/secret", false},
+		{"deep escape", "src/../../outside/secret", false},
+		{"absolute outside", filepath.Join(tmp, "outside/secret"), false},
+		{"sibling prefix", filepath.Join(tmp, "work-other/file"), false},
+		{"parent of root", tmp, false},
+		{"filesystem root", "/", false},
+		{"symlink escape", "link-out/secret", false},
+		{"new file through escaping symlink", "link-out/new.txt", false},
+		{"dotdot after symlink", "link-in/../../outside/secret", false},
+		{"dotdot after nonexistent", "missing/../../outside/secret", false},
+		{"escape below file", "src/main.go/../../../outside/secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.Check(NameRead, map[string]any{"file_path": tt.path})
+			if tt.allowed && err != nil {
+				t.Fatalf("expected %q to be allowed, got %v", tt.path, err)
+			}
+			if !tt.allowed {
+				if err == nil {
+					t.Fatalf("expected %q to be denied", tt.path)
+				}
+				if !errors.Is(err, ErrPathOutsideRoots) {
+					t.Fatalf("expected ErrPathOutsideRoots, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPathGuard_DotdotAfterSymlinkFollowsTarget(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+
+	// link-out points at <tmp>/outside, so link-out/.. is <tmp>, not <tmp>/work.
+	resolved, contained, err := guard.Contains("link-out/../work/src/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contained {
+		t.Fatalf("expected path to resolve back inside the root, got %s", resolved)
+	}
+	if want := filepath.Join(tmp, "work/src/main.go"); resolved != want {
+		t.Fatalf("resolved = %s, want %s", resolved, want)
+	}
+
+	resolved, contained, err = guard.Contains("link-out/../outside/secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contained {
+		t.Fatalf("expected %s to be outside the roots", resolved)
+	}
+}
+
+func TestPathGuard_SymlinkLoop(t *testing.T) {
+	_, guard := pathGuardFixture(t)
+
+	err := guard.Check(NameRead, map[string]any{"file_path": "loop-a/file"})
+	var violation *PathViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected PathViolationError, got %v", err)
+	}
+	if !strings.Contains(violation.Reason, "too many levels of symbolic links") {
+		t.Fatalf("unexpected reason: %q", violation.Reason)
+	}
+}
+
+func TestPathGuard_NullByte(t *testing.T) {
+	_, guard := pathGuardFixture(t)
+	if err := guard.Check(NameWrite, map[string]any{"file_path": "src/a\x00b", "content": ""}); err == nil {
+		t.Fatal("expected null byte path to be denied")
+	}
+}
+
+func TestPathGuard_ToolCoverage(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+	outside := filepath.Join(tmp, "outside")
+
+	denied := []struct {
+		tool  string
+		input map[string]any
+	}{
+		{NameWrite, map[string]any{"file_path": outside + "/x", "content": "x"}},
+		{NameEdit, map[string]any{"file_path": outside + "/secret", "old_string": "a", "new_string": "b"}},
+		{NameGlob, map[string]any{"pattern": "**/*", "path": outside}},
+		{NameGlob, map[string]any{"pattern": outside + "/**/*.go"}},
+		{NameGrep, map[string]any{"pattern": "password", "path": outside}},
+		{NameGlob, map[string]any{"pattern": "../outside/*"}},
+		{NameGlob, map[string]any{"pattern": "../../outside/*", "path": "src"}},
+		{NameGlob, map[string]any{"pattern": "*/../../outside/secret"}},
+		{NameGlob, map[string]any{"pattern": "~/.ssh/*"}},
+		{NameRead, map[string]any{"file_path": "~/.ssh/id_rsa"}},
+		{NameNotebookEdit, map[string]any{"notebook_path": outside + "/nb.ipynb", "new_source": "x"}},
+	}
+	for _, tt := range denied {
+		if err := guard.Check(tt.tool, tt.input); err == nil {
+			t.Errorf("%s %v: expected denial", tt.tool, tt.input)
+		}
+	}
+
+	allowed := []struct {
+		tool  string
+		input map[string]any
+	}{
+		{NameGlob, map[string]any{"pattern": "**/*.go"}},
+		{NameGrep, map[string]any{"pattern": "TODO"}},
+		{NameGlob, map[string]any{"pattern": filepath.Join(tmp, "work") + "/src/*.go"}},
+		{NameGlob, map[string]any{"pattern": "../*.go", "path": "src"}},
+		{NameNotebookEdit, map[string]any{"notebook_path": "src/nb.ipynb", "new_source": "x"}},
+		{NameBash, map[string]any{"command": "cat " + outside + "/secret"}},
+		{NameWebFetch, map[string]any{"url": "https://example.com", "prompt": "p"}},
+		{"mcp__fs__read", map[string]any{"file_path": outside + "/secret"}},
+	}
+	for _, tt := range allowed {
+		if err := guard.Check(tt.tool, tt.input); err != nil {
+			t.Errorf("%s %v: unexpected denial: %v", tt.tool, tt.input, err)
+		}
+	}
+}
+
+func TestPathGuard_ErrorMessage(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+
+	err := guard.Check(NameRead, map[string]any{"file_path": "link-out/secret"})
+	if err == nil {
+		t.Fatal("expected denial")
+	}
+	msg := err.Error()
+	for _, want := range []string{"Read", "link-out/secret", filepath.Join(tmp, "outside/secret"), filepath.Join(tmp, "work")} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q missing %q", msg, want)
+		}
+	}
+}
+
+func TestPathGuard_CanUseTool(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+
+	var delegated []string
+	callback := guard.CanUseTool(func(toolName string, input map[string]any, ctx *types.ToolPermissionContext) (types.PermissionResult, error) {
+		delegated = append(delegated, toolName)
+		return &types.PermissionResultAllow{Behavior: "allow"}, nil
+	})
+
+	result, err := callback(NameRead, map[string]any{"file_path": filepath.Join(tmp, "outside/secret")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deny, ok := result.(*types.PermissionResultDeny)
+	if !ok {
+		t.Fatalf("expected deny, got %T", result)
+	}
+	if !strings.Contains(deny.Message, "outside allowed directories") {
+		t.Fatalf("unexpected deny message: %q", deny.Message)
+	}
+	if len(delegated) != 0 {
+		t.Fatalf("denied call should not reach next callback")
+	}
+
+	result, err = callback(NameRead, map[string]any{"file_path": "src/main.go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.(*types.PermissionResultAllow); !ok {
+		t.Fatalf("expected allow, got %T", result)
+	}
+	if len(delegated) != 1 || delegated[0] != NameRead {
+		t.Fatalf("expected delegation to next callback, got %v", delegated)
+	}
+
+	result, err = guard.CanUseTool(nil)(NameRead, map[string]any{"file_path": "src/main.go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.(*types.PermissionResultAllow); !ok {
+		t.Fatalf("expected allow with nil next, got %T", result)
+	}
+}
+
+func TestPathGuard_PreToolUseHook(t *testing.T) {
+	tmp, guard := pathGuardFixture(t)
+	hook := guard.PreToolUseHook()
+
+	output, err := hook(map[string]any{
+		"hook_event_name": "PreToolUse",
+		"tool_name":       NameEdit,
+		"tool_input":      map[string]any{"file_path": filepath.Join(tmp, "outside/secret")},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.HookSpecific["permissionDecision"] != "deny" {
+		t.Fatalf("expected deny decision, got %v", output.HookSpecific)
+	}
+
+	output, err = hook(&types.PreToolUseHookInput{
+		ToolName:  NameEdit,
+		ToolInput: map[string]any{"file_path": "src/main.go"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.HookSpecific != nil {
+		t.Fatalf("expected no decision for allowed path, got %#v", output.HookSpecific)
+	}
+}
+
+func TestNewPathGuardFromOptions(t *testing.T) {
+	tmp, _ := pathGuardFixture(t)
+
+	opts := types.DefaultOptions()
+	types.WithCwd(filepath.Join(tmp, "work"))(opts)
+	types.WithAddDirs(filepath.Join(tmp, "extra"))(opts)
+
+	guard, err := NewPathGuardFromOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := guard.Roots()
+	if len(roots) != 2 || roots[0] != filepath.Join(tmp, "work") || roots[1] != filepath.Join(tmp, "extra") {
+		t.Fatalf("unexpected roots: %v", roots)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+// Result is implemented by all typed built-in tool results
+// (PostToolUseHookInput.ToolResponse / UserMessage.ToolUseResult).
+type Result interface {
+	ToolName() string
+}
+
+// StructuredPatchHunk is a single hunk of a file diff reported by Write and Edit.
+type StructuredPatchHunk struct {
+	OldStart int      `json:"oldStart"`
+	OldLines int      `json:"oldLines"`
+	NewStart int      `json:"newStart"`
+	NewLines int      `json:"newLines"`
+	Lines    []string `json:"lines"`
+}
+
+// BashResult is the result of the Bash tool.
+type BashResult struct {
+	Stdout                   string `json:"stdout"`
+	Stderr                   string `json:"stderr"`
+	Interrupted              bool   `json:"interrupted"`
+	IsImage                  bool   `json:"isImage,omitempty"`
+	BackgroundTaskID         string `json:"backgroundTaskId,omitempty"`
+	ReturnCodeInterpretation string `json:"returnCodeInterpretation,omitempty"`
+}
+
+func (r *BashResult) ToolName() string { return NameBash }
+
+// ReadResultFile describes the file payload of a Read result. Text reads
+// populate FilePath/Content/line counts; image reads populate Base64/MediaType.
+type ReadResultFile struct {
+	FilePath     string `json:"filePath,omitempty"`
+	Content      string `json:"content,omitempty"`
+	NumLines     int    `json:"numLines,omitempty"`
+	StartLine    int    `json:"startLine,omitempty"`
+	TotalLines   int    `json:"totalLines,omitempty"`
+	Base64       string `json:"base64,omitempty"`
+	MediaType    string `json:"type,omitempty"`
+	OriginalSize int    `json:"originalSize,omitempty"`
+}
+
+// ReadResult is the result of the Read tool.
+type ReadResult struct {
+	Type string         `json:"type"` // "text", "image", "notebook", "pdf"
+	File ReadResultFile `json:"file"`
+}
+
+func (r *ReadResult) ToolName() string { return NameRead }
+
+// WriteResult is the result of the Write tool.
+type WriteResult struct {
+	Type            string                `json:"type"` // "create" or "update"
+	FilePath        string                `json:"filePath"`
+	Content         string                `json:"content"`
+	StructuredPatch []StructuredPatchHunk `json:"structuredPatch,omitempty"`
+	OriginalFile    *string               `json:"originalFile,omitempty"`
+}
+
+func (r *WriteResult) ToolName() string { return NameWrite }
+
+// EditResult is the result of the Edit tool.
+type EditResult struct {
+	FilePath        string                `json:"filePath"`
+	OldString       string                `json:"oldString"`
+	NewString       string                `json:"newString"`
+	OriginalFile    string                `json:"originalFile,omitempty"`
+	StructuredPatch []StructuredPatchHunk `json:"structuredPatch,omitempty"`
+	UserModified    bool                  `json:"userModified,omitempty"`
+	ReplaceAll      bool                  `json:"replaceAll,omitempty"`
+}
+
+func (r *EditResult) ToolName() string { return NameEdit }
+
+// GlobResult is the result of the Glob tool.
+type GlobResult struct {
+	Filenames  []string `json:"filenames"`
+	NumFiles   int      `json:"numFiles"`
+	DurationMS int      `json:"durationMs,omitempty"`
+	Truncated  bool     `json:"truncated,omitempty"`
+}
+
+func (r *GlobResult) ToolName() string { return NameGlob }
+
+// GrepResult is the result of the Grep tool.
+type GrepResult struct {
+	Mode         string   `json:"mode,omitempty"` // "content", "files_with_matches", "count"
+	Filenames    []string `json:"filenames"`
+	NumFiles     int      `json:"numFiles"`
+	Content      string   `json:"content,omitempty"`
+	NumLines     int      `json:"numLines,omitempty"`
+	NumMatches   int      `json:"numMatches,omitempty"`
+	AppliedLimit *int     `json:"appliedLimit,omitempty"`
+}
+
+func (r *GrepResult) ToolName() string { return NameGrep }
+
+// WebFetchResult is the result of the WebFetch tool.
+type WebFetchResult struct {
+	URL        string `json:"url"`
+	Result     string `json:"result"`
+	Code       int    `json:"code"`
+	CodeText   string `json:"codeText,omitempty"`
+	Bytes      int    `json:"bytes,omitempty"`
+	DurationMS int    `json:"durationMs,omitempty"`
+}
+
+func (r *WebFetchResult) ToolName() string { return NameWebFetch }
+
+// TaskResultContent is a content item returned by a subagent.
+type TaskResultContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// TaskResult is the result of the Task (subagent) tool.
+type TaskResult struct {
+	Status            string              `json:"status,omitempty"`
+	AgentID           string              `json:"agentId,omitempty"`
+	Prompt            string              `json:"prompt,omitempty"`
+	Content           []TaskResultContent `json:"content"`
+	TotalDurationMS   int                 `json:"totalDurationMs,omitempty"`
+	TotalTokens       int                 `json:"totalTokens,omitempty"`
+	TotalToolUseCount int                 `json:"totalToolUseCount,omitempty"`
+	Usage             map[string]any      `json:"usage,omitempty"`
+}
+
+func (r *TaskResult) ToolName() string { return NameTask }
+
+// Text returns the concatenated text content returned by the subagent.
+func (r *TaskResult) Text() string {
+	var result string
+	for _, item := range r.Content {
+		if item.Type == "text" {
+			result += item.Text
+		}
+	}
+	return result
+}
+
+// TodoWriteResult is the result of the TodoWrite tool.
+type TodoWriteResult struct {
+	OldTodos []Todo `json:"oldTodos"`
+	NewTodos []Todo `json:"newTodos"`
+}
+
+func (r *TodoWriteResult) ToolName() string { return NameTodoWrite }
+
+// UnknownResult holds a tool result without a typed model, or a result whose
+// payload is not a JSON object (for example, an error string). Raw is the
+// original value.
+type UnknownResult struct {
+	Name string
+	Raw  any
+}
+
+func (r *UnknownResult) ToolName() string { return r.Name }
@@ -0,0 +1,139 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+// Decode converts a raw tool input map into the struct pointed to by dst.
+func Decode(input map[string]any, dst any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool input: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to decode tool input as %T: %w", dst, err)
+	}
+	return nil
+}
+
+// Encode converts a typed tool input back into a raw map.
+//
+// Keys in original that the typed struct does not model are carried over
+// unchanged, so rewriting an input never drops fields added by newer CLI versions.
+// Keys the struct does model always take the struct's value; clearing an optional
+// field removes it from the result.
+func Encode(v any, original map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tool input: %w", err)
+	}
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to encode tool input: %w", err)
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(v))
+	result := make(map[string]any, len(original)+len(encoded))
+	for k, val := range original {
+		if !known[k] {
+			result[k] = val
+		}
+	}
+	for k, val := range encoded {
+		result[k] = val
+	}
+	return result, nil
+}
+
+// jsonFieldNames returns the JSON keys declared by a struct type's fields.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// Rewrite decodes input into T, applies fn, and encodes the result.
+// The original map is not modified.
+func Rewrite[T any](input map[string]any, fn func(*T) error) (map[string]any, error) {
+	var typed T
+	if err := Decode(input, &typed); err != nil {
+		return nil, err
+	}
+	if err := fn(&typed); err != nil {
+		return nil, err
+	}
+	return Encode(&typed, input)
+}
+
+// RewritePermission rewrites a tool input and wraps it in an allow decision
+// for use as a CanUseToolCallback result.
+func RewritePermission[T any](input map[string]any, fn func(*T) error) (*types.PermissionResultAllow, error) {
+	updated, err := Rewrite(input, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &types.PermissionResultAllow{
+		Behavior:     "allow",
+		UpdatedInput: updated,
+	}, nil
+}
+
+// RewritePreToolUse rewrites a tool input and wraps it in an allow decision
+// for use as a PreToolUse hook output.
+func RewritePreToolUse[T any](input map[string]any, reason string, fn func(*T) error) (*types.HookOutput, error) {
+	updated, err := Rewrite(input, fn)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPreToolUseOutput("allow", reason, updated), nil
+}
+
+// RewritePath applies fn to the path of a file-targeting tool input (Read, Write,
+// Edit, Glob, Grep). It returns the updated input and true, or the original input
+// and false if the tool does not target a path or the input has no path set.
+func RewritePath(toolName string, input map[string]any, fn func(path string) string) (map[string]any, bool, error) {
+	fileInput, ok := newInput(toolName).(FileInput)
+	if !ok {
+		return input, false, nil
+	}
+	if err := Decode(input, fileInput); err != nil {
+		return nil, false, err
+	}
+	if fileInput.Path() == "" {
+		return input, false, nil
+	}
+	fileInput.SetPath(fn(fileInput.Path()))
+	updated, err := Encode(fileInput, input)
+	if err != nil {
+		return nil, false, err
+	}
+	return updated, true, nil
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package tools
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/claude-agent-sdk-go/types"
+)
+
+func TestDecode_BashInput(t *testing.T) {
+	var input BashInput
+	err := Decode(map[string]any{
+		"command":     "git status",
+		"timeout":     float64(30000),
+		"description": "show status",
+	}, &input)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if input.Command != "git status" || input.Description != "show status" {
+		t.Fatalf("unexpected input: %+v", input)
+	}
+	if input.Timeout == nil || *input.Timeout != 30000 {
+		t.Fatalf("unexpected timeout: %v", input.Timeout)
+	}
+}
+
+func TestDecode_TypeMismatch(t *testing.T) {
+	var input ReadInput
+	if err := Decode(map[string]any{"file_path": 42}, &input); err == nil {
+		t.Fatal("expected error for mistyped file_path")
+	}
+}
+
+func TestGrepInput_FlagKeys(t *testing.T) {
+	var input GrepInput
+	if err := Decode(map[string]any{
+		"pattern": "TODO",
+		"-i":      true,
+		"-C":      float64(2),
+	}, &input); err != nil {
+		t.Fatal(err)
+	}
+	if !input.CaseInsensitive || input.Context == nil || *input.Context != 2 {
+		t.Fatalf("unexpected grep input: %+v", input)
+	}
+
+	encoded, err := Encode(&input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded["-i"] != true || encoded["-C"] != float64(2) {
+		t.Fatalf("flag keys not preserved: %+v", encoded)
+	}
+}
+
+func TestRewrite_PreservesUnknownFields(t *testing.T) {
+	original := map[string]any{
+		"command":      "make deploy",
+		"future_field": "keep me",
+	}
+
+	updated, err := Rewrite(original, func(b *BashInput) error {
+		b.Command += " --dry-run"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	if updated["command"] != "make deploy --dry-run" {
+		t.Errorf("unexpected command: %v", updated["command"])
+	}
+	if updated["future_field"] != "keep me" {
+		t.Errorf("unknown field dropped: %+v", updated)
+	}
+	if original["command"] != "make deploy" {
+		t.Errorf("original input was modified: %+v", original)
+	}
+}
+
+func TestRewrite_ClearedOptionalFieldIsRemoved(t *testing.T) {
+	original := map[string]any{
+		"command":           "sleep 100",
+		"run_in_background": true,
+	}
+
+	updated, err := Rewrite(original, func(b *BashInput) error {
+		b.RunInBackground = false
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated["run_in_background"]; ok {
+		t.Errorf("expected run_in_background to be removed, got %+v", updated)
+	}
+}
+
+func TestRewrite_CallbackError(t *testing.T) {
+	wantErr := errors.New("refuse")
+	_, err := Rewrite(map[string]any{"command": "ls"}, func(b *BashInput) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+}
+
+func TestRewritePermission(t *testing.T) {
+	var result types.PermissionResult
+	result, err := RewritePermission(map[string]any{"file_path": "/etc/hosts", "content": "x"}, func(w *WriteInput) error {
+		w.FilePath = filepath.Join("/tmp/scratch", filepath.Base(w.FilePath))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allow, ok := result.(*types.PermissionResultAllow)
+	if !ok {
+		t.Fatalf("expected *PermissionResultAllow, got %T", result)
+	}
+	if allow.Behavior != "allow" {
+		t.Errorf("expected allow behavior, got %q", allow.Behavior)
+	}
+	if allow.UpdatedInput["file_path"] != "/tmp/scratch/hosts" || allow.UpdatedInput["content"] != "x" {
+		t.Errorf("unexpected updated input: %+v", allow.UpdatedInput)
+	}
+}
+
+func TestRewritePreToolUse(t *testing.T) {
+	output, err := RewritePreToolUse(map[string]any{"command": "terraform apply"}, "forced plan", func(b *BashInput) error {
+		b.Command = strings.Replace(b.Command, "apply", "plan", 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.HookSpecific["permissionDecision"] != "allow" {
+		t.Errorf("expected allow decision, got %v", output.HookSpecific["permissionDecision"])
+	}
+	if output.HookSpecific["permissionDecisionReason"] != "forced plan" {
+		t.Errorf("unexpected reason: %v", output.HookSpecific["permissionDecisionReason"])
+	}
+	updated, _ := output.HookSpecific["updatedInput"].(map[string]any)
+	if updated["command"] != "terraform plan" {
+		t.Errorf("unexpected updated input: %+v", updated)
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	redirect := func(p string) string { return filepath.Join("/scratch", filepath.Base(p)) }
+
+	tests := []struct {
+		name     string
+		toolName string
+		input    map[string]any
+		key      string
+		want     any
+		changed  bool
+	}{
+		{"read", NameRead, map[string]any{"file_path": "/src/a.go"}, "file_path", "/scratch/a.go", true},
+		{"write", NameWrite, map[string]any{"file_path": "/src/b.go", "content": ""}, "file_path", "/scratch/b.go", true},
+		{"edit", NameEdit, map[string]any{"file_path": "/src/c.go", "old_string": "a", "new_string": "b"}, "file_path", "/scratch/c.go", true},
+		{"grep", NameGrep, map[string]any{"pattern": "x", "path": "/src"}, "path", "/scratch/src", true},
+		{"glob without path", NameGlob, map[string]any{"pattern": "*.go"}, "path", nil, false},
+		{"bash", NameBash, map[string]any{"command": "ls"}, "command", "ls", false},
+		{"unknown", "mcp__db__query", map[string]any{"sql": "select 1"}, "sql", "select 1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, changed, err := RewritePath(tt.toolName, tt.input, redirect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.changed {
+				t.Fatalf("changed = %v, want %v", changed, tt.changed)
+			}
+			if updated[tt.key] != tt.want {
+				t.Fatalf("%s = %v, want %v", tt.key, updated[tt.key], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transcript rebuilds a conversation from the message stream. It groups
+// messages into turns, links each tool use to its result, and nests the
+// messages of subagents under the tool call that started them:
+//
+//	transcript := types.NewTranscript()
+//	transcript.Add(&types.UserMessage{Content: []types.ContentBlock{&types.TextBlock{TextContent: prompt}}})
+//	for msg := range client.Messages() {
+//		transcript.Add(msg)
+//	}
+//	fmt.Print(transcript.Markdown())
+//
+// The CLI does not echo prompts, so add them as user messages to have them in
+// the transcript. A turn runs up to and including its ResultMessage. A prompt
+// starts a new turn unless the current one has no prompt or assistant message
+// yet, such as when only the init message has arrived.
+//
+// Stream events are ignored; add messages rebuilt with a MessageAccumulator
+// instead. A Transcript is not safe for concurrent use.
+type Transcript struct {
+	turns       []*Turn
+	compactions []Compaction
+	// calls holds the tool calls of this transcript and of its subagents,
+	// since a subagent may itself start one.
+	calls map[string]*ToolCall
+}
+
+// Turn is a prompt and everything the CLI sent in response to it.
+type Turn struct {
+	// Messages holds the turn's messages in the order they arrived,
+	// including Prompt and Result.
+	Messages []Message `json:"messages"`
+	// ToolCalls holds the turn's tool calls in the order they were made.
+	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+	// Prompt is the user message that started the turn, if it was added.
+	Prompt *UserMessage `json:"-"`
+	// Result is the turn's result, or nil while it is running.
+	Result *ResultMessage `json:"-"`
+}
+
+// ToolCall links a tool use to its result and, for tools that run a
+// subagent such as Task, to the subagent's messages.
+type ToolCall struct {
+	Use *ToolUseBlock
+	// Result is nil until the tool's result arrives.
+	Result *ToolResultBlock
+	// Subagent holds the messages sent with this call as their parent tool
+	// use, or is nil if there are none.
+	Subagent *Transcript
+}
+
+// Compaction records a compact boundary and the turn it arrived in.
+type Compaction struct {
+	Turn     int                     `json:"turn"`
+	Boundary *CompactBoundaryMessage `json:"boundary"`
+}
+
+// NewTranscript returns an empty Transcript.
+func NewTranscript() *Transcript {
+	return &Transcript{calls: make(map[string]*ToolCall)}
+}
+
+// Add appends msg to the transcript. Messages with a parent tool use ID are
+// added to the subagent transcript of that tool call.
+func (t *Transcript) Add(msg Message) {
+	if _, ok := msg.(*StreamEvent); ok {
+		return
+	}
+	if parentID := parentToolUseID(msg); parentID != "" {
+		call := t.call(parentID)
+		if call.Subagent == nil {
+			call.Subagent = &Transcript{calls: t.calls}
+		}
+		call.Subagent.add(msg)
+		return
+	}
+	t.add(msg)
+}
+
+func (t *Transcript) add(msg Message) {
+	if m, ok := msg.(*UserMessage); ok && isPrompt(m) {
+		turn := t.current()
+		if turn.Prompt != nil || turn.hasAssistant() {
+			turn = t.newTurn()
+		}
+		turn.Prompt = m
+	}
+
+	turn := t.current()
+	turn.Messages = append(turn.Messages, msg)
+
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		for _, use := range m.ToolCalls() {
+			call := t.call(use.ID)
+			call.Use = use
+			turn.ToolCalls = append(turn.ToolCalls, call)
+		}
+	case *UserMessage:
+		for _, block := range m.Content {
+			if result, ok := block.(*ToolResultBlock); ok {
+				t.call(result.ToolUseID).Result = result
+			}
+		}
+	case *CompactBoundaryMessage:
+		t.compactions = append(t.compactions, Compaction{Turn: len(t.turns) - 1, Boundary: m})
+	case *ResultMessage:
+		turn.Result = m
+	}
+}
+
+// current returns the turn messages are added to, starting one if the last
+// turn has its result.
+func (t *Transcript) current() *Turn {
+	if len(t.turns) == 0 || t.turns[len(t.turns)-1].Result != nil {
+		return t.newTurn()
+	}
+	return t.turns[len(t.turns)-1]
+}
+
+func (t *Transcript) newTurn() *Turn {
+	turn := &Turn{}
+	t.turns = append(t.turns, turn)
+	return turn
+}
+
+// call returns the tool call with the given ID, creating it if its use has
+// not arrived yet.
+func (t *Transcript) call(id string) *ToolCall {
+	call, ok := t.calls[id]
+	if !ok {
+		call = &ToolCall{}
+		t.calls[id] = call
+	}
+	return call
+}
+
+func (turn *Turn) hasAssistant() bool {
+	for _, msg := range turn.Messages {
+		if _, ok := msg.(*AssistantMessage); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrompt reports whether m is a prompt rather than tool results.
+func isPrompt(m *UserMessage) bool {
+	for _, block := range m.Content {
+		if _, ok := block.(*ToolResultBlock); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func parentToolUseID(msg Message) string {
+	var id *string
+	switch m := msg.(type) {
+	case *UserMessage:
+		id = m.ParentToolUseID
+	case *AssistantMessage:
+		id = m.ParentToolUseID
+	}
+	if id == nil {
+		return ""
+	}
+	return *id
+}
+
+// Turns returns the transcript's turns in order.
+func (t *Transcript) Turns() []*Turn {
+	return append([]*Turn(nil), t.turns...)
+}
+
+// ToolCall returns the tool call with the given ID, searching subagents too.
+func (t *Transcript) ToolCall(id string) (*ToolCall, bool) {
+	call, ok := t.calls[id]
+	if !ok || call.Use == nil {
+		return nil, false
+	}
+	return call, true
+}
+
+// Compactions returns the compact boundaries seen, in order.
+func (t *Transcript) Compactions() []Compaction {
+	return append([]Compaction(nil), t.compactions...)
+}
+
+// SinceCompaction returns the turns from the one holding the last compact
+// boundary onward, or all turns if the conversation was never compacted.
+func (t *Transcript) SinceCompaction() []*Turn {
+	if len(t.compactions) == 0 {
+		return t.Turns()
+	}
+	return append([]*Turn(nil), t.turns[t.compactions[len(t.compactions)-1].Turn:]...)
+}
+
+// Done reports whether the tool's result has arrived.
+func (c *ToolCall) Done() bool { return c.Result != nil }
+
+// MarshalJSON encodes the transcript's turns and compactions. Messages use
+// the CLI's stream-json format, and tool calls refer to their blocks by ID.
+func (t *Transcript) MarshalJSON() ([]byte, error) {
+	turns := t.turns
+	if turns == nil {
+		turns = []*Turn{}
+	}
+	return json.Marshal(struct {
+		Turns       []*Turn      `json:"turns"`
+		Compactions []Compaction `json:"compactions,omitempty"`
+	}{turns, t.compactions})
+}
+
+// MarshalJSON encodes the call's ID, name and status, and its subagent.
+func (c *ToolCall) MarshalJSON() ([]byte, error) {
+	out := struct {
+		ID       string      `json:"id"`
+		Name     string      `json:"name"`
+		Done     bool        `json:"done"`
+		IsError  bool        `json:"is_error,omitempty"`
+		Subagent *Transcript `json:"subagent,omitempty"`
+	}{Done: c.Done(), Subagent: c.Subagent}
+	if c.Use != nil {
+		out.ID, out.Name = c.Use.ID, c.Use.Name
+	}
+	if c.Result != nil {
+		out.IsError = c.Result.IsError
+	}
+	return json.Marshal(out)
+}
+
+// Markdown renders the transcript for reading. Subagent messages are quoted
+// below the tool call that started them.
+func (t *Transcript) Markdown() string {
+	var b strings.Builder
+	t.writeMarkdown(&b, true)
+	return b.String()
+}
+
+func (t *Transcript) writeMarkdown(b *strings.Builder, headings bool) {
+	for i, turn := range t.turns {
+		if headings {
+			fmt.Fprintf(b, "## Turn %d\n\n", i+1)
+		}
+		speaker := ""
+		for _, msg := range turn.Messages {
+			switch m := msg.(type) {
+			case *UserMessage:
+				t.writeBlocks(b, "User", m.Content, &speaker)
+			case *AssistantMessage:
+				t.writeBlocks(b, "Assistant", m.Content, &speaker)
+				if m.Error != nil {
+					fmt.Fprintf(b, "*Error: %s*\n\n", *m.Error)
+				}
+			case *CompactBoundaryMessage:
+				fmt.Fprintf(b, "---\n\n*Conversation compacted (%s, %d tokens before)*\n\n",
+					m.CompactMetadata.Trigger, m.CompactMetadata.PreTokens)
+				speaker = ""
+			case *ResultMessage:
+				fmt.Fprintf(b, "*Result: %s", m.Subtype)
+				if m.TotalCostUSD != nil {
+					fmt.Fprintf(b, ", $%.4f", *m.TotalCostUSD)
+				}
+				b.WriteString("*\n\n")
+			}
+		}
+	}
+}
+
+func (t *Transcript) writeBlocks(b *strings.Builder, role string, blocks []ContentBlock, speaker *string) {
+	for _, block := range blocks {
+		switch blk := block.(type) {
+		case *TextBlock:
+			if *speaker != role {
+				fmt.Fprintf(b, "**%s:** ", role)
+				*speaker = role
+			}
+			b.WriteString(blk.TextContent + "\n\n")
+			continue
+		case *ThinkingBlock:
+			b.WriteString(quote("*Thinking:* "+blk.ThinkingContent) + "\n\n")
+		case *ToolUseBlock:
+			input, _ := json.Marshal(blk.ToolInput)
+			fmt.Fprintf(b, "**Tool call** `%s` (`%s`):\n\n%s\n\n", blk.Name, blk.ID, codeBlock("json", string(input)))
+			if call, ok := t.calls[blk.ID]; ok && call.Subagent != nil {
+				var sub strings.Builder
+				call.Subagent.writeMarkdown(&sub, false)
+				b.WriteString(quote(strings.TrimSuffix(sub.String(), "\n\n")) + "\n\n")
+			}
+		case *ToolResultBlock:
+			label := "Tool result"
+			if blk.IsError {
+				label = "Tool error"
+			}
+			fmt.Fprintf(b, "**%s** (`%s`):\n\n%s\n\n", label, blk.ToolUseID, codeBlock("", blk.ResultContent))
+		default:
+			fmt.Fprintf(b, "*[%s]*\n\n", block.BlockType())
+		}
+		*speaker = ""
+	}
+}
+
+// quote prefixes each line of s with a Markdown blockquote marker.
+func quote(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeBlock fences s with more backticks than it contains in a row.
+func codeBlock(lang, s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimSuffix(s, "\n") + "\n" + fence
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func transcriptMessages() []Message {
+	task := "tu_task"
+	cost := 0.02
+	return []Message{
+		&SystemMessage{Subtype: "init", SessionID: "s1"},
+		&UserMessage{Content: []ContentBlock{&TextBlock{TextContent: "Summarize main.go"}}},
+		&AssistantMessage{MessageID: "msg_1", Content: []ContentBlock{&ThinkingBlock{ThinkingContent: "Delegate."}}},
+		&AssistantMessage{MessageID: "msg_1", Content: []ContentBlock{&ToolUseBlock{ID: "tu_task", Name: "Task", ToolInput: map[string]any{"prompt": "Read main.go"}}}},
+		// The subagent's own conversation
+		&UserMessage{ParentToolUseID: &task, Content: []ContentBlock{&TextBlock{TextContent: "Read main.go"}}},
+		&AssistantMessage{ParentToolUseID: &task, Content: []ContentBlock{&ToolUseBlock{ID: "tu_read", Name: "Read", ToolInput: map[string]any{"file_path": "main.go"}}}},
+		&StreamEvent{ParentToolUseID: &task, Event: map[string]any{"type": "ping"}},
+		&UserMessage{ParentToolUseID: &task, Content: []ContentBlock{&ToolResultBlock{ToolUseID: "tu_read", ResultContent: "package main\n```\n"}}},
+		&AssistantMessage{ParentToolUseID: &task, Content: []ContentBlock{&TextBlock{TextContent: "It prints hello."}}},
+		&UserMessage{Content: []ContentBlock{&ToolResultBlock{ToolUseID: "tu_task", ResultContent: "It prints hello."}}},
+		&AssistantMessage{MessageID: "msg_2", Content: []ContentBlock{&TextBlock{TextContent: "main.go prints hello."}}},
+		&ResultMessage{Subtype: "success", SessionID: "s1", TotalCostUSD: &cost},
+		// Second turn, compacted along the way
+		&UserMessage{Content: []ContentBlock{&TextBlock{TextContent: "Run it"}}},
+		&CompactBoundaryMessage{Subtype: "compact_boundary", CompactMetadata: CompactMetadata{Trigger: "auto", PreTokens: 150000}},
+		&AssistantMessage{Content: []ContentBlock{&ToolUseBlock{ID: "tu_bash", Name: "Bash", ToolInput: map[string]any{"command": "go run ."}}}},
+		&UserMessage{Content: []ContentBlock{&ToolResultBlock{ToolUseID: "tu_bash", ResultContent: "exit status 1", IsError: true}}},
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	transcript := NewTranscript()
+	for _, msg := range transcriptMessages() {
+		transcript.Add(msg)
+	}
+
+	turns := transcript.Turns()
+	if len(turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(turns))
+	}
+	first := turns[0]
+	if first.Prompt == nil || first.Prompt.Text() != "Summarize main.go" || first.Result == nil || len(first.Messages) != 7 {
+		t.Fatalf("unexpected first turn: %+v", first)
+	}
+	if len(first.ToolCalls) != 1 || first.ToolCalls[0].Use.Name != "Task" || !first.ToolCalls[0].Done() {
+		t.Fatalf("unexpected first turn tool calls: %+v", first.ToolCalls)
+	}
+
+	subagent := first.ToolCalls[0].Subagent
+	if subagent == nil {
+		t.Fatal("expected subagent messages under the Task call")
+	}
+	subTurns := subagent.Turns()
+	if len(subTurns) != 1 || subTurns[0].Prompt.Text() != "Read main.go" || len(subTurns[0].Messages) != 4 {
+		t.Fatalf("unexpected subagent turns: %+v", subTurns)
+	}
+	read, ok := transcript.ToolCall("tu_read")
+	if !ok || read.Result == nil || read.Result.ResultContent != "package main\n```\n" {
+		t.Errorf("expected the subagent's Read call to be linked, got %+v", read)
+	}
+
+	second := turns[1]
+	if second.Result != nil || len(second.ToolCalls) != 1 || !second.ToolCalls[0].Result.IsError {
+		t.Errorf("unexpected second turn: %+v", second)
+	}
+	compactions := transcript.Compactions()
+	if len(compactions) != 1 || compactions[0].Turn != 1 || compactions[0].Boundary.CompactMetadata.PreTokens != 150000 {
+		t.Errorf("unexpected compactions: %+v", compactions)
+	}
+	if since := transcript.SinceCompaction(); len(since) != 1 || since[0] != second {
+		t.Errorf("expected only the second turn since compaction, got %d turns", len(since))
+	}
+	if _, ok := transcript.ToolCall("missing"); ok {
+		t.Error("expected no tool call for an unknown ID")
+	}
+}
+
+func TestTranscript_TurnBoundaries(t *testing.T) {
+	transcript := NewTranscript()
+	prompt := func(text string) *UserMessage {
+		return &UserMessage{Content: []ContentBlock{&TextBlock{TextContent: text}}}
+	}
+
+	// Results start a new turn even without a prompt
+	transcript.Add(&AssistantMessage{Content: []ContentBlock{&TextBlock{TextContent: "a"}}})
+	transcript.Add(&ResultMessage{Subtype: "success"})
+	transcript.Add(&AssistantMessage{Content: []ContentBlock{&TextBlock{TextContent: "b"}}})
+	// A prompt after assistant output starts a new turn
+	transcript.Add(prompt("one"))
+	// A second prompt before any answer does too
+	transcript.Add(prompt("two"))
+
+	turns := transcript.Turns()
+	if len(turns) != 4 {
+		t.Fatalf("expected 4 turns, got %d", len(turns))
+	}
+	if turns[1].Prompt != nil || turns[2].Prompt.Text() != "one" || turns[3].Prompt.Text() != "two" {
+		t.Errorf("unexpected prompts: %+v", turns)
+	}
+	if since := transcript.SinceCompaction(); len(since) != 4 {
+		t.Errorf("expected all turns without compaction, got %d", len(since))
+	}
+}
+
+func TestTranscript_Markdown(t *testing.T) {
+	transcript := NewTranscript()
+	for _, msg := range transcriptMessages() {
+		transcript.Add(msg)
+	}
+	got := transcript.Markdown()
+
+	for _, want := range []string{
+		"## Turn 1\n\n**User:** Summarize main.go\n\n> *Thinking:* Delegate.\n\n**Tool call** `Task` (`tu_task`):",
+		"> **User:** Read main.go\n>\n> **Tool call** `Read` (`tu_read`):\n>\n> ```json\n> {\"file_path\":\"main.go\"}\n> ```",
+		"> **Tool result** (`tu_read`):\n>\n> ````\n> package main\n> ```\n> ````",
+		"**Assistant:** main.go prints hello.\n\n*Result: success, $0.0200*\n\n## Turn 2",
+		"---\n\n*Conversation compacted (auto, 150000 tokens before)*",
+		"**Tool error** (`tu_bash`):\n\n```\nexit status 1\n```\n\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected Markdown to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTranscript_MarshalJSON(t *testing.T) {
+	transcript := NewTranscript()
+	data, err := json.Marshal(transcript)
+	if err != nil || string(data) != `{"turns":[]}` {
+		t.Fatalf("got %s, %v for an empty transcript", data, err)
+	}
+
+	for _, msg := range transcriptMessages() {
+		transcript.Add(msg)
+	}
+	data, err = json.Marshal(transcript)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Turns []struct {
+			Messages  []json.RawMessage `json:"messages"`
+			ToolCalls []struct {
+				ID       string `json:"id"`
+				Name     string `json:"name"`
+				Done     bool   `json:"done"`
+				IsError  bool   `json:"is_error"`
+				Subagent *struct {
+					Turns []struct {
+						Messages []json.RawMessage `json:"messages"`
+					} `json:"turns"`
+				} `json:"subagent"`
+			} `json:"tool_calls"`
+		} `json:"turns"`
+		Compactions []Compaction `json:"compactions"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(decoded.Turns) != 2 || len(decoded.Compactions) != 1 || decoded.Compactions[0].Turn != 1 {
+		t.Fatalf("unexpected transcript JSON: %s", data)
+	}
+	task := decoded.Turns[0].ToolCalls[0]
+	if task.ID != "tu_task" || task.Name != "Task" || !task.Done || task.Subagent == nil || len(task.Subagent.Turns[0].Messages) != 4 {
+		t.Errorf("unexpected Task call JSON: %+v", task)
+	}
+	if bash := decoded.Turns[1].ToolCalls[0]; !bash.Done || !bash.IsError {
+		t.Errorf("unexpected Bash call JSON: %+v", bash)
+	}
+
+	// Messages are in the stream-json format
+	msg, err := UnmarshalMessage(decoded.Turns[0].Messages[1])
+	if err != nil {
+		t.Fatalf("UnmarshalMessage failed: %v", err)
+	}
+	if user, ok := msg.(*UserMessage); !ok || user.Text() != "Summarize main.go" {
+		t.Errorf("unexpected message: %#v", msg)
+	}
+}
@@ -5,6 +5,7 @@ package types
 
 import (
 	"context"
+	"encoding/json"
 )
 
 // Transport defines the interface for communicating with Claude.
@@ -36,6 +37,43 @@ type ErrorTransport interface {
 	Errors() <-chan error
 }
 
+// WireMessage is a message from the CLI together with the JSON it was decoded from.
+type WireMessage struct {
+	// Message is the decoded message, as delivered by Messages.
+	Message map[string]any
+	// JSON is the message exactly as the CLI wrote it.
+	JSON json.RawMessage
+}
+
+// WireMessageTransport is an optional transport extension delivering each
+// message with its original JSON, so Options.RetainRawJSON can keep it
+// byte for byte instead of re-encoding the decoded map.
+//
+// WireMessages and Messages read the same stream: a consumer uses one of them.
+// Query uses WireMessages when a transport implements it, unless it returns nil,
+// which wrapping transports do when the transport they wrap lacks it.
+type WireMessageTransport interface {
+	WireMessages() <-chan WireMessage
+}
+
+// ExitErrorTransport is an optional transport extension reporting why the
+// underlying process or connection ended.
+//
+// Query consults ExitError after the transport closes its message stream, so
+// transports should set it before closing Messages(). It returns nil for a clean
+// shutdown.
+type ExitErrorTransport interface {
+	ExitError() error
+}
+
+// ResourceLimitTransport is an optional transport extension reporting the
+// resource limits in effect for the CLI process.
+type ResourceLimitTransport interface {
+	// ResourceLimits returns the limits read back from the running process,
+	// with zero meaning unlimited, or nil if no limits were requested.
+	ResourceLimits() *ResourceLimits
+}
+
 // LegacyTransport represents the transitional interface shape where Transport also
 // included Errors(). It is kept as a compatibility shim.
 //
@@ -0,0 +1,247 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import "sync"
+
+// Usage is the token usage the API reports for a message or a query.
+type Usage struct {
+	InputTokens              int                `json:"input_tokens"`
+	OutputTokens             int                `json:"output_tokens"`
+	CacheCreationInputTokens int                `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int                `json:"cache_read_input_tokens"`
+	CacheCreation            CacheCreationUsage `json:"cache_creation"`
+	ServerToolUse            ServerToolUsage    `json:"server_tool_use"`
+	ServiceTier              string             `json:"service_tier,omitempty"`
+}
+
+// CacheCreationUsage breaks cache writes down by cache lifetime.
+type CacheCreationUsage struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
+}
+
+// ServerToolUsage counts requests made by server tools.
+type ServerToolUsage struct {
+	WebSearchRequests int `json:"web_search_requests"`
+	WebFetchRequests  int `json:"web_fetch_requests"`
+}
+
+// ParseUsage reads a usage payload. Missing fields are zero.
+func ParseUsage(raw map[string]any) Usage {
+	usage := Usage{
+		InputTokens:              getInt(raw, "input_tokens"),
+		OutputTokens:             getInt(raw, "output_tokens"),
+		CacheCreationInputTokens: getInt(raw, "cache_creation_input_tokens"),
+		CacheReadInputTokens:     getInt(raw, "cache_read_input_tokens"),
+		ServiceTier:              getString(raw, "service_tier"),
+	}
+	if cache, ok := raw["cache_creation"].(map[string]any); ok {
+		usage.CacheCreation = CacheCreationUsage{
+			Ephemeral5mInputTokens: getInt(cache, "ephemeral_5m_input_tokens"),
+			Ephemeral1hInputTokens: getInt(cache, "ephemeral_1h_input_tokens"),
+		}
+	}
+	if serverTools, ok := raw["server_tool_use"].(map[string]any); ok {
+		usage.ServerToolUse = ServerToolUsage{
+			WebSearchRequests: getInt(serverTools, "web_search_requests"),
+			WebFetchRequests:  getInt(serverTools, "web_fetch_requests"),
+		}
+	}
+	return usage
+}
+
+// TotalTokens returns the input, output and cache tokens together.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// Add returns the sum of u and other. The service tier is kept from u unless it is empty.
+func (u Usage) Add(other Usage) Usage {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+	u.CacheCreation.Ephemeral5mInputTokens += other.CacheCreation.Ephemeral5mInputTokens
+	u.CacheCreation.Ephemeral1hInputTokens += other.CacheCreation.Ephemeral1hInputTokens
+	u.ServerToolUse.WebSearchRequests += other.ServerToolUse.WebSearchRequests
+	u.ServerToolUse.WebFetchRequests += other.ServerToolUse.WebFetchRequests
+	if u.ServiceTier == "" {
+		u.ServiceTier = other.ServiceTier
+	}
+	return u
+}
+
+// resultUsage returns the result's usage typed, parsing Usage when only the
+// map was set, or nil if it has none.
+func resultUsage(m *ResultMessage) *Usage {
+	if m.TokenUsage != nil || m.Usage == nil {
+		return m.TokenUsage
+	}
+	usage := ParseUsage(m.Usage)
+	return &usage
+}
+
+// UsageSummary is the usage a UsageTracker has seen.
+type UsageSummary struct {
+	// Usage sums the usage of completed queries and of the assistant
+	// messages of queries still running.
+	Usage Usage
+	// CostUSD sums the cost of completed queries.
+	CostUSD float64
+	// ModelUsage sums the per-model usage of completed queries.
+	ModelUsage map[string]ModelUsage
+	// Results counts completed queries, and Sessions the sessions they ran in.
+	Results  int
+	Sessions int
+}
+
+// UsageTracker sums token usage and cost across turns and sessions. Feed it
+// every message, for example from several clients:
+//
+//	tracker := types.NewUsageTracker()
+//	for msg := range client.Messages() {
+//		tracker.Add(msg)
+//	}
+//	summary := tracker.Summary()
+//	fmt.Printf("$%.4f, %d tokens\n", summary.CostUSD, summary.Usage.TotalTokens())
+//
+// Assistant messages count toward a query while it runs. The CLI sends one
+// assistant message per content block, all carrying the usage of the whole
+// API response, so each response is counted once by its message ID. When
+// the query's ResultMessage arrives, its usage replaces what its assistant
+// messages added, since it is the CLI's authoritative total, and its
+// TotalCostUSD and ModelUsage are added. A result without usage is counted
+// from its ModelUsage, and one without a cost is costed from it.
+//
+// A UsageTracker is safe for concurrent use.
+type UsageTracker struct {
+	mu       sync.Mutex
+	counted  map[string]map[string]bool // message IDs counted, by session
+	running  map[string]Usage
+	total    Usage
+	costUSD  float64
+	models   map[string]ModelUsage
+	results  int
+	sessions map[string]bool
+}
+
+// NewUsageTracker returns an empty UsageTracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		counted:  make(map[string]map[string]bool),
+		running:  make(map[string]Usage),
+		models:   make(map[string]ModelUsage),
+		sessions: make(map[string]bool),
+	}
+}
+
+// Add records the usage carried by msg. Messages without usage are ignored.
+func (t *UsageTracker) Add(msg Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		if m.Usage == nil {
+			return
+		}
+		if m.MessageID != "" {
+			if t.counted[m.SessionID][m.MessageID] {
+				return
+			}
+			if t.counted[m.SessionID] == nil {
+				t.counted[m.SessionID] = make(map[string]bool)
+			}
+			t.counted[m.SessionID][m.MessageID] = true
+		}
+		t.running[m.SessionID] = t.running[m.SessionID].Add(*m.Usage)
+
+	case *ResultMessage:
+		switch usage := resultUsage(m); {
+		case usage != nil:
+			t.total = t.total.Add(*usage)
+		case len(m.ModelUsage) > 0:
+			t.total = t.total.Add(usageFromModels(m.ModelUsage))
+		default:
+			t.total = t.total.Add(t.running[m.SessionID])
+		}
+		delete(t.running, m.SessionID)
+		delete(t.counted, m.SessionID)
+
+		modelCost := 0.0
+		for model, usage := range m.ModelUsage {
+			t.models[model] = addModelUsage(t.models[model], usage)
+			modelCost += usage.CostUSD
+		}
+		if m.TotalCostUSD != nil {
+			t.costUSD += *m.TotalCostUSD
+		} else {
+			t.costUSD += modelCost
+		}
+		t.results++
+		t.sessions[m.SessionID] = true
+	}
+}
+
+// Summary returns the usage seen so far.
+func (t *UsageTracker) Summary() UsageSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := UsageSummary{
+		Usage:      t.total,
+		CostUSD:    t.costUSD,
+		ModelUsage: make(map[string]ModelUsage, len(t.models)),
+		Results:    t.results,
+		Sessions:   len(t.sessions),
+	}
+	for _, usage := range t.running {
+		summary.Usage = summary.Usage.Add(usage)
+	}
+	for model, usage := range t.models {
+		summary.ModelUsage[model] = usage
+	}
+	return summary
+}
+
+// Reset discards everything the tracker has seen.
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counted = make(map[string]map[string]bool)
+	t.running = make(map[string]Usage)
+	t.total = Usage{}
+	t.costUSD = 0
+	t.models = make(map[string]ModelUsage)
+	t.results = 0
+	t.sessions = make(map[string]bool)
+}
+
+// addModelUsage sums token counts and costs. Limits such as the context
+// window are properties of the model, so the larger is kept.
+func addModelUsage(a, b ModelUsage) ModelUsage {
+	a.InputTokens += b.InputTokens
+	a.OutputTokens += b.OutputTokens
+	a.CacheReadInputTokens += b.CacheReadInputTokens
+	a.CacheCreationInputTokens += b.CacheCreationInputTokens
+	a.WebSearchRequests += b.WebSearchRequests
+	a.CostUSD += b.CostUSD
+	a.ContextWindow = max(a.ContextWindow, b.ContextWindow)
+	a.MaxOutputTokens = max(a.MaxOutputTokens, b.MaxOutputTokens)
+	return a
+}
+
+// usageFromModels sums the token counts of per-model usage.
+func usageFromModels(models map[string]ModelUsage) Usage {
+	var usage Usage
+	for _, model := range models {
+		usage.InputTokens += model.InputTokens
+		usage.OutputTokens += model.OutputTokens
+		usage.CacheReadInputTokens += model.CacheReadInputTokens
+		usage.CacheCreationInputTokens += model.CacheCreationInputTokens
+		usage.ServerToolUse.WebSearchRequests += model.WebSearchRequests
+	}
+	return usage
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2025 Claude Agent SDK Go Contributors
+// SPDX-License-Identifier: GPL-3.0-only
+
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUsage(t *testing.T) {
+	got := ParseUsage(map[string]any{
+		"input_tokens":                float64(12),
+		"output_tokens":               float64(30),
+		"cache_creation_input_tokens": float64(5),
+		"cache_read_input_tokens":     float64(100),
+		"cache_creation":              map[string]any{"ephemeral_5m_input_tokens": float64(5)},
+		"server_tool_use":             map[string]any{"web_search_requests": float64(2)},
+		"service_tier":                "standard",
+	})
+	want := Usage{
+		InputTokens:              12,
+		OutputTokens:             30,
+		CacheCreationInputTokens: 5,
+		CacheReadInputTokens:     100,
+		CacheCreation:            CacheCreationUsage{Ephemeral5mInputTokens: 5},
+		ServerToolUse:            ServerToolUsage{WebSearchRequests: 2},
+		ServiceTier:              "standard",
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got.TotalTokens() != 147 {
+		t.Errorf("expected 147 total tokens, got %d", got.TotalTokens())
+	}
+
+	sum := got.Add(Usage{InputTokens: 1, ServerToolUse: ServerToolUsage{WebFetchRequests: 1}, ServiceTier: "priority"})
+	if sum.InputTokens != 13 || sum.ServerToolUse != (ServerToolUsage{WebSearchRequests: 2, WebFetchRequests: 1}) || sum.ServiceTier != "standard" {
+		t.Errorf("unexpected sum: %+v", sum)
+	}
+
+	if resultUsage(&ResultMessage{}) != nil {
+		t.Error("expected nil usage for a result without usage")
+	}
+
+	result, err := parseMessage(map[string]any{
+		"type":       "result",
+		"subtype":    "success",
+		"session_id": "s1",
+		"usage":      map[string]any{"input_tokens": float64(3), "output_tokens": float64(4)},
+	})
+	if err != nil {
+		t.Fatalf("parseMessage failed: %v", err)
+	}
+	usage := result.(*ResultMessage).TokenUsage
+	if usage == nil || usage.InputTokens != 3 || usage.OutputTokens != 4 {
+		t.Errorf("expected typed usage from the parser, got %+v", usage)
+	}
+	data, err := json.Marshal(&ResultMessage{Subtype: "success", TokenUsage: &Usage{InputTokens: 5}})
+	if err != nil || !strings.Contains(string(data), `"input_tokens":5`) {
+		t.Errorf("expected TokenUsage to be marshalled when Usage is unset, got %s, %v", data, err)
+	}
+}
+
+func TestUsageTracker(t *testing.T) {
+	tracker := NewUsageTracker()
+	assistant := func(session, id string, input, output int) *AssistantMessage {
+		return &AssistantMessage{SessionID: session, MessageID: id, Usage: &Usage{InputTokens: input, OutputTokens: output}}
+	}
+
+	// Content blocks of one response share its usage and are counted once
+	tracker.Add(assistant("s1", "msg_1", 10, 5))
+	tracker.Add(assistant("s1", "msg_1", 10, 5))
+	tracker.Add(assistant("s1", "msg_2", 20, 7))
+	tracker.Add(&AssistantMessage{SessionID: "s1"})
+	if got := tracker.Summary().Usage; got != (Usage{InputTokens: 30, OutputTokens: 12}) {
+		t.Errorf("unexpected running usage: %+v", got)
+	}
+
+	// The result's usage replaces the running usage
+	cost := 0.25
+	tracker.Add(&ResultMessage{
+		SessionID:    "s1",
+		TotalCostUSD: &cost,
+		Usage:        map[string]any{"input_tokens": float64(31), "output_tokens": float64(12)},
+		ModelUsage:   map[string]ModelUsage{"sonnet": {InputTokens: 31, OutputTokens: 12, CostUSD: 0.25, ContextWindow: 200000}},
+	})
+	// A result without usage or cost is reconciled from its model usage
+	tracker.Add(assistant("s2", "msg_1", 1, 1))
+	tracker.Add(&ResultMessage{
+		SessionID: "s2",
+		ModelUsage: map[string]ModelUsage{
+			"sonnet": {InputTokens: 4, OutputTokens: 2, CostUSD: 0.5, ContextWindow: 1000000},
+			"haiku":  {InputTokens: 3, OutputTokens: 1, CostUSD: 0.125},
+		},
+	})
+	// The same message ID in another session is another response
+	tracker.Add(assistant("s3", "msg_1", 2, 2))
+
+	summary := tracker.Summary()
+	want := UsageSummary{
+		Usage:   Usage{InputTokens: 40, OutputTokens: 17},
+		CostUSD: 0.875,
+		ModelUsage: map[string]ModelUsage{
+			"sonnet": {InputTokens: 35, OutputTokens: 14, CostUSD: 0.75, ContextWindow: 1000000},
+			"haiku":  {InputTokens: 3, OutputTokens: 1, CostUSD: 0.125},
+		},
+		Results:  2,
+		Sessions: 2,
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("got %+v, want %+v", summary, want)
+	}
+
+	// A resumed session counts once
+	tracker.Add(&ResultMessage{SessionID: "s1", Usage: map[string]any{"input_tokens": float64(1)}})
+	if summary := tracker.Summary(); summary.Results != 3 || summary.Sessions != 2 || summary.Usage.InputTokens != 41 {
+		t.Errorf("unexpected summary after resume: %+v", summary)
+	}
+
+	tracker.Reset()
+	if summary := tracker.Summary(); !reflect.DeepEqual(summary, UsageSummary{ModelUsage: map[string]ModelUsage{}}) {
+		t.Errorf("expected an empty summary after Reset, got %+v", summary)
+	}
+}
+
+func TestParseMessage_AssistantUsage(t *testing.T) {
+	msg, err := parseMessage(map[string]any{
+		"type": "assistant",
+		"message": map[string]any{
+			"id":      "msg_1",
+			"model":   "claude-sonnet-4-5",
+			"content": []any{},
+			"usage":   map[string]any{"input_tokens": float64(3), "output_tokens": float64(9)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseMessage failed: %v", err)
+	}
+	assistant := msg.(*AssistantMessage)
+	if assistant.MessageID != "msg_1" || assistant.Usage == nil || *assistant.Usage != (Usage{InputTokens: 3, OutputTokens: 9}) {
+		t.Errorf("unexpected assistant message: %+v", assistant)
+	}
+}